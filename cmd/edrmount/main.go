@@ -4,6 +4,7 @@ import (
 	"context"
 	"flag"
 	"log"
+	"net"
 	"net/http"
 	"os"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/gaby/EDRmount/internal/fusefs"
 	"github.com/gaby/EDRmount/internal/health"
 	"github.com/gaby/EDRmount/internal/runner"
+	"github.com/gaby/EDRmount/internal/streamer"
 	"github.com/gaby/EDRmount/internal/watch"
 )
 
@@ -35,6 +37,14 @@ func main() {
 	if err := cfg.Validate(); err != nil {
 		log.Fatalf("config validate: %v", err)
 	}
+	if err := cfg.ValidateTools(); err != nil {
+		log.Fatalf("config validate: %v", err)
+	}
+	for _, t := range cfg.Tools() {
+		if t.Required && !t.OK {
+			log.Printf("WARN: %s binary %s is %s (needed by %s)", t.Name, t.Path, t.Error, t.RequiredBy)
+		}
+	}
 
 	dbPath := "/config/edrmount.db"
 	// One-shot DB reset marker (created by API/UI): delete ONLY the DB files, keep config.json.
@@ -61,14 +71,26 @@ func main() {
 	defer cancel()
 	if srvJobs := srv.Jobs(); srvJobs != nil {
 		// Start watchers (NZB/media) and runner (job executor) independently.
-		if cfg.Watch.NZB.Enabled || cfg.Watch.Media.Enabled {
-			w := watch.New(srvJobs, cfg.Watch.NZB, cfg.Watch.Media)
+		// runner.roles: no NZB watching without imports, no media watching without uploads.
+		nzbWatch, mediaWatch := cfg.Watch.NZB, cfg.Watch.Media
+		if !cfg.Runner.HasRole(config.RoleImport) && nzbWatch.Enabled {
+			nzbWatch.Enabled = false
+			log.Printf("watch.nzb disabled: runner.roles has no import role")
+		}
+		if !cfg.Runner.HasRole(config.RoleUpload) && mediaWatch.Enabled {
+			mediaWatch.Enabled = false
+			log.Printf("watch.media disabled: runner.roles has no upload role")
+		}
+		if nzbWatch.Enabled || mediaWatch.Enabled {
+			w := watch.New(srvJobs, nzbWatch, mediaWatch)
+			w.NZBExtraDirs = []string{cfg.Upload.MoviesOutputDir, cfg.Upload.SeriesOutputDir}
 			go w.Run(ctx)
 		}
 
 		if cfg.Runner.Enabled {
 			r := runner.New(srvJobs)
 			r.Mode = cfg.Runner.Mode
+			r.ImportConcurrency = cfg.Runner.ImportConcurrency
 			r.GetConfig = srv.Config
 			go r.Run(ctx)
 		}
@@ -76,6 +98,7 @@ func main() {
 		// Backup scheduler (reads latest config from the API server)
 		sched := &backup.Scheduler{
 			DBPath: dbPath,
+			Jobs:   srvJobs,
 			Cfg: func() backup.Config {
 				c := srv.Config().Backups
 				return backup.Config{
@@ -84,6 +107,9 @@ func main() {
 					EveryMins:  c.EveryMins,
 					Keep:       c.Keep,
 					CompressGZ: c.CompressGZ,
+					RetryMins:  c.RetryMins,
+					AlertAfter: c.AlertAfterFailures,
+					WebhookURL: c.WebhookURL,
 				}
 			},
 		}
@@ -93,7 +119,12 @@ func main() {
 		hs := &health.Scheduler{
 			Jobs: srvJobs,
 			Cfg: func() config.HealthConfig {
-				return srv.Config().Health
+				c := srv.Config()
+				h := c.Health
+				if !c.Runner.HasRole(config.RoleHealth) {
+					h.Enabled = false
+				}
+				return h
 			},
 		}
 		go hs.Run(ctx)
@@ -111,11 +142,31 @@ func main() {
 					log.Printf("FUSE library-manual mounted at %s/library-manual", cfg.Paths.MountPoint)
 				}
 			}
+			if cfg.Library.MountRaw {
+				if _, err := fusefs.MountRaw(ctx, cfg, srvJobs); err != nil {
+					log.Printf("FUSE raw mount failed: %v", err)
+				} else {
+					log.Printf("FUSE raw mounted at %s/raw", cfg.Paths.MountPoint)
+				}
+			}
+			if cfg.Library.MountCollections {
+				if _, err := fusefs.MountCollections(ctx, cfg, srvJobs); err != nil {
+					log.Printf("FUSE collections mount failed: %v", err)
+				} else {
+					log.Printf("FUSE collections mounted at %s/collections", cfg.Paths.MountPoint)
+				}
+			}
 		}
 	}
 
+	ln, err := net.Listen("tcp", cfg.Server.Addr)
+	if err != nil {
+		log.Fatalf("server: %v", err)
+	}
 	log.Printf("EDRmount listening on %s", cfg.Server.Addr)
-	if err := http.ListenAndServe(cfg.Server.Addr, srv.Handler()); err != nil {
+	// Warm the segment cache for recently played titles once the API is reachable.
+	go streamer.WarmRecent(ctx, srv.Config(), srv.Jobs())
+	if err := http.Serve(ln, srv.Handler()); err != nil {
 		log.Fatalf("server: %v", err)
 	}
 }
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// withAuth enforces server.auth on every route except /live (container health checks).
+// The config is read per request, so changes saved from the UI apply immediately.
+func (s *Server) withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/live" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		a := s.Config().Server.Auth
+		switch a.Mode {
+		case "basic":
+			u, p, ok := r.BasicAuth()
+			if ok && secretEqual(u, a.User) && secretEqual(p, a.Pass) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("WWW-Authenticate", `Basic realm="EDRmount", charset="UTF-8"`)
+		case "token":
+			tok := r.URL.Query().Get("token")
+			if h := r.Header.Get("Authorization"); len(h) > 7 && strings.EqualFold(h[:7], "Bearer ") {
+				tok = strings.TrimSpace(h[7:])
+			}
+			if tok != "" && secretEqual(tok, a.Token) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="EDRmount"`)
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "unauthorized"})
+	})
+}
+
+// secretEqual compares in constant time; hashing first hides the length of the secret.
+func secretEqual(got, want string) bool {
+	if want == "" {
+		return false
+	}
+	g := sha256.Sum256([]byte(got))
+	h := sha256.Sum256([]byte(want))
+	return subtle.ConstantTimeCompare(g[:], h[:]) == 1
+}
@@ -47,6 +47,38 @@ func (s *Server) registerBackupRoutes(dbPath string) {
 		_ = json.NewEncoder(w).Encode(map[string]any{"dir": cfg.Backups.Dir, "items": out})
 	})
 
+	// GET /api/v1/backups/status
+	// Last success/failure, current failure streak and recent attempts from backup_runs.
+	s.mux.HandleFunc("/api/v1/backups/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if s.jobs == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "db not configured"})
+			return
+		}
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		st, err := s.jobs.BackupStatus(r.Context(), 20)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		cfg := s.Config().Backups
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"enabled":              cfg.Enabled && cfg.EveryMins > 0,
+			"every_mins":           cfg.EveryMins,
+			"alert_after_failures": cfg.AlertAfterFailures,
+			"escalated":            cfg.AlertAfterFailures > 0 && st.ConsecutiveFailures >= cfg.AlertAfterFailures,
+			"last_success":         st.LastSuccess,
+			"last_failure":         st.LastFailure,
+			"consecutive_failures": st.ConsecutiveFailures,
+			"recent":               st.Recent,
+		})
+	})
+
 	// Backup now
 	s.mux.HandleFunc("/api/v1/backups/run", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -64,7 +96,11 @@ func (s *Server) registerBackupRoutes(dbPath string) {
 		}
 
 		cfg := s.Config()
+		started := time.Now()
 		path, err := backup.RunOnce(r.Context(), dbPath, cfg.Backups.Dir, cfg.Backups.CompressGZ)
+		if s.jobs != nil {
+			_ = s.jobs.RecordBackupRun(r.Context(), "manual", started, path, err)
+		}
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
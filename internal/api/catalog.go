@@ -1,9 +1,12 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"time"
+
+	"github.com/gaby/EDRmount/internal/thumbs"
 )
 
 type importRow struct {
@@ -12,6 +15,22 @@ type importRow struct {
 	ImportedAt string `json:"imported_at"`
 	FilesCount int    `json:"files_count"`
 	TotalBytes int64  `json:"total_bytes"`
+
+	// NeedsExtraction marks archive releases (RAR volumes) that can't be streamed as-is.
+	NeedsExtraction bool `json:"needs_extraction"`
+
+	Notes string `json:"notes,omitempty"`
+
+	// From the NZB <head>: category, and whether it declares an archive password.
+	Category    string `json:"category,omitempty"`
+	HasPassword bool   `json:"has_password"`
+}
+
+// catalogSortColumns backs ?sort= on GET /api/v1/catalog/imports (default: newest first).
+var catalogSortColumns = map[string]string{
+	"name": "path",
+	"size": "total_bytes",
+	"date": "imported_at",
 }
 
 func (s *Server) registerCatalogRoutes() {
@@ -24,7 +43,13 @@ func (s *Server) registerCatalogRoutes() {
 		}
 		switch r.Method {
 		case http.MethodGet:
-			rows, err := s.jobs.DB().SQL.QueryContext(r.Context(), `SELECT id,path,imported_at,files_count,total_bytes FROM nzb_imports ORDER BY imported_at DESC LIMIT 50`)
+			orderBy, err := listOrderBy(r, catalogSortColumns, "id", "imported_at DESC")
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+			rows, err := s.jobs.DB().SQL.QueryContext(r.Context(), `SELECT id,path,imported_at,files_count,total_bytes,needs_extraction,notes,nzb_category,nzb_has_password<>0 FROM nzb_imports `+orderBy+` LIMIT 50`)
 			if err != nil {
 				w.WriteHeader(http.StatusInternalServerError)
 				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
@@ -37,10 +62,13 @@ func (s *Server) registerCatalogRoutes() {
 				var tUnix int64
 				var fc int
 				var tb int64
-				if err := rows.Scan(&id, &path, &tUnix, &fc, &tb); err != nil {
+				var ne int
+				var notes, category string
+				var pw bool
+				if err := rows.Scan(&id, &path, &tUnix, &fc, &tb, &ne, &notes, &category, &pw); err != nil {
 					continue
 				}
-				out = append(out, importRow{ID: id, Path: path, ImportedAt: time.Unix(tUnix, 0).Format(time.RFC3339), FilesCount: fc, TotalBytes: tb})
+				out = append(out, importRow{ID: id, Path: path, ImportedAt: time.Unix(tUnix, 0).Format(time.RFC3339), FilesCount: fc, TotalBytes: tb, NeedsExtraction: ne != 0, Notes: notes, Category: category, HasPassword: pw})
 			}
 			_ = json.NewEncoder(w).Encode(out)
 		default:
@@ -76,35 +104,48 @@ func (s *Server) registerCatalogRoutes() {
 		}
 
 		// Delete import and all associated DB rows. Does NOT delete the NZB file on disk.
-		tx, err := s.jobs.DB().SQL.BeginTx(r.Context(), nil)
-		if err != nil {
+		if err := s.deleteImport(r.Context(), id); err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 			return
 		}
-		defer func() { _ = tx.Rollback() }()
-
-		stmts := []string{
-			`DELETE FROM nzb_segments WHERE import_id=?`,
-			`DELETE FROM nzb_files WHERE import_id=?`,
-			`DELETE FROM library_overrides WHERE import_id=?`,
-			`DELETE FROM library_review_dismissed WHERE import_id=?`,
-			`DELETE FROM library_resolved WHERE import_id=?`,
-			`DELETE FROM manual_items WHERE import_id=?`,
-			`DELETE FROM nzb_imports WHERE id=?`,
+		pruned := 0
+		if s.Config().Library.PruneManualDirs {
+			pruned, _ = s.jobs.PruneEmptyManualDirs(r.Context())
 		}
-		for _, s := range stmts {
-			if _, err := tx.ExecContext(r.Context(), s, id); err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
-				return
-			}
-		}
-		if err := tx.Commit(); err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
-			return
-		}
-		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "pruned_dirs": pruned})
 	})
 }
+
+// deleteImport removes an import and every row that refers to it in one transaction,
+// then drops its cached thumbnails. The NZB file itself is left alone.
+func (s *Server) deleteImport(ctx context.Context, id string) error {
+	tx, err := s.jobs.DB().SQL.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	stmts := []string{
+		`DELETE FROM nzb_segments WHERE import_id=?`,
+		`DELETE FROM nzb_files WHERE import_id=?`,
+		`DELETE FROM library_overrides WHERE import_id=?`,
+		`DELETE FROM library_review_dismissed WHERE import_id=?`,
+		`DELETE FROM library_resolved WHERE import_id=?`,
+		`DELETE FROM manual_items WHERE import_id=?`,
+		`DELETE FROM collection_items WHERE import_id=?`,
+		`DELETE FROM library_resolve_pending WHERE import_id=?`,
+		`DELETE FROM play_history WHERE import_id=?`,
+		`DELETE FROM nzb_imports WHERE id=?`,
+	}
+	for _, q := range stmts {
+		if _, err := tx.ExecContext(ctx, q, id); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.jobs.TouchLibrary(id)
+	_ = thumbs.Remove(s.Config().Paths.CacheDir, id)
+	return nil
+}
@@ -3,7 +3,11 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
+
+	"github.com/gaby/EDRmount/internal/thumbs"
 )
 
 type fileRow struct {
@@ -19,6 +23,8 @@ type fileRow struct {
 
 func (s *Server) registerCatalogFileRoutes() {
 	// GET /api/v1/catalog/imports/{id}/files
+	// GET|PUT /api/v1/catalog/imports/{id}/notes
+	// GET /api/v1/catalog/imports/{id}/files/{idx}/thumb
 	s.mux.HandleFunc("/api/v1/catalog/imports/", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		if s.jobs == nil {
@@ -26,14 +32,14 @@ func (s *Server) registerCatalogFileRoutes() {
 			_ = json.NewEncoder(w).Encode(map[string]string{"error": "db not configured"})
 			return
 		}
-		if r.Method != http.MethodGet {
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			return
-		}
 
 		path := strings.TrimPrefix(r.URL.Path, "/api/v1/catalog/imports/")
 		parts := strings.Split(path, "/")
-		if len(parts) != 2 || parts[1] != "files" {
+		if len(parts) == 4 && parts[0] != "" && parts[1] == "files" && parts[3] == "thumb" {
+			s.handleFileThumb(w, r, parts[0], parts[2])
+			return
+		}
+		if len(parts) != 2 || (parts[1] != "files" && parts[1] != "notes") {
 			w.WriteHeader(http.StatusNotFound)
 			_ = json.NewEncoder(w).Encode(map[string]string{"error": "not found"})
 			return
@@ -44,6 +50,14 @@ func (s *Server) registerCatalogFileRoutes() {
 			_ = json.NewEncoder(w).Encode(map[string]string{"error": "id required"})
 			return
 		}
+		if parts[1] == "notes" {
+			s.handleImportNotes(w, r, importID)
+			return
+		}
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
 
 		rows, err := s.jobs.DB().SQL.QueryContext(r.Context(), `SELECT idx,filename,subject,poster,date,groups_json,segments_count,total_bytes FROM nzb_files WHERE import_id=? ORDER BY idx ASC`, importID)
 		if err != nil {
@@ -66,3 +80,33 @@ func (s *Server) registerCatalogFileRoutes() {
 		_ = json.NewEncoder(w).Encode(out)
 	})
 }
+
+// handleFileThumb serves the preview frame cached by the thumbnail_import job.
+func (s *Server) handleFileThumb(w http.ResponseWriter, r *http.Request, importID, idxStr string) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	idx, err := strconv.Atoi(idxStr)
+	if err != nil || idx < 0 || !thumbs.ValidImportID(importID) {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid id or idx"})
+		return
+	}
+	f, err := os.Open(thumbs.Path(s.Config().Paths.CacheDir, importID, idx))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "no thumbnail"})
+		return
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	http.ServeContent(w, r, fi.Name(), fi.ModTime(), f)
+}
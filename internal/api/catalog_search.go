@@ -0,0 +1,111 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gaby/EDRmount/internal/library"
+)
+
+type catalogSearchItem struct {
+	ImportID    string `json:"import_id"`
+	FileIdx     int    `json:"file_idx"`
+	Kind        string `json:"kind"`
+	Title       string `json:"title"`
+	Year        int    `json:"year"`
+	Quality     string `json:"quality"`
+	VirtualPath string `json:"virtual_path"`
+	Overridden  bool   `json:"overridden"`
+}
+
+func (s *Server) registerCatalogSearchRoutes() {
+	// GET /api/v1/catalog/search?q=titan&kind=movie&quality=1080&limit=100
+	// Title substring search over library_resolved, with library_overrides taking precedence.
+	s.mux.HandleFunc("/api/v1/catalog/search", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if s.jobs == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "db not configured"})
+			return
+		}
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		q := strings.TrimSpace(r.URL.Query().Get("q"))
+		if q == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "q required"})
+			return
+		}
+		kind := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("kind")))
+		if kind != "" && kind != "movie" && kind != "series" {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "kind must be movie|series"})
+			return
+		}
+		buckets := s.Config().Library.QualityBuckets
+		quality := ""
+		if qq := strings.TrimSpace(r.URL.Query().Get("quality")); qq != "" {
+			quality = library.NormalizeQuality(qq, buckets)
+			if quality == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": "quality must be 4K|1080|720|SD"})
+				return
+			}
+		}
+		limit := 100
+		if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= 1000 {
+			limit = v
+		}
+
+		// Escape LIKE wildcards so the query is a plain substring match.
+		like := "%" + strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(q) + "%"
+
+		rows, err := s.jobs.DB().SQL.QueryContext(r.Context(), `
+			SELECT import_id, file_idx, kind, title, year, quality, virtual_path, overridden FROM (
+				SELECT r.import_id, r.file_idx,
+				       COALESCE(NULLIF(o.kind,''), r.kind) AS kind,
+				       COALESCE(NULLIF(o.title,''), r.title) AS title,
+				       CASE WHEN o.year > 0 THEN o.year ELSE r.year END AS year,
+				       COALESCE(NULLIF(o.quality,''), r.quality) AS quality,
+				       r.virtual_path,
+				       o.import_id IS NOT NULL AS overridden
+				FROM library_resolved r
+				LEFT JOIN library_overrides o ON o.import_id=r.import_id AND o.file_idx=r.file_idx
+				WHERE r.title LIKE ? ESCAPE '\' OR o.title LIKE ? ESCAPE '\'
+				UNION ALL
+				SELECT o.import_id, o.file_idx, o.kind, o.title, o.year, o.quality, '', 1
+				FROM library_overrides o
+				WHERE o.title LIKE ? ESCAPE '\'
+				  AND NOT EXISTS (SELECT 1 FROM library_resolved r WHERE r.import_id=o.import_id AND r.file_idx=o.file_idx)
+			)
+			WHERE title LIKE ? ESCAPE '\'
+			  AND (?='' OR kind=?)
+			  AND (?='' OR quality=? OR quality=?)
+			ORDER BY title COLLATE NOCASE ASC, year ASC, import_id ASC, file_idx ASC
+			LIMIT ?
+		`, like, like, like, like, kind, kind, quality, quality, library.RawQualityFolder(quality, buckets), limit)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		defer rows.Close()
+
+		out := make([]catalogSearchItem, 0)
+		for rows.Next() {
+			var it catalogSearchItem
+			var ov int
+			if err := rows.Scan(&it.ImportID, &it.FileIdx, &it.Kind, &it.Title, &it.Year, &it.Quality, &it.VirtualPath, &ov); err != nil {
+				continue
+			}
+			it.Overridden = ov != 0
+			out = append(out, it)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"items": out, "total": len(out)})
+	})
+}
@@ -0,0 +1,282 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type collection struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt int64  `json:"created_at"`
+	Items     int    `json:"items"`
+}
+
+type collectionItem struct {
+	ImportID string `json:"import_id"`
+	FileIdx  int    `json:"file_idx"`
+	Filename string `json:"filename"`
+	Bytes    int64  `json:"bytes"`
+	AddedAt  int64  `json:"added_at"`
+}
+
+// Collections are user groupings of catalog files ("Christmas movies"), independent of
+// the manual folder tree. An import can belong to any number of collections.
+func (s *Server) registerCollectionRoutes() {
+	// GET  /api/v1/collections
+	// POST /api/v1/collections {name}
+	s.mux.HandleFunc("/api/v1/collections", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if s.jobs == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "db not configured"})
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			rows, err := s.jobs.DB().SQL.QueryContext(r.Context(), `
+				SELECT c.id, c.name, c.created_at, (SELECT COUNT(1) FROM collection_items i WHERE i.collection_id=c.id)
+				FROM collections c ORDER BY c.name COLLATE NOCASE
+			`)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+			defer rows.Close()
+			out := make([]collection, 0)
+			for rows.Next() {
+				var c collection
+				if err := rows.Scan(&c.ID, &c.Name, &c.CreatedAt, &c.Items); err != nil {
+					continue
+				}
+				out = append(out, c)
+			}
+			_ = json.NewEncoder(w).Encode(out)
+		case http.MethodPost:
+			var req struct {
+				Name string `json:"name"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+			name := strings.TrimSpace(req.Name)
+			if name == "" || strings.ContainsAny(name, "/\x00") {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": "valid name required"})
+				return
+			}
+			c := collection{ID: uuid.NewString(), Name: name, CreatedAt: time.Now().Unix()}
+			if _, err := s.jobs.DB().SQL.ExecContext(r.Context(), `INSERT INTO collections(id,name,created_at) VALUES(?,?,?)`, c.ID, c.Name, c.CreatedAt); err != nil {
+				w.WriteHeader(http.StatusConflict)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(c)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	// PUT    /api/v1/collections/{id} {name}
+	// DELETE /api/v1/collections/{id}
+	// GET    /api/v1/collections/{id}/items
+	// POST   /api/v1/collections/{id}/items {import_id, file_idx?}  (no file_idx: all MKVs of the import)
+	// DELETE /api/v1/collections/{id}/items?import_id=...&file_idx=...  (no file_idx: whole import)
+	s.mux.HandleFunc("/api/v1/collections/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if s.jobs == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "db not configured"})
+			return
+		}
+		rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/collections/"), "/")
+		parts := strings.Split(rest, "/")
+		id := parts[0]
+		if id == "" || len(parts) > 2 || (len(parts) == 2 && parts[1] != "items") {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "not found"})
+			return
+		}
+		db := s.jobs.DB().SQL
+		var exists int
+		_ = db.QueryRowContext(r.Context(), `SELECT COUNT(1) FROM collections WHERE id=?`, id).Scan(&exists)
+		if exists == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "collection not found"})
+			return
+		}
+
+		if len(parts) == 1 {
+			switch r.Method {
+			case http.MethodPut:
+				var req struct {
+					Name string `json:"name"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+					return
+				}
+				name := strings.TrimSpace(req.Name)
+				if name == "" || strings.ContainsAny(name, "/\x00") {
+					w.WriteHeader(http.StatusBadRequest)
+					_ = json.NewEncoder(w).Encode(map[string]string{"error": "valid name required"})
+					return
+				}
+				if _, err := db.ExecContext(r.Context(), `UPDATE collections SET name=? WHERE id=?`, name, id); err != nil {
+					w.WriteHeader(http.StatusConflict)
+					_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+					return
+				}
+				_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "id": id, "name": name})
+			case http.MethodDelete:
+				tx, err := db.BeginTx(r.Context(), nil)
+				if err != nil {
+					w.WriteHeader(http.StatusInternalServerError)
+					_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+					return
+				}
+				defer func() { _ = tx.Rollback() }()
+				for _, q := range []string{`DELETE FROM collection_items WHERE collection_id=?`, `DELETE FROM collections WHERE id=?`} {
+					if _, err := tx.ExecContext(r.Context(), q, id); err != nil {
+						w.WriteHeader(http.StatusInternalServerError)
+						_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+						return
+					}
+				}
+				if err := tx.Commit(); err != nil {
+					w.WriteHeader(http.StatusInternalServerError)
+					_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+					return
+				}
+				_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "deleted": id})
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			rows, err := db.QueryContext(r.Context(), `
+				SELECT i.import_id, i.file_idx, COALESCE(f.filename,''), COALESCE(f.total_bytes,0), i.added_at
+				FROM collection_items i
+				LEFT JOIN nzb_files f ON f.import_id=i.import_id AND f.idx=i.file_idx
+				WHERE i.collection_id=?
+				ORDER BY i.added_at ASC, i.import_id ASC, i.file_idx ASC
+			`, id)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+			defer rows.Close()
+			out := make([]collectionItem, 0)
+			for rows.Next() {
+				var it collectionItem
+				if err := rows.Scan(&it.ImportID, &it.FileIdx, &it.Filename, &it.Bytes, &it.AddedAt); err != nil {
+					continue
+				}
+				out = append(out, it)
+			}
+			_ = json.NewEncoder(w).Encode(out)
+		case http.MethodPost:
+			var req struct {
+				ImportID string `json:"import_id"`
+				FileIdx  *int   `json:"file_idx"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+			importID := strings.TrimSpace(req.ImportID)
+			if importID == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": "import_id required"})
+				return
+			}
+			var idxs []int
+			if req.FileIdx != nil {
+				var c int
+				_ = db.QueryRowContext(r.Context(), `SELECT COUNT(1) FROM nzb_files WHERE import_id=? AND idx=?`, importID, *req.FileIdx).Scan(&c)
+				if c == 0 {
+					w.WriteHeader(http.StatusNotFound)
+					_ = json.NewEncoder(w).Encode(map[string]string{"error": "file not found in import"})
+					return
+				}
+				idxs = append(idxs, *req.FileIdx)
+			} else {
+				rows, err := db.QueryContext(r.Context(), `SELECT idx FROM nzb_files WHERE import_id=? AND LOWER(COALESCE(filename,'')) LIKE '%.mkv' ORDER BY idx`, importID)
+				if err != nil {
+					w.WriteHeader(http.StatusInternalServerError)
+					_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+					return
+				}
+				for rows.Next() {
+					var idx int
+					if err := rows.Scan(&idx); err == nil {
+						idxs = append(idxs, idx)
+					}
+				}
+				rows.Close()
+				if len(idxs) == 0 {
+					w.WriteHeader(http.StatusNotFound)
+					_ = json.NewEncoder(w).Encode(map[string]string{"error": "no mkv files in import"})
+					return
+				}
+			}
+			now := time.Now().Unix()
+			added := 0
+			for _, idx := range idxs {
+				res, err := db.ExecContext(r.Context(), `INSERT OR IGNORE INTO collection_items(collection_id,import_id,file_idx,added_at) VALUES(?,?,?,?)`, id, importID, idx, now)
+				if err != nil {
+					w.WriteHeader(http.StatusInternalServerError)
+					_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+					return
+				}
+				if n, _ := res.RowsAffected(); n > 0 {
+					added++
+				}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "added": added})
+		case http.MethodDelete:
+			importID := strings.TrimSpace(r.URL.Query().Get("import_id"))
+			if importID == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": "import_id required"})
+				return
+			}
+			q := `DELETE FROM collection_items WHERE collection_id=? AND import_id=?`
+			args := []any{id, importID}
+			if v := strings.TrimSpace(r.URL.Query().Get("file_idx")); v != "" {
+				idx, err := strconv.Atoi(v)
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid file_idx"})
+					return
+				}
+				q += ` AND file_idx=?`
+				args = append(args, idx)
+			}
+			res, err := db.ExecContext(r.Context(), q, args...)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+			n, _ := res.RowsAffected()
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "removed": n})
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"os"
+	"time"
 )
 
 // POST /api/v1/db/reset
@@ -20,3 +21,57 @@ func (s *Server) handleDBReset(w http.ResponseWriter, r *http.Request) {
 	_ = os.WriteFile(marker, []byte("1\n"), 0o644)
 	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "marker": marker, "note": "DB will be reset on next restart"})
 }
+
+// POST /api/v1/db/check
+// Runs PRAGMA integrity_check + foreign_key_check and returns per-table row counts.
+func (s *Server) handleDBCheck(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if s.jobs == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "db not configured"})
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "method not allowed"})
+		return
+	}
+	res, err := s.jobs.DB().Check(r.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// POST /api/v1/db/vacuum
+// Compacts the sqlite DB (VACUUM + WAL truncate). Writers wait while it runs.
+func (s *Server) handleDBVacuum(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if s.jobs == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "db not configured"})
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "method not allowed"})
+		return
+	}
+	d := s.jobs.DB()
+	before, _ := d.SizeBytes(r.Context())
+	start := time.Now()
+	if err := d.Vacuum(r.Context()); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	after, _ := d.SizeBytes(r.Context())
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"ok":           true,
+		"bytes_before": before,
+		"bytes_after":  after,
+		"duration_ms":  time.Since(start).Milliseconds(),
+	})
+}
@@ -9,6 +9,8 @@ import (
 	"os/exec"
 	"strings"
 	"time"
+
+	"github.com/gaby/EDRmount/internal/config"
 )
 
 func (s *Server) registerFileBotRoutes() {
@@ -21,7 +23,7 @@ func (s *Server) registerFileBotRoutes() {
 		cfg := s.Config()
 		bin := strings.TrimSpace(cfg.Rename.FileBot.Binary)
 		if bin == "" {
-			bin = "/usr/local/bin/filebot"
+			bin = config.DefaultFileBotBinary
 		}
 		licensePath := strings.TrimSpace(cfg.Rename.FileBot.LicensePath)
 		if licensePath == "" {
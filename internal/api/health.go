@@ -1,10 +1,12 @@
 package api
 
 import (
+	"database/sql"
 	"encoding/json"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -32,45 +34,41 @@ func (s *Server) registerHealthRoutes() {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
 		}
-		root := strings.TrimSpace(s.Config().NgPost.OutputDir)
-		if root == "" {
-			root = "/host/inbox/nzb"
-		}
-
-		// If root doesn't exist, return empty list (not error) to keep UI friendly.
-		st, err := os.Stat(root)
-		if err != nil || !st.IsDir() {
-			_ = json.NewEncoder(w).Encode(map[string]any{"root": root, "entries": []healthScanEntry{}})
-			return
-		}
+		roots := s.Config().NZBOutputRoots()
 
+		// Missing roots are skipped (an empty list is not an error) to keep the UI friendly.
 		entries := make([]healthScanEntry, 0, 256)
-		_ = filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
-			if err != nil {
-				return nil
+		for _, root := range roots {
+			if st, err := os.Stat(root); err != nil || !st.IsDir() {
+				continue
 			}
-			if d.IsDir() {
-				// skip hidden folders, and the health backup folder
-				if strings.HasPrefix(d.Name(), ".") {
-					return filepath.SkipDir
+			_ = filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+				if err != nil {
+					return nil
+				}
+				if d.IsDir() {
+					// skip hidden folders, and the health backup folder
+					if strings.HasPrefix(d.Name(), ".") {
+						return filepath.SkipDir
+					}
+					if d.Name() == ".health-bak" {
+						return filepath.SkipDir
+					}
+					return nil
 				}
-				if d.Name() == ".health-bak" {
-					return filepath.SkipDir
+				name := strings.ToLower(d.Name())
+				if !strings.HasSuffix(name, ".nzb") {
+					return nil
 				}
+				info, err := d.Info()
+				if err != nil {
+					return nil
+				}
+				rp, _ := filepath.Rel(root, p)
+				entries = append(entries, healthScanEntry{Path: p, RelPath: rp, Size: info.Size(), ModTime: info.ModTime()})
 				return nil
-			}
-			name := strings.ToLower(d.Name())
-			if !strings.HasSuffix(name, ".nzb") {
-				return nil
-			}
-			info, err := d.Info()
-			if err != nil {
-				return nil
-			}
-			rp, _ := filepath.Rel(root, p)
-			entries = append(entries, healthScanEntry{Path: p, RelPath: rp, Size: info.Size(), ModTime: info.ModTime()})
-			return nil
-		})
+			})
+		}
 
 		states := map[string]healthScanEntry{}
 		totalCheckedNow := 0
@@ -110,7 +108,8 @@ func (s *Server) registerHealthRoutes() {
 		}
 
 		_ = json.NewEncoder(w).Encode(map[string]any{
-			"root":    root,
+			"root":    roots[0],
+			"roots":   roots,
 			"entries": entries,
 			"summary": map[string]any{
 				"total":                  len(entries),
@@ -175,4 +174,75 @@ func (s *Server) registerHealthRoutes() {
 		}
 		_ = json.NewEncoder(w).Encode(job)
 	})
+
+	// Re-check a single NZB (STAT its MKV segments) and update health_nzb_state; never repairs.
+	// POST {path} ?wait=<seconds> (max 120) waits for the job and includes the resulting status.
+	s.mux.HandleFunc("/api/v1/jobs/enqueue/health-check", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if s.jobs == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "jobs db not configured"})
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var payload struct {
+			Path string `json:"path"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		payload.Path = strings.TrimSpace(payload.Path)
+		if payload.Path == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "path required"})
+			return
+		}
+		if st, err := os.Stat(payload.Path); err != nil || st.IsDir() {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "nzb not found"})
+			return
+		}
+		job, err := s.jobs.Enqueue(r.Context(), jobs.TypeHealthCheck, payload)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		wait, _ := strconv.Atoi(r.URL.Query().Get("wait"))
+		if wait <= 0 {
+			_ = json.NewEncoder(w).Encode(job)
+			return
+		}
+		if wait > 120 {
+			wait = 120
+		}
+		db := s.jobs.DB().SQL
+		deadline := time.Now().Add(time.Duration(wait) * time.Second)
+		state := string(job.State)
+		for time.Now().Before(deadline) && (state == string(jobs.StateQueued) || state == string(jobs.StateRunning)) {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(250 * time.Millisecond):
+			}
+			_ = db.QueryRowContext(r.Context(), `SELECT state FROM jobs WHERE id=?`, job.ID).Scan(&state)
+		}
+		out := map[string]any{"job_id": job.ID, "state": state, "path": payload.Path}
+		if state == string(jobs.StateDone) || state == string(jobs.StateFailed) {
+			var status string
+			var lastErr sql.NullString
+			if err := db.QueryRowContext(r.Context(), `SELECT status, last_error FROM health_nzb_state WHERE path=?`, payload.Path).Scan(&status, &lastErr); err == nil {
+				out["status"] = status
+				if lastErr.Valid {
+					out["error"] = lastErr.String
+				}
+			}
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	})
 }
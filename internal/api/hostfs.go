@@ -9,6 +9,8 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/gaby/EDRmount/internal/watch"
 )
 
 type hostEntry struct {
@@ -119,6 +121,7 @@ func (s *Server) registerHostFSRoutes() {
 
 	// List host paths (read-only).
 	// This is intentionally limited to inside cfg.Paths.HostRoot (usually "/host").
+	// Entries matching hostfs.hidden_patterns are skipped unless ?show_hidden=1.
 	s.mux.HandleFunc("/api/v1/hostfs/list", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		if r.Method != http.MethodGet {
@@ -154,8 +157,14 @@ func (s *Server) registerHostFSRoutes() {
 			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 			return
 		}
+		showHidden := r.URL.Query().Get("show_hidden") == "1" || strings.EqualFold(r.URL.Query().Get("show_hidden"), "true")
 		out := make([]hostEntry, 0, len(ents))
+		hidden := 0
 		for _, e := range ents {
+			if !showHidden && watch.IgnoredName(e.Name(), cfg.HostFS.HiddenPatterns) {
+				hidden++
+				continue
+			}
 			info, err := e.Info()
 			if err != nil {
 				continue
@@ -182,6 +191,7 @@ func (s *Server) registerHostFSRoutes() {
 			"root":    root,
 			"path":    p,
 			"entries": out,
+			"hidden":  hidden,
 		})
 	})
 
@@ -9,6 +9,8 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/gaby/EDRmount/internal/trash"
 )
 
 func (s *Server) registerImportDeleteRoutes() {
@@ -41,23 +43,9 @@ func (s *Server) registerImportDeleteRoutes() {
 		}
 
 		cfg := s.Config()
-		nzbRoot := strings.TrimSpace(cfg.Watch.NZB.Dir)
-		if nzbRoot == "" {
-			nzbRoot = strings.TrimSpace(cfg.Paths.NzbInbox)
-		}
-		if nzbRoot == "" {
-			nzbRoot = "/host/inbox/nzb"
-		}
-		nzbRoot = filepath.Clean(nzbRoot)
-
-		parRoot := strings.TrimSpace(cfg.Upload.Par.Dir)
-		if parRoot == "" {
-			parRoot = "/host/inbox/par2"
-		}
-		parRoot = filepath.Clean(parRoot)
-
-		trashRoot := filepath.Join("/host", "inbox", ".trash")
-		trashRoot = filepath.Clean(trashRoot)
+		nzbRoot := trashNZBRoot(cfg)
+		parRoot := trashParRoot(cfg)
+		trashRoot := trash.Root
 
 		// Look up the NZB path before we delete it from DB.
 		var nzbPath string
@@ -108,36 +96,25 @@ func (s *Server) registerImportDeleteRoutes() {
 		}
 
 		// Finally delete DB rows (global)
-		tx, err := s.jobs.DB().SQL.BeginTx(r.Context(), nil)
-		if err != nil {
+		if err := s.deleteImport(r.Context(), id); err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 			return
 		}
-		defer func() { _ = tx.Rollback() }()
-		stmts := []string{
-			`DELETE FROM nzb_segments WHERE import_id=?`,
-			`DELETE FROM nzb_files WHERE import_id=?`,
-			`DELETE FROM library_overrides WHERE import_id=?`,
-			`DELETE FROM library_review_dismissed WHERE import_id=?`,
-			`DELETE FROM library_resolved WHERE import_id=?`,
-			`DELETE FROM manual_items WHERE import_id=?`,
-			`DELETE FROM nzb_imports WHERE id=?`,
-		}
-		for _, q := range stmts {
-			if _, err := tx.ExecContext(r.Context(), q, id); err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
-				return
-			}
+
+		pruned := 0
+		if cfg.Library.PruneManualDirs {
+			pruned, _ = s.jobs.PruneEmptyManualDirs(r.Context())
 		}
-		if err := tx.Commit(); err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
-			return
+
+		// Keep the watcher from re-importing a leftover copy right away.
+		suppressed := false
+		if hrs := cfg.Watch.DeleteCooldownHours; hrs > 0 {
+			until := time.Now().Add(time.Duration(hrs) * time.Hour)
+			suppressed = s.jobs.Suppress(r.Context(), nzbPath, "nzb", "delete_full", until) == nil
 		}
 
-		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "trashed_nzb": movedNZB, "trashed_par2": parMoved})
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "trashed_nzb": movedNZB, "trashed_par2": parMoved, "suppressed": suppressed, "pruned_dirs": pruned})
 	})
 }
 
@@ -155,7 +132,7 @@ func moveToTrash(src, trashBase, rel string) (string, error) {
 	rel = strings.TrimPrefix(rel, string(filepath.Separator))
 	rel = filepath.Clean(rel)
 
-	stamp := time.Now().Format("20060102-150405")
+	stamp := time.Now().Format(trash.StampLayout)
 	dst := filepath.Join(trashBase, stamp, rel)
 	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
 		return "", err
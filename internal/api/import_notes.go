@@ -0,0 +1,63 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxImportNotes caps the free-text note stored per import (in characters).
+const maxImportNotes = 2000
+
+// GET /api/v1/catalog/imports/{id}/notes
+// PUT /api/v1/catalog/imports/{id}/notes {notes}  (empty clears)
+func (s *Server) handleImportNotes(w http.ResponseWriter, r *http.Request, importID string) {
+	db := s.jobs.DB().SQL
+	switch r.Method {
+	case http.MethodGet:
+		var notes string
+		err := db.QueryRowContext(r.Context(), `SELECT notes FROM nzb_imports WHERE id=?`, importID).Scan(&notes)
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "import not found"})
+			return
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": importID, "notes": notes})
+	case http.MethodPut:
+		var req struct {
+			Notes string `json:"notes"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		notes := strings.TrimSpace(req.Notes)
+		if utf8.RuneCountInString(notes) > maxImportNotes {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "notes too long (max 2000 characters)"})
+			return
+		}
+		res, err := db.ExecContext(r.Context(), `UPDATE nzb_imports SET notes=? WHERE id=?`, notes, importID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "import not found"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "id": importID, "notes": notes})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
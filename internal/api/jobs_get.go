@@ -0,0 +1,138 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gaby/EDRmount/internal/jobs"
+)
+
+// jobProgress is what the PHASE:/PROGRESS: log lines of a job say (uploads and imports
+// log them).
+type jobProgress struct {
+	Phase   string `json:"phase"`
+	Percent int    `json:"percent"`
+	// ETASeconds extrapolates the PROGRESS rate of the log window; 0 = unknown.
+	ETASeconds int64 `json:"eta_seconds,omitempty"`
+}
+
+// parseJobProgress reads the newest PHASE: and PROGRESS: lines of lines (newest first);
+// ok is false when there are none. "PROGRESS: 42 (files 3/7, ...)" reads as 42.
+func parseJobProgress(lines []string) (p jobProgress, ok bool) {
+	phaseSeen, progressSeen := false, false
+	for _, ln := range lines {
+		l := strings.TrimSpace(ln)
+		if !phaseSeen && strings.HasPrefix(l, "PHASE:") {
+			p.Phase = strings.TrimSpace(strings.TrimPrefix(l, "PHASE:"))
+			phaseSeen = true
+		}
+		if !progressSeen {
+			if n, isProgress := progressPercent(l); isProgress {
+				p.Percent = n
+				progressSeen = true
+			}
+		}
+		if phaseSeen && progressSeen {
+			break
+		}
+	}
+	return p, phaseSeen || progressSeen
+}
+
+// progressPercent parses a "PROGRESS: n ..." line.
+func progressPercent(line string) (int, bool) {
+	if !strings.HasPrefix(line, "PROGRESS:") {
+		return 0, false
+	}
+	v := strings.TrimSpace(strings.TrimPrefix(line, "PROGRESS:"))
+	if i := strings.IndexFunc(v, func(r rune) bool { return r < '0' || r > '9' }); i >= 0 {
+		v = v[:i]
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 || n > 100 {
+		return 0, false
+	}
+	return n, true
+}
+
+// progressETA extrapolates the remaining time of a running job from its PROGRESS lines
+// (newest first): the rate between the newest one and the oldest of the same rising run.
+func progressETA(lines []jobs.LogLine) time.Duration {
+	var t1, t0 time.Time
+	p1, p0 := -1, -1
+	for _, ln := range lines {
+		n, ok := progressPercent(strings.TrimSpace(ln.Line))
+		if !ok {
+			continue
+		}
+		if p1 < 0 {
+			t1, p1 = ln.TS, n
+			continue
+		}
+		if n > p1 || (p0 >= 0 && n > p0) {
+			break // an earlier, unrelated run (retry, phase restart)
+		}
+		t0, p0 = ln.TS, n
+	}
+	if p1 <= 0 || p1 >= 100 || p0 < 0 || p0 >= p1 || !t1.After(t0) {
+		return 0
+	}
+	perPoint := t1.Sub(t0) / time.Duration(p1-p0)
+	return perPoint * time.Duration(100-p1)
+}
+
+// handleJobGet serves GET /api/v1/jobs/{id}: the job, its recent log lines (newest
+// first, ?limit= like /logs) and, when it logs them, the parsed phase/progress/ETA.
+func (s *Server) handleJobGet(w http.ResponseWriter, r *http.Request, jobID string) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	j, err := s.jobs.Get(r.Context(), jobID)
+	if errors.Is(err, jobs.ErrJobNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "job not found"})
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	limit := 200
+	if q := r.URL.Query().Get("limit"); q != "" {
+		var n int
+		_, _ = fmt.Sscanf(q, "%d", &n)
+		if n > 0 && n <= 5000 {
+			limit = n
+		}
+	}
+	logs, err := s.jobs.GetLogLines(r.Context(), jobID, limit)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if logs == nil {
+		logs = []jobs.LogLine{}
+	}
+
+	resp := map[string]any{"job": j, "logs": logs}
+	lines := make([]string, len(logs))
+	for i, l := range logs {
+		lines[i] = l.Line
+	}
+	if p, ok := parseJobProgress(lines); ok {
+		if j.State == jobs.StateRunning {
+			p.ETASeconds = int64(progressETA(logs).Seconds())
+		}
+		resp["progress"] = p
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
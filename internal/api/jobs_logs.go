@@ -8,7 +8,9 @@ import (
 )
 
 func (s *Server) registerJobLogRoutes() {
-	// GET /api/v1/jobs/{id}/logs?limit=500
+	// GET  /api/v1/jobs/{id}
+	// GET  /api/v1/jobs/{id}/logs?limit=500
+	// POST /api/v1/jobs/{id}/bump
 	s.mux.HandleFunc("/api/v1/jobs/", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		if s.jobs == nil {
@@ -18,8 +20,16 @@ func (s *Server) registerJobLogRoutes() {
 		}
 
 		path := strings.TrimPrefix(r.URL.Path, "/api/v1/jobs/")
-		// expected: {id}/logs
+		// expected: {id}, {id}/logs or {id}/bump
 		parts := strings.Split(path, "/")
+		if len(parts) == 1 && parts[0] != "" {
+			s.handleJobGet(w, r, parts[0])
+			return
+		}
+		if len(parts) == 2 && parts[1] == "bump" && parts[0] != "" {
+			s.handleJobBump(w, r, parts[0])
+			return
+		}
 		if len(parts) != 2 || parts[1] != "logs" {
 			w.WriteHeader(http.StatusNotFound)
 			_ = json.NewEncoder(w).Encode(map[string]string{"error": "not found"})
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gaby/EDRmount/internal/jobs"
+)
+
+func (s *Server) registerJobOrderRoutes() {
+	// POST /api/v1/jobs/reorder {"ids":["...","..."]}
+	// Listed queued jobs go to the front in that order; the rest keep their order after them.
+	s.mux.HandleFunc("/api/v1/jobs/reorder", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if s.jobs == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "jobs db not configured"})
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			IDs []string `json:"ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.IDs) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "ids required"})
+			return
+		}
+		s.writeQueueReorder(w, r, s.jobs.Reorder(r.Context(), req.IDs))
+	})
+}
+
+// handleJobBump serves POST /api/v1/jobs/{id}/bump: move a queued job to the front.
+func (s *Server) handleJobBump(w http.ResponseWriter, r *http.Request, jobID string) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	s.writeQueueReorder(w, r, s.jobs.Bump(r.Context(), jobID))
+}
+
+// writeQueueReorder maps a reorder error to a status, or returns the new effective queue order.
+func (s *Server) writeQueueReorder(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case err == nil:
+	case errors.Is(err, jobs.ErrJobNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	case errors.Is(err, jobs.ErrNotQueued):
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	order, err := s.jobs.QueuedOrder(r.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "queue": order})
+}
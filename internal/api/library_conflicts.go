@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type conflictEntry struct {
+	ImportID   string `json:"import_id"`
+	FileIdx    int    `json:"file_idx"`
+	Kind       string `json:"kind"`
+	Title      string `json:"title"`
+	Year       int    `json:"year"`
+	TMDBID     int    `json:"tmdb_id"`
+	NZBPath    string `json:"nzb_path"`
+	ImportedAt int64  `json:"imported_at"`
+}
+
+type conflictGroup struct {
+	VirtualPath string          `json:"virtual_path"`
+	Entries     []conflictEntry `json:"entries"`
+}
+
+func (s *Server) registerLibraryConflictRoutes() {
+	// List library_resolved rows that share the same virtual_path.
+	// The FUSE tree disambiguates these with an [id8] suffix; this report lets the
+	// user delete or re-override one of them.
+	s.mux.HandleFunc("/api/v1/library/conflicts", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if s.jobs == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "db not configured"})
+			return
+		}
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		rows, err := s.jobs.DB().SQL.QueryContext(r.Context(), `
+			SELECT lr.virtual_path, lr.import_id, lr.file_idx, lr.kind, lr.title, lr.year, lr.tmdb_id,
+			       COALESCE(i.path,''), COALESCE(i.imported_at,0)
+			FROM library_resolved lr
+			LEFT JOIN nzb_imports i ON i.id=lr.import_id
+			WHERE lr.virtual_path IN (
+				SELECT virtual_path FROM library_resolved
+				WHERE virtual_path != ''
+				GROUP BY virtual_path
+				HAVING COUNT(*) > 1
+			)
+			ORDER BY lr.virtual_path ASC, i.imported_at ASC, lr.import_id ASC, lr.file_idx ASC
+		`)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		defer rows.Close()
+
+		groups := make([]conflictGroup, 0)
+		for rows.Next() {
+			var vp string
+			var e conflictEntry
+			if err := rows.Scan(&vp, &e.ImportID, &e.FileIdx, &e.Kind, &e.Title, &e.Year, &e.TMDBID, &e.NZBPath, &e.ImportedAt); err != nil {
+				continue
+			}
+			if len(groups) == 0 || groups[len(groups)-1].VirtualPath != vp {
+				groups = append(groups, conflictGroup{VirtualPath: vp})
+			}
+			g := &groups[len(groups)-1]
+			g.Entries = append(g.Entries, e)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"conflicts": groups, "total": len(groups)})
+	})
+}
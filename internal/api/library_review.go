@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -122,7 +123,7 @@ func (s *Server) registerLibraryReviewRoutes() {
 				Bytes:        bytes,
 				GuessTitle:   g.Title,
 				GuessYear:    g.Year,
-				GuessQuality: g.Quality,
+				GuessQuality: library.QualityOr(library.DetectQuality(filename, cfg.Library.QualityBuckets), cfg.Library.Defaults().DefaultQuality, cfg.Library.QualityBuckets),
 			})
 			if len(out) >= 50 {
 				break
@@ -230,6 +231,7 @@ func (s *Server) registerLibraryReviewRoutes() {
 			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 			return
 		}
+		s.jobs.TouchLibrary(req.ImportID)
 		// also remove any dismissed flag for this file
 		_, _ = s.jobs.DB().SQL.ExecContext(r.Context(), `DELETE FROM library_review_dismissed WHERE import_id=? AND file_idx=?`, req.ImportID, req.FileIdx)
 
@@ -320,6 +322,131 @@ func (s *Server) registerLibraryReviewRoutes() {
 			}
 		}
 
+		if count > 0 {
+			s.jobs.TouchLibrary(req.ImportID)
+		}
 		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "import_id": req.ImportID, "updated": count})
 	})
+
+	// Batch override for a season pack: assign sequential episodes to the import's video files
+	// (sorted by filename) and report the applied mapping so it can be sanity-checked.
+	s.mux.HandleFunc("/api/v1/library/override/season", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if s.jobs == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "db not configured"})
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			ImportID     string `json:"import_id"`
+			Title        string `json:"title"`
+			Year         int    `json:"year"`
+			Quality      string `json:"quality"`
+			TMDBID       int    `json:"tmdb_id"`
+			Season       int    `json:"season"`
+			StartEpisode int    `json:"start_episode"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		req.ImportID = strings.TrimSpace(req.ImportID)
+		req.Title = strings.TrimSpace(req.Title)
+		req.Quality = strings.TrimSpace(req.Quality)
+		if req.ImportID == "" || req.Title == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "import_id, title required"})
+			return
+		}
+		if req.Season < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "season must be >= 0"})
+			return
+		}
+		if req.StartEpisode <= 0 {
+			req.StartEpisode = 1
+		}
+		if req.Year < 0 {
+			req.Year = 0
+		}
+		if req.TMDBID < 0 {
+			req.TMDBID = 0
+		}
+
+		rows, err := s.jobs.DB().SQL.QueryContext(r.Context(), `SELECT idx, COALESCE(filename,''), subject FROM nzb_files WHERE import_id=? ORDER BY idx`, req.ImportID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		type seasonFile struct {
+			idx  int
+			name string
+		}
+		files := make([]seasonFile, 0)
+		for rows.Next() {
+			var idx int
+			var fn string
+			var subj string
+			if err := rows.Scan(&idx, &fn, &subj); err != nil {
+				continue
+			}
+			name := strings.TrimSpace(fn)
+			if name == "" {
+				name = strings.TrimSpace(filepath.Base(subj))
+			}
+			low := strings.ToLower(name)
+			if !(strings.HasSuffix(low, ".mkv") || strings.HasSuffix(low, ".mp4") || strings.HasSuffix(low, ".avi") || strings.HasSuffix(low, ".m4v")) {
+				continue
+			}
+			files = append(files, seasonFile{idx: idx, name: name})
+		}
+		rows.Close()
+		if len(files) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "no video files in import"})
+			return
+		}
+		sort.SliceStable(files, func(i, j int) bool { return strings.ToLower(files[i].name) < strings.ToLower(files[j].name) })
+
+		type mapping struct {
+			FileIdx  int    `json:"file_idx"`
+			Filename string `json:"filename"`
+			Season   int    `json:"season"`
+			Episode  int    `json:"episode"`
+		}
+		applied := make([]mapping, 0, len(files))
+		now := time.Now().Unix()
+		for i, f := range files {
+			ep := req.StartEpisode + i
+			_, err := s.jobs.DB().SQL.ExecContext(r.Context(), `
+				INSERT INTO library_overrides(import_id,file_idx,kind,title,year,quality,tmdb_id,season,episode,updated_at)
+				VALUES(?,?,?,?,?,?,?,?,?,?)
+				ON CONFLICT(import_id,file_idx) DO UPDATE SET
+					kind=excluded.kind,
+					title=excluded.title,
+					year=excluded.year,
+					quality=excluded.quality,
+					tmdb_id=excluded.tmdb_id,
+					season=excluded.season,
+					episode=excluded.episode,
+					updated_at=excluded.updated_at
+			`, req.ImportID, f.idx, "series", req.Title, req.Year, req.Quality, req.TMDBID, req.Season, ep, now)
+			if err != nil {
+				continue
+			}
+			_, _ = s.jobs.DB().SQL.ExecContext(r.Context(), `DELETE FROM library_review_dismissed WHERE import_id=? AND file_idx=?`, req.ImportID, f.idx)
+			applied = append(applied, mapping{FileIdx: f.idx, Filename: f.name, Season: req.Season, Episode: ep})
+		}
+
+		if len(applied) > 0 {
+			s.jobs.TouchLibrary(req.ImportID)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "import_id": req.ImportID, "updated": len(applied), "mapping": applied})
+	})
 }
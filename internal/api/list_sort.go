@@ -0,0 +1,40 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// listSortKeys are the ?sort= values of the catalog and manual listings.
+var listSortKeys = []string{"name", "size", "date"}
+
+// listOrderBy builds the ORDER BY clause of a listing from ?sort=name|size|date and
+// ?order=asc|desc. cols maps each key to its SQL expression, so only whitelisted
+// columns reach the query; tie keeps the order stable. Without ?sort the listing keeps
+// def (its historical order).
+func listOrderBy(r *http.Request, cols map[string]string, tie, def string) (string, error) {
+	q := r.URL.Query()
+	key := strings.ToLower(strings.TrimSpace(q.Get("sort")))
+	order := strings.ToLower(strings.TrimSpace(q.Get("order")))
+	if key == "" && order == "" {
+		return "ORDER BY " + def, nil
+	}
+	if key == "" {
+		key = "name"
+	}
+	expr, ok := cols[key]
+	if !ok {
+		return "", fmt.Errorf("sort must be %s", strings.Join(listSortKeys, "|"))
+	}
+	dir := "ASC"
+	switch order {
+	case "", "asc":
+	case "desc":
+		dir = "DESC"
+	default:
+		return "", errors.New("order must be asc|desc")
+	}
+	return fmt.Sprintf("ORDER BY %s %s, %s", expr, dir, tie), nil
+}
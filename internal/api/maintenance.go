@@ -0,0 +1,418 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gaby/EDRmount/internal/config"
+)
+
+type relocateMove struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// relocateTree lists the files under oldRoot with their destination under newRoot.
+func relocateTree(oldRoot, newRoot string) ([]relocateMove, error) {
+	out := make([]relocateMove, 0)
+	if _, err := os.Stat(oldRoot); errors.Is(err, os.ErrNotExist) {
+		return out, nil
+	}
+	err := filepath.WalkDir(oldRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(oldRoot, p)
+		if err != nil {
+			return err
+		}
+		out = append(out, relocateMove{From: p, To: filepath.Join(newRoot, rel)})
+		return nil
+	})
+	return out, err
+}
+
+// rebaseUnder returns p moved from oldRoot to newRoot, or false if p is not under oldRoot.
+func rebaseUnder(p, oldRoot, newRoot string) (string, bool) {
+	rel, err := filepath.Rel(oldRoot, filepath.Clean(p))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.Join(newRoot, rel), true
+}
+
+func relocateFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	// Different filesystem: copy then remove.
+	tmp := dst + ".tmp"
+	_ = os.Remove(tmp)
+	if err := copyFileLocal(src, tmp); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// removeEmptyDirs deletes empty folders below root (root itself is kept).
+func removeEmptyDirs(root string) {
+	var dirs []string
+	_ = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() && p != root {
+			dirs = append(dirs, p)
+		}
+		return nil
+	})
+	for i := len(dirs) - 1; i >= 0; i-- {
+		_ = os.Remove(dirs[i])
+	}
+}
+
+func (s *Server) registerMaintenanceRoutes() {
+	// POST /api/v1/maintenance/relocate-nzb {nzb_dir?, movies_dir?, series_dir?, par_dir?, dry_run?}
+	// Moves NZB trees (ngpost.output_dir, upload.movies_output_dir, upload.series_output_dir)
+	// and/or the PAR2 tree (upload.par.dir) to a new root, rewrites the stored NZB paths in
+	// one transaction and saves the new dirs in config. Configured dirs inside a moved tree
+	// (watch.nzb.dir, a category dir under output_dir) follow it.
+	s.mux.HandleFunc("/api/v1/maintenance/relocate-nzb", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if s.jobs == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "db not configured"})
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			NZBDir    string `json:"nzb_dir"`
+			MoviesDir string `json:"movies_dir"`
+			SeriesDir string `json:"series_dir"`
+			ParDir    string `json:"par_dir"`
+			DryRun    bool   `json:"dry_run"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		cfg := s.Config()
+		cleanDir := func(v string) string {
+			if v = strings.TrimSpace(v); v == "" {
+				return ""
+			}
+			return filepath.Clean(v)
+		}
+
+		type tree struct{ name, from, to string }
+		var trees []tree
+		for _, t := range []tree{
+			{"nzb", cleanDir(cfg.NgPost.OutputDir), cleanDir(req.NZBDir)},
+			{"movies", cleanDir(cfg.Upload.MoviesOutputDir), cleanDir(req.MoviesDir)},
+			{"series", cleanDir(cfg.Upload.SeriesOutputDir), cleanDir(req.SeriesDir)},
+			{"par2", cleanDir(cfg.Upload.Par.Dir), cleanDir(req.ParDir)},
+		} {
+			if t.to == "" || t.to == t.from {
+				continue
+			}
+			if !filepath.IsAbs(t.to) {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": t.name + " dir must be absolute"})
+				return
+			}
+			if t.from == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": t.name + " dir is not configured"})
+				return
+			}
+			if _, ok := rebaseUnder(t.to, t.from, t.from); ok {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": t.name + " dir cannot be inside the current one"})
+				return
+			}
+			if _, ok := rebaseUnder(t.from, t.to, t.to); ok {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": t.name + " dir cannot contain the current one"})
+				return
+			}
+			for _, o := range trees {
+				_, in := rebaseUnder(t.from, o.from, o.from)
+				_, out := rebaseUnder(o.from, t.from, t.from)
+				if in || out {
+					w.WriteHeader(http.StatusBadRequest)
+					_ = json.NewEncoder(w).Encode(map[string]string{"error": o.name + " and " + t.name + " dirs overlap; relocate the outer one alone"})
+					return
+				}
+			}
+			trees = append(trees, t)
+		}
+		if len(trees) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "nzb_dir, movies_dir, series_dir or par_dir required (and different from current)"})
+			return
+		}
+
+		moves := make([]relocateMove, 0)
+		conflicts := make([]string, 0)
+		for _, t := range trees {
+			ms, err := relocateTree(t.from, t.to)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+			for _, m := range ms {
+				if _, err := os.Stat(m.To); err == nil {
+					conflicts = append(conflicts, m.To)
+				}
+			}
+			moves = append(moves, ms...)
+		}
+
+		// Stored NZB paths to rewrite (only the NZB trees are referenced from the DB).
+		type pathUpdate struct{ table, col, key, keyCol, from, to string }
+		var updates []pathUpdate
+		for _, t := range trees {
+			if t.name == "par2" {
+				continue
+			}
+			for _, q := range []struct{ table, keyCol, col string }{
+				{"nzb_imports", "id", "path"},
+				{"health_nzb_state", "path", "path"},
+				{"health_replacements", "media_name", "nzb_path"},
+			} {
+				rows, err := s.jobs.DB().SQL.QueryContext(r.Context(), fmt.Sprintf(`SELECT %s, %s FROM %s`, q.keyCol, q.col, q.table))
+				if err != nil {
+					w.WriteHeader(http.StatusInternalServerError)
+					_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+					return
+				}
+				for rows.Next() {
+					var key, p string
+					if err := rows.Scan(&key, &p); err != nil {
+						continue
+					}
+					if np, ok := rebaseUnder(p, t.from, t.to); ok {
+						updates = append(updates, pathUpdate{table: q.table, col: q.col, key: key, keyCol: q.keyCol, from: p, to: np})
+					}
+				}
+				rows.Close()
+			}
+		}
+		importsRewritten := 0
+		for _, u := range updates {
+			if u.table == "nzb_imports" {
+				importsRewritten++
+			}
+		}
+
+		if req.DryRun || len(conflicts) > 0 {
+			if len(conflicts) > 0 {
+				w.WriteHeader(http.StatusConflict)
+			}
+			sample := moves
+			if len(sample) > 50 {
+				sample = sample[:50]
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"ok":                len(conflicts) == 0,
+				"dry_run":           req.DryRun,
+				"files":             len(moves),
+				"moves":             sample,
+				"imports_rewritten": importsRewritten,
+				"conflicts":         conflicts,
+			})
+			return
+		}
+
+		// Move files first; any failure puts the already-moved ones back.
+		done := make([]relocateMove, 0, len(moves))
+		undo := func() {
+			for i := len(done) - 1; i >= 0; i-- {
+				_ = relocateFile(done[i].To, done[i].From)
+			}
+		}
+		for _, m := range moves {
+			if err := relocateFile(m.From, m.To); err != nil {
+				undo()
+				w.WriteHeader(http.StatusInternalServerError)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("move %s: %v", m.From, err)})
+				return
+			}
+			done = append(done, m)
+		}
+
+		tx, err := s.jobs.DB().SQL.BeginTx(r.Context(), nil)
+		if err != nil {
+			undo()
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		defer func() { _ = tx.Rollback() }()
+		for _, u := range updates {
+			q := fmt.Sprintf(`UPDATE %s SET %s=? WHERE %s=? AND %s=?`, u.table, u.col, u.keyCol, u.col)
+			if _, err := tx.ExecContext(r.Context(), q, u.to, u.key, u.from); err != nil {
+				undo()
+				w.WriteHeader(http.StatusInternalServerError)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			undo()
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		next := cfg
+		for _, t := range trees {
+			for _, dir := range []*string{&next.NgPost.OutputDir, &next.Upload.MoviesOutputDir, &next.Upload.SeriesOutputDir, &next.Upload.Par.Dir, &next.Watch.NZB.Dir} {
+				if strings.TrimSpace(*dir) == "" {
+					continue
+				}
+				if np, ok := rebaseUnder(*dir, t.from, t.to); ok {
+					*dir = np
+				}
+			}
+			removeEmptyDirs(t.from)
+		}
+		cfgErr := config.Save(s.cfgPath, next)
+		if cfgErr == nil {
+			s.setConfig(next)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"ok":                true,
+			"dry_run":           false,
+			"files":             len(done),
+			"imports_rewritten": importsRewritten,
+			"nzb_dir":           next.NgPost.OutputDir,
+			"movies_dir":        next.Upload.MoviesOutputDir,
+			"series_dir":        next.Upload.SeriesOutputDir,
+			"par_dir":           next.Upload.Par.Dir,
+			"config_error":      errString(cfgErr),
+		})
+	})
+	// POST /api/v1/maintenance/refilename {dry_run?}
+	// Re-runs subject -> filename extraction (current subject.patterns) for nzb_files still
+	// carrying the file_NNNN.bin placeholder, then queues the touched imports for a
+	// library_resolved refresh so library-auto picks up the new names.
+	s.mux.HandleFunc("/api/v1/maintenance/refilename", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if s.jobs == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "db not configured"})
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			DryRun bool `json:"dry_run"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		db := s.jobs.DB().SQL
+		rows, err := db.QueryContext(r.Context(), `
+			SELECT import_id, idx, COALESCE(subject,''), COALESCE(filename,'') FROM nzb_files
+			WHERE filename IS NULL OR filename = '' OR filename GLOB 'file_[0-9]*.bin'`)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		type fix struct {
+			ImportID string `json:"import_id"`
+			Idx      int    `json:"idx"`
+			From     string `json:"from"`
+			To       string `json:"to"`
+		}
+		ex := s.subjectExtractor()
+		fixes := make([]fix, 0)
+		checked := 0
+		for rows.Next() {
+			var f fix
+			var subj string
+			if err := rows.Scan(&f.ImportID, &f.Idx, &subj, &f.From); err != nil {
+				continue
+			}
+			// Only the importer's exact placeholder; a real file named file_0001.bin stays.
+			if f.From != "" && f.From != fmt.Sprintf("file_%04d.bin", f.Idx) {
+				continue
+			}
+			checked++
+			if fn, _, ok := ex.Filename(subj); ok && fn != "" && fn != f.From {
+				f.To = fn
+				fixes = append(fixes, f)
+			}
+		}
+		rows.Close()
+
+		imports := map[string]struct{}{}
+		for _, f := range fixes {
+			imports[f.ImportID] = struct{}{}
+		}
+		if !req.DryRun && len(fixes) > 0 {
+			tx, err := db.BeginTx(r.Context(), nil)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+			defer func() { _ = tx.Rollback() }()
+			for _, f := range fixes {
+				if _, err := tx.ExecContext(r.Context(), `UPDATE nzb_files SET filename=? WHERE import_id=? AND idx=?`, f.To, f.ImportID, f.Idx); err != nil {
+					w.WriteHeader(http.StatusInternalServerError)
+					_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+					return
+				}
+			}
+			if err := tx.Commit(); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+			touched := make([]string, 0, len(imports))
+			for id := range imports {
+				touched = append(touched, id)
+				_ = s.jobs.MarkResolvePending(r.Context(), id, "filenames re-derived from subjects")
+			}
+			s.jobs.TouchLibrary(touched...)
+		}
+		sample := fixes
+		if len(sample) > 50 {
+			sample = sample[:50]
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"ok":      true,
+			"dry_run": req.DryRun,
+			"checked": checked,
+			"fixed":   len(fixes),
+			"imports": len(imports),
+			"sample":  sample,
+		})
+	})
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gaby/EDRmount/internal/library"
+)
+
+type labelChange struct {
+	ItemID string `json:"item_id"`
+	DirID  string `json:"dir_id"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+func (s *Server) registerManualLabelRoutes() {
+	// Normalize manual item labels in a folder (optionally recursive).
+	// Returns a before/after preview; only writes when apply=true.
+	s.mux.HandleFunc("/api/v1/manual/labels/normalize", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if s.jobs == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "db not configured"})
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			DirID     string `json:"dir_id"`
+			Recursive bool   `json:"recursive"`
+			Apply     bool   `json:"apply"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		req.DirID = strings.TrimSpace(req.DirID)
+		if req.DirID == "" {
+			req.DirID = "root"
+		}
+
+		db := s.jobs.DB().SQL
+		dirIDs := []string{req.DirID}
+		if req.Recursive {
+			ids, err := manualSubtreeDirIDs(r.Context(), db, req.DirID)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+			dirIDs = ids
+		}
+
+		changes := make([]labelChange, 0)
+		for _, dirID := range dirIDs {
+			rows, err := db.QueryContext(r.Context(), `SELECT id,label FROM manual_items WHERE dir_id=? ORDER BY label`, dirID)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+			for rows.Next() {
+				var id, label string
+				if err := rows.Scan(&id, &label); err != nil {
+					continue
+				}
+				after := library.CleanLabel(label)
+				if after == "" || after == label {
+					continue
+				}
+				changes = append(changes, labelChange{ItemID: id, DirID: dirID, Before: label, After: after})
+			}
+			rows.Close()
+		}
+
+		applied := 0
+		if req.Apply {
+			for _, c := range changes {
+				if _, err := db.ExecContext(r.Context(), `UPDATE manual_items SET label=? WHERE id=?`, c.After, c.ItemID); err == nil {
+					applied++
+				}
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "dir_id": req.DirID, "applied": req.Apply, "updated": applied, "changes": changes})
+	})
+}
+
+// manualSubtreeDirIDs returns dirID and all its descendant manual dir ids.
+func manualSubtreeDirIDs(ctx context.Context, db *sql.DB, dirID string) ([]string, error) {
+	out := []string{dirID}
+	seen := map[string]bool{dirID: true}
+	for i := 0; i < len(out); i++ {
+		rows, err := db.QueryContext(ctx, `SELECT id FROM manual_dirs WHERE parent_id=? AND id<>'root'`, out[i])
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				continue
+			}
+			if !seen[id] {
+				seen[id] = true
+				out = append(out, id)
+			}
+		}
+		rows.Close()
+	}
+	return out, nil
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"database/sql"
 	"encoding/json"
+	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -26,6 +27,20 @@ type manualItem struct {
 	Filename string `json:"filename"`
 }
 
+// manualDirSortColumns and manualItemSortColumns back ?sort= on the manual listings
+// (default: by name). A dir's size and date come from the items directly inside it.
+var manualDirSortColumns = map[string]string{
+	"name": "name",
+	"size": "(SELECT COALESCE(SUM(f.total_bytes),0) FROM manual_items i JOIN nzb_files f ON f.import_id=i.import_id AND f.idx=i.file_idx WHERE i.dir_id=manual_dirs.id)",
+	"date": "(SELECT COALESCE(MAX(n.imported_at),0) FROM manual_items i JOIN nzb_imports n ON n.id=i.import_id WHERE i.dir_id=manual_dirs.id)",
+}
+
+var manualItemSortColumns = map[string]string{
+	"name": "i.label",
+	"size": "f.total_bytes",
+	"date": "(SELECT imported_at FROM nzb_imports WHERE id=i.import_id)",
+}
+
 func (s *Server) registerManualLibraryRoutes() {
 	// Path breadcrumb (root -> current)
 	s.mux.HandleFunc("/api/v1/manual/path", func(w http.ResponseWriter, r *http.Request) {
@@ -128,7 +143,13 @@ func (s *Server) registerManualLibraryRoutes() {
 			if parent == "" {
 				parent = "root"
 			}
-			rows, err := s.jobs.DB().SQL.QueryContext(r.Context(), `SELECT id,parent_id,name FROM manual_dirs WHERE parent_id=? AND id<>'root' ORDER BY name`, parent)
+			orderBy, err := listOrderBy(r, manualDirSortColumns, "id", "name")
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+			rows, err := s.jobs.DB().SQL.QueryContext(r.Context(), `SELECT id,parent_id,name FROM manual_dirs WHERE parent_id=? AND id<>'root' `+orderBy, parent)
 			if err != nil {
 				w.WriteHeader(http.StatusInternalServerError)
 				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
@@ -215,7 +236,7 @@ func (s *Server) registerManualLibraryRoutes() {
 			if strings.TrimSpace(req.ParentID) != "" {
 				parent = req.ParentID
 			}
-			_, err := s.jobs.DB().SQL.ExecContext(r.Context(), `UPDATE manual_dirs SET parent_id=?, name=? WHERE id=?`, parent, name, id)
+			_, err := s.jobs.DB().SQL.ExecContext(r.Context(), `UPDATE manual_dirs SET parent_id=?, name=?, source='user' WHERE id=?`, parent, name, id)
 			if err != nil {
 				w.WriteHeader(http.StatusInternalServerError)
 				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
@@ -263,9 +284,14 @@ func (s *Server) registerManualLibraryRoutes() {
 				FROM manual_items i
 				JOIN nzb_files f ON f.import_id=i.import_id AND f.idx=i.file_idx
 				WHERE i.dir_id=?
-				ORDER BY i.label
 			`
-			rows, err := s.jobs.DB().SQL.QueryContext(r.Context(), q, dir)
+			orderBy, err := listOrderBy(r, manualItemSortColumns, "i.id", "i.label")
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+			rows, err := s.jobs.DB().SQL.QueryContext(r.Context(), q+orderBy, dir)
 			if err != nil {
 				w.WriteHeader(http.StatusInternalServerError)
 				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
@@ -316,7 +342,22 @@ func (s *Server) registerManualLibraryRoutes() {
 				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 				return
 			}
-			_ = json.NewEncoder(w).Encode(manualItem{ID: id, DirID: req.DirID, Label: label, ImportID: req.ImportID, FileIdx: req.FileIdx})
+			resp := struct {
+				manualItem
+				Override  *manualOverride `json:"override,omitempty"`
+				AutoError string          `json:"auto_error,omitempty"`
+			}{manualItem: manualItem{ID: id, DirID: req.DirID, Label: label, ImportID: req.ImportID, FileIdx: req.FileIdx}}
+			// library.manual_items_in_auto: the pick also gets its library-auto path. The
+			// item itself is kept when that fails; the caller sees why in auto_error.
+			if s.Config().Library.ManualItemsInAuto {
+				o, err := s.manualItemToAuto(r.Context(), id, req.ImportID, req.FileIdx, strings.TrimSpace(req.Label) != "")
+				if err != nil {
+					log.Printf("manual item %s: not added to library-auto: %v", id, err)
+					resp.AutoError = err.Error()
+				}
+				resp.Override = o
+			}
+			_ = json.NewEncoder(w).Encode(resp)
 		default:
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
@@ -332,11 +373,19 @@ func (s *Server) registerManualLibraryRoutes() {
 		}
 		id := strings.TrimPrefix(r.URL.Path, "/api/v1/manual/items/")
 		id = strings.Trim(id, "/")
+		promote := false
+		if rest, ok := strings.CutSuffix(id, "/promote"); ok {
+			id, promote = rest, true
+		}
 		if id == "" {
 			w.WriteHeader(http.StatusBadRequest)
 			_ = json.NewEncoder(w).Encode(map[string]string{"error": "id required"})
 			return
 		}
+		if promote {
+			s.handleManualPromote(w, r, id)
+			return
+		}
 		switch r.Method {
 		case http.MethodPut:
 			var req struct {
@@ -359,15 +408,23 @@ func (s *Server) registerManualLibraryRoutes() {
 			if strings.TrimSpace(req.DirID) != "" {
 				dir = req.DirID
 			}
+			oldLabel := label
 			if strings.TrimSpace(req.Label) != "" {
 				label = req.Label
 			}
+			relabeled := label != oldLabel
 			_, err := s.jobs.DB().SQL.ExecContext(r.Context(), `UPDATE manual_items SET dir_id=?, label=? WHERE id=?`, dir, label, id)
 			if err != nil {
 				w.WriteHeader(http.StatusInternalServerError)
 				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 				return
 			}
+			// library.manual_labels_to_overrides: a corrected label also fixes library-auto.
+			if relabeled && s.Config().Library.ManualLabelsToOverrides {
+				if _, err := s.promoteManualItem(r.Context(), id, manualOverride{}); err != nil {
+					log.Printf("manual item %s: label not promoted to override: %v", id, err)
+				}
+			}
 			_ = json.NewEncoder(w).Encode(manualItem{ID: id, DirID: dir, Label: label, ImportID: imp, FileIdx: idx})
 		case http.MethodDelete:
 			_, err := s.jobs.DB().SQL.ExecContext(r.Context(), `DELETE FROM manual_items WHERE id=?`, id)
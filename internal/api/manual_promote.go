@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gaby/EDRmount/internal/library"
+)
+
+// manualOverride is the library_overrides row a manual item is promoted to. Zero fields
+// of a request are filled from the item's label (see library.ParseLabel), then from the
+// file's current override/resolved metadata.
+type manualOverride struct {
+	ImportID string `json:"import_id"`
+	FileIdx  int    `json:"file_idx"`
+	Kind     string `json:"kind"`
+	Title    string `json:"title"`
+	Year     int    `json:"year"`
+	Quality  string `json:"quality"`
+	TMDBID   int    `json:"tmdb_id"`
+	Season   int    `json:"season"`
+	Episode  int    `json:"episode"`
+}
+
+var errManualItemNotFound = errors.New("not found")
+
+// manualPromoteError is a promotion rejected for its input (400, not 500).
+type manualPromoteError string
+
+func (e manualPromoteError) Error() string { return string(e) }
+
+// promoteManualItem turns the label of manual item id into a library_override for its
+// import/file, so the library-auto tree picks up a title corrected in the manual tree.
+func (s *Server) promoteManualItem(ctx context.Context, id string, req manualOverride) (manualOverride, error) {
+	db := s.jobs.DB().SQL
+	var label, filename string
+	var out manualOverride
+	err := db.QueryRowContext(ctx, `
+		SELECT i.label, i.import_id, i.file_idx, COALESCE(f.filename,'')
+		FROM manual_items i
+		LEFT JOIN nzb_files f ON f.import_id=i.import_id AND f.idx=i.file_idx
+		WHERE i.id=?`, id).Scan(&label, &out.ImportID, &out.FileIdx, &filename)
+	if errors.Is(err, sql.ErrNoRows) {
+		return out, errManualItemNotFound
+	}
+	if err != nil {
+		return out, err
+	}
+
+	// Current metadata: an existing override wins over the import-time resolution.
+	var cur manualOverride
+	if err := db.QueryRowContext(ctx, `SELECT kind,title,year,quality,tmdb_id,season,episode FROM library_overrides WHERE import_id=? AND file_idx=?`, out.ImportID, out.FileIdx).Scan(&cur.Kind, &cur.Title, &cur.Year, &cur.Quality, &cur.TMDBID, &cur.Season, &cur.Episode); err != nil {
+		_ = db.QueryRowContext(ctx, `SELECT kind,title,year,quality,tmdb_id,season,episode FROM library_resolved WHERE import_id=? AND file_idx=?`, out.ImportID, out.FileIdx).Scan(&cur.Kind, &cur.Title, &cur.Year, &cur.Quality, &cur.TMDBID, &cur.Season, &cur.Episode)
+	}
+
+	g := library.ParseLabel(label)
+	out.Title = firstNonEmpty(strings.TrimSpace(req.Title), g.Title, cur.Title)
+	if out.Title == "" {
+		return out, manualPromoteError("item label has no title")
+	}
+	out.Year = firstPositive(req.Year, g.Year, cur.Year)
+	out.Season = firstPositive(req.Season, g.Season, cur.Season)
+	out.Episode = firstPositive(req.Episode, g.Episode, cur.Episode)
+	out.Kind = strings.ToLower(strings.TrimSpace(req.Kind))
+	if out.Kind == "" {
+		out.Kind = "movie"
+		if g.IsSeries || (strings.EqualFold(cur.Kind, "series") && out.Season > 0 && out.Episode > 0) {
+			out.Kind = "series"
+		}
+	}
+	if out.Kind != "movie" && out.Kind != "series" {
+		return out, manualPromoteError("kind must be movie|series")
+	}
+	if out.Kind == "series" && (out.Season <= 0 || out.Episode <= 0) {
+		return out, manualPromoteError("series needs season and episode (label \"Show - 01x02\")")
+	}
+	if out.Kind == "movie" {
+		out.Season, out.Episode = 0, 0
+	}
+	l := s.Config().Library.Defaults()
+	out.Quality = library.QualityOr(firstNonEmpty(strings.TrimSpace(req.Quality), library.DetectQuality(label, l.QualityBuckets), cur.Quality, library.DetectQuality(filename, l.QualityBuckets)), l.DefaultQuality, l.QualityBuckets)
+	// A TMDB id only carries over while the title still names the same thing.
+	out.TMDBID = req.TMDBID
+	if out.TMDBID <= 0 && strings.EqualFold(out.Title, strings.TrimSpace(cur.Title)) {
+		out.TMDBID = cur.TMDBID
+	}
+	if out.TMDBID < 0 {
+		out.TMDBID = 0
+	}
+
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO library_overrides(import_id,file_idx,kind,title,year,quality,tmdb_id,season,episode,updated_at)
+		VALUES(?,?,?,?,?,?,?,?,?,?)
+		ON CONFLICT(import_id,file_idx) DO UPDATE SET
+			kind=excluded.kind,
+			title=excluded.title,
+			year=excluded.year,
+			quality=excluded.quality,
+			tmdb_id=excluded.tmdb_id,
+			season=excluded.season,
+			episode=excluded.episode,
+			updated_at=excluded.updated_at
+	`, out.ImportID, out.FileIdx, out.Kind, out.Title, out.Year, out.Quality, out.TMDBID, out.Season, out.Episode, time.Now().Unix())
+	if err != nil {
+		return out, err
+	}
+	s.jobs.TouchLibrary(out.ImportID)
+	_, _ = db.ExecContext(ctx, `DELETE FROM library_review_dismissed WHERE import_id=? AND file_idx=?`, out.ImportID, out.FileIdx)
+	return out, nil
+}
+
+// manualItemToAuto promotes a new manual item (library.manual_items_in_auto) unless its
+// file already has an override, which was set on purpose and wins over the pick (nil
+// override, nil error). An item created without a label is promoted from its file name.
+func (s *Server) manualItemToAuto(ctx context.Context, id, importID string, fileIdx int, labeled bool) (*manualOverride, error) {
+	db := s.jobs.DB().SQL
+	var one int
+	err := db.QueryRowContext(ctx, `SELECT 1 FROM library_overrides WHERE import_id=? AND file_idx=?`, importID, fileIdx).Scan(&one)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	var req manualOverride
+	if !labeled {
+		var filename string
+		_ = db.QueryRowContext(ctx, `SELECT COALESCE(filename,'') FROM nzb_files WHERE import_id=? AND idx=?`, importID, fileIdx).Scan(&filename)
+		g := library.ParseLabel(filename)
+		if g.Title == "" {
+			return nil, manualPromoteError("item has no label and its file name has no title")
+		}
+		req = manualOverride{Title: g.Title, Year: g.Year, Quality: g.Quality, Season: g.Season, Episode: g.Episode}
+		if g.IsSeries {
+			req.Kind = "series"
+		}
+	}
+	o, err := s.promoteManualItem(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// handleManualPromote serves POST /api/v1/manual/items/{id}/promote. The body is
+// optional: {kind,title,year,quality,tmdb_id,season,episode} override the label.
+func (s *Server) handleManualPromote(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req manualOverride
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	o, err := s.promoteManualItem(r.Context(), id, req)
+	if errors.Is(err, errManualItemNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "not found"})
+		return
+	}
+	var bad manualPromoteError
+	if errors.As(err, &bad) {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "override": o})
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+func firstPositive(vals ...int) int {
+	for _, v := range vals {
+		if v > 0 {
+			return v
+		}
+	}
+	return 0
+}
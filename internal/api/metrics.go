@@ -0,0 +1,32 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/gaby/EDRmount/internal/nntp"
+)
+
+func (s *Server) registerMetricsRoutes() {
+	// GET /api/v1/metrics
+	// Runtime gauges for monitoring; "nntp" is the global connection limiter shared by
+	// streaming and health (limit = download.connections, 0 = unlimited).
+	s.mux.HandleFunc("/api/v1/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"nntp":           nntp.GlobalLimiter().Stats(),
+			"breakers":       nntp.BreakerStates(),
+			"goroutines":     runtime.NumGoroutine(),
+			"heap_bytes":     ms.HeapAlloc,
+			"uptime_seconds": int64(time.Since(s.started).Seconds()),
+		})
+	})
+}
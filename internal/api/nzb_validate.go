@@ -0,0 +1,237 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gaby/EDRmount/internal/nntp"
+	"github.com/gaby/EDRmount/internal/nzb"
+	"github.com/gaby/EDRmount/internal/yenc"
+)
+
+const (
+	nzbValidateDefaultSample = 20
+	nzbValidateMaxSample     = 200
+)
+
+type nzbValidateStage struct {
+	Name   string `json:"name"` // parse|stat|decode
+	OK     bool   `json:"ok"`
+	Ms     int64  `json:"ms"`
+	Detail string `json:"detail,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// sampleSegments picks up to n segments spread evenly over segs (first and last included).
+func sampleSegments(segs []nzb.Segment, n int) []nzb.Segment {
+	if n <= 0 || len(segs) <= n {
+		return segs
+	}
+	if n == 1 {
+		return segs[:1]
+	}
+	out := make([]nzb.Segment, 0, n)
+	for i := 0; i < n; i++ {
+		out = append(out, segs[i*(len(segs)-1)/(n-1)])
+	}
+	return out
+}
+
+// nzbValidateTarget returns the file used for the stat/decode stages: the first MKV,
+// or the first file with segments when there is none. Segments come back in order.
+func nzbValidateTarget(doc *nzb.NZB) (nzb.File, bool) {
+	pick := -1
+	for i, f := range doc.Files {
+		if len(f.Segments) == 0 {
+			continue
+		}
+		if strings.Contains(strings.ToLower(f.Subject), ".mkv") {
+			pick = i
+			break
+		}
+		if pick < 0 {
+			pick = i
+		}
+	}
+	if pick < 0 {
+		return nzb.File{}, false
+	}
+	f := doc.Files[pick]
+	segs := append([]nzb.Segment(nil), f.Segments...)
+	sort.Slice(segs, func(i, j int) bool { return segs[i].Number < segs[j].Number })
+	f.Segments = segs
+	return f, true
+}
+
+func (s *Server) registerNZBValidateRoutes() {
+	// POST /api/v1/nzb/validate {path, sample?}
+	// End-to-end sanity check of one NZB against the download provider: parse it, STAT a
+	// sample of segments of its first MKV (or first file) and download + yEnc-decode the
+	// first segment. Each stage is reported separately; later stages are skipped once one
+	// fails. Nothing is recorded (see /api/v1/jobs/enqueue/health-check for that).
+	s.mux.HandleFunc("/api/v1/nzb/validate", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			Path   string `json:"path"`
+			Sample int    `json:"sample"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		req.Path = strings.TrimSpace(req.Path)
+		if req.Path == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "path required"})
+			return
+		}
+		if req.Sample <= 0 {
+			req.Sample = nzbValidateDefaultSample
+		}
+		if req.Sample > nzbValidateMaxSample {
+			req.Sample = nzbValidateMaxSample
+		}
+		cfg := s.Config()
+
+		stages := make([]nzbValidateStage, 0, 3)
+		finish := func(file string) {
+			ok := len(stages) == 3
+			for _, st := range stages {
+				ok = ok && st.OK
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"ok":     ok,
+				"path":   req.Path,
+				"file":   file,
+				"stages": stages,
+			})
+		}
+
+		// 1) parse
+		start := time.Now()
+		st := nzbValidateStage{Name: "parse"}
+		f, err := os.Open(req.Path)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "nzb not found"})
+			return
+		}
+		doc, err := nzb.Parse(f)
+		_ = f.Close()
+		st.Ms = time.Since(start).Milliseconds()
+		if err != nil {
+			st.Error = err.Error()
+			stages = append(stages, st)
+			finish("")
+			return
+		}
+		target, ok := nzbValidateTarget(doc)
+		if !ok {
+			st.Error = "no files with segments"
+			stages = append(stages, st)
+			finish("")
+			return
+		}
+		st.OK = true
+		st.Detail = fmt.Sprintf("%d files, %d segments in target", len(doc.Files), len(target.Segments))
+		stages = append(stages, st)
+
+		if !cfg.Download.Enabled || strings.TrimSpace(cfg.Download.Host) == "" {
+			stages = append(stages, nzbValidateStage{Name: "stat", Error: "download provider not configured"})
+			finish(target.Subject)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+		defer cancel()
+
+		// 2) stat
+		start = time.Now()
+		st = nzbValidateStage{Name: "stat"}
+		cl, err := nntp.Dial(ctx, cfg.Download.NNTPConfig())
+		if err == nil {
+			defer cl.Close()
+			err = cl.Auth()
+		}
+		if err != nil {
+			st.Ms = time.Since(start).Milliseconds()
+			st.Error = "connect: " + err.Error()
+			stages = append(stages, st)
+			finish(target.Subject)
+			return
+		}
+		sample := sampleSegments(target.Segments, req.Sample)
+		missing := make([]int, 0)
+		for _, seg := range sample {
+			if ctx.Err() != nil {
+				err = ctx.Err()
+				break
+			}
+			if strings.TrimSpace(seg.ID) == "" {
+				missing = append(missing, seg.Number)
+				continue
+			}
+			if e := cl.StatByMessageID(seg.ID); e != nil {
+				if !errors.Is(e, nntp.ErrArticleNotFound) {
+					err = e
+					break
+				}
+				missing = append(missing, seg.Number)
+			}
+		}
+		st.Ms = time.Since(start).Milliseconds()
+		switch {
+		case err != nil:
+			st.Error = err.Error()
+		case len(missing) > 0:
+			st.Error = fmt.Sprintf("%d/%d sampled segments missing: %v", len(missing), len(sample), missing)
+		default:
+			st.OK = true
+			st.Detail = fmt.Sprintf("%d/%d sampled segments present", len(sample), len(target.Segments))
+		}
+		stages = append(stages, st)
+		if !st.OK {
+			finish(target.Subject)
+			return
+		}
+
+		// 3) decode the first segment
+		start = time.Now()
+		st = nzbValidateStage{Name: "decode"}
+		first := target.Segments[0]
+		lines, err := cl.BodyByMessageID(first.ID)
+		var part yenc.Part
+		if err == nil {
+			part, err = yenc.Decode(lines)
+		}
+		if err == nil {
+			err = part.CheckCRC()
+		}
+		if err == nil && part.End > 0 && part.End-part.Begin+1 != len(part.Data) {
+			err = fmt.Errorf("decoded %d bytes, yEnc part declares %d", len(part.Data), part.End-part.Begin+1)
+		}
+		if err == nil && len(part.Data) == 0 {
+			err = errors.New("empty yEnc payload")
+		}
+		st.Ms = time.Since(start).Milliseconds()
+		if err != nil {
+			st.Error = fmt.Sprintf("segment %d: %v", first.Number, err)
+		} else {
+			st.OK = true
+			st.Detail = fmt.Sprintf("segment %d: %d bytes, name=%q, crc=%t", first.Number, len(part.Data), part.Name, part.HasCRC)
+		}
+		stages = append(stages, st)
+		finish(target.Subject)
+	})
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os/exec"
+	"strings"
+
+	"github.com/gaby/EDRmount/internal/streamer"
+)
+
+// servePlayRemux repackages the file into fragmented MP4 with "ffmpeg -c copy" (no re-encoding)
+// so browsers can play MKVs whose codecs they support but whose container they don't.
+// The output is produced on the fly: it is not seekable, Range is ignored and no
+// Content-Length is sent.
+func (s *Server) servePlayRemux(ctx context.Context, w http.ResponseWriter, r *http.Request, st *streamer.Streamer, importID string, fileIdx int, filename string, size int64, format string) {
+	cfg := s.Config()
+	if !cfg.Transcode.Enabled {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "remux disabled (transcode.enabled=false)"})
+		return
+	}
+	if format != "mp4" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "remux must be mp4"})
+		return
+	}
+	if size <= 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "remux: file is empty"})
+		return
+	}
+
+	w.Header().Del("Content-Length")
+	w.Header().Set("Content-Type", "video/mp4")
+	w.Header().Set("Accept-Ranges", "none")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", strings.TrimSuffix(filename, ".mkv")+".mp4"))
+	w.Header().Set("X-EDR-Remux", format)
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	bin := strings.TrimSpace(cfg.Transcode.FFmpegPath)
+	if bin == "" {
+		bin = "ffmpeg"
+	}
+	cmd := exec.CommandContext(ctx, bin,
+		"-hide_banner", "-loglevel", "error",
+		"-i", "pipe:0",
+		"-map", "0:v:0", "-map", "0:a?", "-sn",
+		"-c", "copy",
+		"-f", "mp4", "-movflags", "frag_keyframe+empty_moov+default_base_moof",
+		"pipe:1",
+	)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadGateway)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "ffmpeg: " + err.Error()})
+		return
+	}
+
+	// Feed the source bytes; ffmpeg closing its stdin (client gone, error) ends the stream.
+	go func() {
+		defer stdin.Close()
+		if err := st.StreamRange(ctx, importID, fileIdx, filename, 0, size-1, stdin, 2); err != nil && !errors.Is(err, io.EOF) && ctx.Err() == nil {
+			log.Printf("PLAY remux source failed import=%s fileIdx=%d err=%v", importID, fileIdx, err)
+		}
+	}()
+
+	// The 200 waits for ffmpeg's first bytes: a source it cannot read (bad codec, broken
+	// file, missing segments) is still reported as an error instead of an empty video.
+	buf := make([]byte, 32*1024)
+	n, rerr := stdout.Read(buf)
+	if n == 0 {
+		cancel()
+		werr := cmd.Wait()
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = fmt.Sprint(errors.Join(rerr, werr))
+		}
+		log.Printf("PLAY remux produced no output import=%s fileIdx=%d stderr=%q", importID, fileIdx, msg)
+		w.Header().Del("Content-Disposition")
+		w.Header().Del("Accept-Ranges")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadGateway)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "ffmpeg: " + msg})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(buf[:n])
+	if err == nil && rerr == nil {
+		_, err = io.Copy(w, stdout)
+	}
+	if err != nil {
+		cancel()
+	}
+	if err := cmd.Wait(); err != nil && ctx.Err() == nil {
+		log.Printf("PLAY remux ffmpeg failed import=%s fileIdx=%d err=%v stderr=%q", importID, fileIdx, err, strings.TrimSpace(stderr.String()))
+	}
+}
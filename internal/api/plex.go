@@ -0,0 +1,43 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gaby/EDRmount/internal/plex"
+)
+
+func (s *Server) registerPlexRoutes() {
+	// GET /api/v1/plex/sections
+	// Lists the Plex library sections (id, title, type, locations) so the UI can build
+	// plex.categories / plex.section_map.
+	s.mux.HandleFunc("/api/v1/plex/sections", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		cfg := s.Config()
+		pc := plex.New(cfg.Plex.BaseURL, cfg.Plex.Token)
+		if !pc.Enabled() {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "plex.base_url and plex.token required"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+		defer cancel()
+		sections, err := pc.Sections(ctx)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"sections":    sections,
+			"categories":  cfg.Plex.Categories,
+			"section_map": cfg.Plex.SectionMap,
+		})
+	})
+}
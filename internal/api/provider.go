@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gaby/EDRmount/internal/config"
+	"github.com/gaby/EDRmount/internal/nntp"
 )
 
 type providerTestRequest struct {
@@ -47,7 +49,7 @@ func (s *Server) registerProviderRoutes() {
 			req.Port = 563
 		}
 
-		addr := fmt.Sprintf("%s:%d", req.Host, req.Port)
+		addr := net.JoinHostPort(req.Host, strconv.Itoa(req.Port))
 		start := time.Now()
 		var err error
 		if req.SSL {
@@ -66,6 +68,10 @@ func (s *Server) registerProviderRoutes() {
 		}
 
 		lat := time.Since(start).Milliseconds()
+		if err == nil {
+			// A provider that answers again doesn't have to wait out the breaker cooldown.
+			nntp.ResetBreaker(req.Host, req.Port)
+		}
 		if err != nil {
 			w.WriteHeader(http.StatusOK)
 			_ = json.NewEncoder(w).Encode(providerTestResponse{OK: false, Message: err.Error(), LatencyMs: lat})
@@ -88,7 +94,7 @@ func (s *Server) registerProviderRoutes() {
 			if dl.Pass != "" {
 				dl.Pass = "***"
 			}
-			_ = json.NewEncoder(w).Encode(map[string]any{"ngpost": ng, "download": dl})
+			_ = json.NewEncoder(w).Encode(map[string]any{"ngpost": ng, "download": dl, "breakers": nntp.BreakerStates()})
 		default:
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
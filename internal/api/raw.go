@@ -4,8 +4,6 @@ import (
 	"encoding/json"
 	"net/http"
 	"strings"
-
-	"github.com/gaby/EDRmount/internal/subject"
 )
 
 type rawItem struct {
@@ -55,6 +53,7 @@ func (s *Server) registerRawRoutes() {
 		defer rows.Close()
 
 		out := make([]rawItem, 0)
+		ex := s.subjectExtractor()
 		for rows.Next() {
 			var idx int
 			var subj string
@@ -63,7 +62,7 @@ func (s *Server) registerRawRoutes() {
 			if err := rows.Scan(&idx, &subj, &segs, &bytes); err != nil {
 				continue
 			}
-			fn, ok := subject.FilenameFromSubject(subj)
+			fn, _, ok := ex.Filename(subj)
 			if !ok {
 				fn = "file_" + strings.ReplaceAll(strings.ReplaceAll(subj, " ", "_"), "/", "_")
 			}
@@ -80,6 +79,9 @@ func (s *Server) registerRawRoutes() {
 
 	// GET|HEAD /api/v1/play/{importId}/{fileIdx}
 	// Optional query param: ?filename=<name> (only used for cache naming/content-disposition)
+	// Debugging: ?nocache=1 re-downloads instead of reading the segment cache;
+	// ?provider=<name> forces the download provider (implies nocache).
+	// ?remux=mp4 repackages to fragmented MP4 via ffmpeg (transcode.enabled); not seekable.
 	s.mux.HandleFunc("/api/v1/play/", func(w http.ResponseWriter, r *http.Request) {
 		if s.jobs == nil {
 			w.Header().Set("Content-Type", "application/json")
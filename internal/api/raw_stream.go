@@ -12,10 +12,9 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
 
+	"github.com/gaby/EDRmount/internal/cache"
 	"github.com/gaby/EDRmount/internal/streamer"
-	"github.com/gaby/EDRmount/internal/subject"
 )
 
 func (s *Server) handleRawFileStream(w http.ResponseWriter, r *http.Request) {
@@ -32,10 +31,25 @@ func (s *Server) handleRawFileStream(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 90*time.Second)
-	defer cancel()
 	cfg := s.Config()
-	st := streamer.New(cfg.Download, s.jobs, cfg.Paths.CacheDir, cfg.Paths.CacheMaxBytes)
+	release, ok := s.acquireStream(w, r, cfg)
+	if !ok {
+		return
+	}
+	defer release()
+	ctx, cancel := streamContext(r.Context(), cfg)
+	defer cancel()
+	dl, tr, ok := streamDebug(w, r, cfg)
+	if !ok {
+		return
+	}
+	if tr != nil {
+		ctx = streamer.WithTrace(ctx, tr)
+		defer writeStreamTrace(w, tr)
+	}
+	st := streamer.New(dl, s.jobs, cfg.Paths.CacheDir, cfg.Paths.CacheMaxBytes)
+	st.CompressCache = cfg.Paths.CompressCache
+	st.Eviction = cache.NewEviction(cfg.Paths.CacheEviction, cfg.Paths.CachePinnedImports)
 
 	// Find matching file_idx by subject-derived filename and also get total bytes.
 	rows, err := s.jobs.DB().SQL.QueryContext(ctx, `SELECT idx,filename,subject,total_bytes FROM nzb_files WHERE import_id=? ORDER BY idx ASC`, importID)
@@ -49,6 +63,7 @@ func (s *Server) handleRawFileStream(w http.ResponseWriter, r *http.Request) {
 	fileIdx := -1
 	var size int64
 	seen := map[string]int{}
+	ex := s.subjectExtractor()
 	for rows.Next() {
 		var idx int
 		var dbfn sql.NullString
@@ -59,7 +74,7 @@ func (s *Server) handleRawFileStream(w http.ResponseWriter, r *http.Request) {
 		if dbfn.Valid {
 			base = dbfn.String
 		} else {
-			f2, ok := subject.FilenameFromSubject(subj)
+			f2, _, ok := ex.Filename(subj)
 			if ok {
 				base = f2
 			}
@@ -94,7 +109,14 @@ func (s *Server) handleRawFileStream(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/octet-stream")
 	w.Header().Set("Accept-Ranges", "bytes")
 
-	mr, perr := parseRanges(r.Header.Get("Range"), size)
+	etag, lastMod := s.streamValidators(ctx, importID, fileIdx, size)
+	setStreamValidators(w, etag, lastMod)
+	if streamNotModified(r, etag, lastMod) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	mr, perr := parseRanges(streamRangeHeader(r, etag, lastMod), size)
 	if perr != nil {
 		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
 		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
@@ -202,7 +224,12 @@ func (s *Server) handlePlayStream(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 90*time.Second)
+	release, ok := s.acquireStream(w, r, s.Config())
+	if !ok {
+		return
+	}
+	defer release()
+	ctx, cancel := streamContext(r.Context(), s.Config())
 	defer cancel()
 
 	var (
@@ -234,7 +261,7 @@ func (s *Server) handlePlayStream(w http.ResponseWriter, r *http.Request) {
 	if filename == "" {
 		if dbFilename.Valid && strings.TrimSpace(dbFilename.String) != "" {
 			filename = strings.TrimSpace(dbFilename.String)
-		} else if fn, ok := subject.FilenameFromSubject(subj); ok {
+		} else if fn, _, ok := s.subjectExtractor().Filename(subj); ok {
 			filename = fn
 		} else {
 			filename = fmt.Sprintf("file_%04d.bin", fileIdx)
@@ -244,8 +271,26 @@ func (s *Server) handlePlayStream(w http.ResponseWriter, r *http.Request) {
 	log.Printf("PLAY start import=%s fileIdx=%d method=%s range=%q ua=%q remote=%s", importID, fileIdx, r.Method, r.Header.Get("Range"), r.UserAgent(), r.RemoteAddr)
 	defer log.Printf("PLAY end import=%s fileIdx=%d method=%s", importID, fileIdx, r.Method)
 
+	if r.Method == http.MethodGet {
+		if playID, err := s.jobs.RecordPlayStart(ctx, importID, fileIdx, r.UserAgent()); err == nil {
+			cw := &playCountingWriter{ResponseWriter: w}
+			w = cw
+			defer func() { _ = s.jobs.RecordPlayEnd(context.Background(), playID, cw.n) }()
+		}
+	}
+
 	cfg := s.Config()
-	st := streamer.New(cfg.Download, s.jobs, cfg.Paths.CacheDir, cfg.Paths.CacheMaxBytes)
+	dl, tr, ok := streamDebug(w, r, cfg)
+	if !ok {
+		return
+	}
+	if tr != nil {
+		ctx = streamer.WithTrace(ctx, tr)
+		defer writeStreamTrace(w, tr)
+	}
+	st := streamer.New(dl, s.jobs, cfg.Paths.CacheDir, cfg.Paths.CacheMaxBytes)
+	st.CompressCache = cfg.Paths.CompressCache
+	st.Eviction = cache.NewEviction(cfg.Paths.CacheEviction, cfg.Paths.CachePinnedImports)
 
 	w.Header().Set("Content-Type", "application/octet-stream")
 	w.Header().Set("Accept-Ranges", "bytes")
@@ -254,7 +299,38 @@ func (s *Server) handlePlayStream(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("X-EDR-Import-ID", importID)
 	w.Header().Set("X-EDR-File-Idx", strconv.Itoa(fileIdx))
 
-	mr, perr := parseRanges(r.Header.Get("Range"), size)
+	// ?remux=mp4 streams the whole file through ffmpeg; it is bounded by the client
+	// connection rather than the stream idle timeout above.
+	if remux := strings.TrimSpace(r.URL.Query().Get("remux")); remux != "" {
+		rctx := r.Context()
+		if tr != nil {
+			rctx = streamer.WithTrace(rctx, tr)
+		}
+		s.servePlayRemux(rctx, w, r, st, importID, fileIdx, filename, size, strings.ToLower(remux))
+		return
+	}
+
+	etag, lastMod := s.streamValidators(ctx, importID, fileIdx, size)
+	setStreamValidators(w, etag, lastMod)
+	if streamNotModified(r, etag, lastMod) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// Fully cached (e.g. an earlier full download): let net/http serve ranges from disk.
+	// Debug traces (?nocache/?provider) always go to the network.
+	if cfg.Paths.ServeCachedFiles && tr == nil {
+		if localPath, ok := st.CachedFile(importID, filename, size); ok {
+			if f, err := os.Open(localPath); err == nil {
+				defer f.Close()
+				w.Header().Set("X-EDR-Cached", "1")
+				http.ServeContent(w, r, filename, lastMod, f)
+				return
+			}
+		}
+	}
+
+	mr, perr := parseRanges(streamRangeHeader(r, etag, lastMod), size)
 	if perr != nil {
 		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
 		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
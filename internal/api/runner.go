@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gaby/EDRmount/internal/jobs"
+)
+
+func (s *Server) registerRunnerRoutes() {
+	setPaused := func(paused bool) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if s.jobs == nil {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": "jobs db not configured"})
+				return
+			}
+			if r.Method != http.MethodPost {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			if err := s.jobs.SetPaused(r.Context(), paused); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "paused": paused})
+		}
+	}
+	s.mux.HandleFunc("/api/v1/runner/pause", setPaused(true))
+	s.mux.HandleFunc("/api/v1/runner/resume", setPaused(false))
+
+	s.mux.HandleFunc("/api/v1/runner/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if s.jobs == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "jobs db not configured"})
+			return
+		}
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		paused, err := s.jobs.Paused(r.Context())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		counts := map[string]int{}
+		for _, st := range []jobs.State{jobs.StateQueued, jobs.StateRunning} {
+			var n int
+			_ = s.jobs.DB().SQL.QueryRowContext(r.Context(), `SELECT COUNT(*) FROM jobs WHERE state=?`, string(st)).Scan(&n)
+			counts[string(st)] = n
+		}
+		// Imports waiting for a TMDB enrichment retry (library_resolve_pending).
+		resolvePending, _ := s.jobs.ResolvePendingCount(r.Context())
+		cfg := s.Config()
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"enabled":         cfg.Runner.Enabled,
+			"paused":          paused,
+			"queued":          counts[string(jobs.StateQueued)],
+			"running":         counts[string(jobs.StateRunning)],
+			"resolve_pending": resolvePending,
+		})
+	})
+}
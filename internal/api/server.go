@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"runtime"
 	"time"
 
 	"strconv"
@@ -15,6 +16,7 @@ import (
 	"github.com/gaby/EDRmount/internal/config"
 	"github.com/gaby/EDRmount/internal/db"
 	"github.com/gaby/EDRmount/internal/jobs"
+	"github.com/gaby/EDRmount/internal/nntp"
 	"github.com/gaby/EDRmount/internal/version"
 )
 
@@ -27,6 +29,8 @@ type Server struct {
 	cfgPath string
 	mux     *http.ServeMux
 	jobs    *jobs.Store
+	started time.Time
+	streams streamClients // open raw/play streams per client
 }
 
 func (s *Server) Config() config.Config {
@@ -39,6 +43,7 @@ func (s *Server) setConfig(next config.Config) {
 	s.cfgMu.Lock()
 	s.cfg = next
 	s.cfgMu.Unlock()
+	nntp.GlobalLimiter().SetLimit(next.Download.Connections)
 }
 
 type Options struct {
@@ -47,7 +52,9 @@ type Options struct {
 }
 
 func New(cfg config.Config, opts Options) (*Server, func() error, error) {
-	s := &Server{cfg: cfg, cfgPath: opts.ConfigPath, mux: http.NewServeMux()}
+	s := &Server{cfg: cfg, cfgPath: opts.ConfigPath, mux: http.NewServeMux(), started: time.Now()}
+	// Every NNTP consumer (streaming, health scan/repair) shares download.connections.
+	nntp.GlobalLimiter().SetLimit(cfg.Download.Connections)
 
 	closers := []func() error{}
 	if opts.DBPath != "" {
@@ -80,12 +87,32 @@ func New(cfg config.Config, opts Options) (*Server, func() error, error) {
 		})
 	})
 
+	// Build info + uptime; cheap and stable for monitoring to poll.
+	s.mux.HandleFunc("/api/v1/version", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		uptime := time.Since(s.started).Truncate(time.Second)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"version":        version.Version,
+			"commit":         version.Commit,
+			"build_date":     version.Date,
+			"go_version":     runtime.Version(),
+			"started_at":     s.started.Format(time.RFC3339),
+			"uptime":         uptime.String(),
+			"uptime_seconds": int64(uptime.Seconds()),
+		})
+	})
+
 	// Basic API (UI consumes this)
 	s.mux.HandleFunc("/api/v1/config", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		switch r.Method {
 		case http.MethodGet:
-			_ = json.NewEncoder(w).Encode(s.Config())
+			// Secrets never leave the server; a PUT with them blank keeps the current ones.
+			_ = json.NewEncoder(w).Encode(s.Config().Redacted())
 		case http.MethodPut:
 			b, err := io.ReadAll(r.Body)
 			if err != nil {
@@ -99,11 +126,17 @@ func New(cfg config.Config, opts Options) (*Server, func() error, error) {
 				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 				return
 			}
+			next = next.WithCredentialsFrom(s.Config()).WithSetupFrom(s.Config())
 			if err := next.Validate(); err != nil {
 				w.WriteHeader(http.StatusBadRequest)
 				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 				return
 			}
+			if err := next.ValidateTools(); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
 			// Persist to disk and apply in-memory
 			if err := config.Save(s.cfgPath, next); err != nil {
 				w.WriteHeader(http.StatusInternalServerError)
@@ -111,7 +144,7 @@ func New(cfg config.Config, opts Options) (*Server, func() error, error) {
 				return
 			}
 			s.setConfig(next)
-			_ = json.NewEncoder(w).Encode(s.Config())
+			_ = json.NewEncoder(w).Encode(s.Config().Redacted())
 		default:
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
@@ -134,6 +167,8 @@ func New(cfg config.Config, opts Options) (*Server, func() error, error) {
 
 	// DB admin
 	s.mux.HandleFunc("/api/v1/db/reset", s.handleDBReset)
+	s.mux.HandleFunc("/api/v1/db/check", s.handleDBCheck)
+	s.mux.HandleFunc("/api/v1/db/vacuum", s.handleDBVacuum)
 
 	// Jobs API
 	s.mux.HandleFunc("/api/v1/jobs", func(w http.ResponseWriter, r *http.Request) {
@@ -204,6 +239,11 @@ func New(cfg config.Config, opts Options) (*Server, func() error, error) {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
 		}
+		if !s.Config().Runner.HasRole(config.RoleUpload) {
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "upload role disabled (runner.roles)"})
+			return
+		}
 		var payload struct {
 			Path string `json:"path"`
 		}
@@ -223,21 +263,37 @@ func New(cfg config.Config, opts Options) (*Server, func() error, error) {
 
 	// Extra routes
 	s.registerJobLogRoutes()
+	s.registerJobOrderRoutes()
 	s.registerProviderRoutes()
 	s.registerCatalogRoutes()
+	s.registerCatalogSearchRoutes()
 	s.registerImportDeleteRoutes()
 	s.registerCatalogFileRoutes()
 	s.registerRawRoutes()
 	s.registerManualLibraryRoutes()
+	s.registerManualLabelRoutes()
 	s.registerManualImportRoutes()
 	s.registerManualMediaUploadRoutes()
+	s.registerCollectionRoutes()
+	s.registerPlexRoutes()
+	s.registerPlayHistoryRoutes()
+	s.registerMaintenanceRoutes()
+	s.registerTrashRoutes()
+	s.registerMetricsRoutes()
+	s.registerSettingsBundleRoutes()
 	s.registerHostFSRoutes()
 	s.registerLibraryReviewRoutes()
+	s.registerLibraryConflictRoutes()
 	s.registerLibraryAutoListRoutes()
 	s.registerLibraryTemplatesRoutes()
 	s.registerUploadSummaryRoutes()
 	s.registerHealthRoutes()
+	s.registerNZBValidateRoutes()
 	s.registerFileBotRoutes()
+	s.registerSystemToolsRoutes()
+	s.registerSubjectRoutes()
+	s.registerRunnerRoutes()
+	s.registerSetupRoutes()
 
 	// Backups
 	s.registerBackupRoutes(opts.DBPath)
@@ -265,6 +321,6 @@ func New(cfg config.Config, opts Options) (*Server, func() error, error) {
 	return s, closeFn, nil
 }
 
-func (s *Server) Handler() http.Handler { return s.mux }
+func (s *Server) Handler() http.Handler { return s.withAuth(s.mux) }
 
 func (s *Server) Jobs() *jobs.Store { return s.jobs }
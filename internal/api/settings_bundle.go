@@ -0,0 +1,350 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gaby/EDRmount/internal/config"
+	"github.com/gaby/EDRmount/internal/version"
+)
+
+const settingsBundleVersion = 1
+
+// settingsBundle is the portable export of an instance's settings: config plus the
+// UI-managed library state. Import ids differ between instances, so rows that point
+// at an import also carry its NZB path to be re-matched on import.
+type settingsBundle struct {
+	Version     int             `json:"version"`
+	ExportedAt  int64           `json:"exported_at"`
+	AppVersion  string          `json:"app_version"`
+	Credentials string          `json:"credentials"` // included|redacted
+	Config      json.RawMessage `json:"config"`
+
+	ManualDirs       []bundleManualDir `json:"manual_dirs"`
+	ManualItems      []bundleItem      `json:"manual_items"`
+	LibraryOverrides []bundleOverride  `json:"library_overrides"`
+}
+
+type bundleManualDir struct {
+	ID       string `json:"id"`
+	ParentID string `json:"parent_id"`
+	Name     string `json:"name"`
+	Source   string `json:"source"`
+}
+
+type bundleItem struct {
+	ID       string `json:"id"`
+	DirID    string `json:"dir_id"`
+	Label    string `json:"label"`
+	ImportID string `json:"import_id"`
+	FileIdx  int    `json:"file_idx"`
+	NZBPath  string `json:"nzb_path"`
+}
+
+type bundleOverride struct {
+	ImportID  string `json:"import_id"`
+	FileIdx   int    `json:"file_idx"`
+	NZBPath   string `json:"nzb_path"`
+	Kind      string `json:"kind"`
+	Title     string `json:"title"`
+	Year      int    `json:"year"`
+	Quality   string `json:"quality"`
+	TMDBID    int    `json:"tmdb_id"`
+	Season    int    `json:"season"`
+	Episode   int    `json:"episode"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+// bundleImportResolver maps a bundle row to a local import id: same id first, then the
+// same NZB path, then the same NZB file name.
+type bundleImportResolver struct {
+	ids    map[string]bool
+	byPath map[string]string
+	byBase map[string]string
+}
+
+func newBundleImportResolver(ctx context.Context, db *sql.DB) (*bundleImportResolver, error) {
+	rows, err := db.QueryContext(ctx, `SELECT id, path FROM nzb_imports ORDER BY imported_at ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := &bundleImportResolver{ids: map[string]bool{}, byPath: map[string]string{}, byBase: map[string]string{}}
+	for rows.Next() {
+		var id, p string
+		if err := rows.Scan(&id, &p); err != nil {
+			continue
+		}
+		res.ids[id] = true
+		res.byPath[p] = id
+		res.byBase[filepath.Base(p)] = id
+	}
+	return res, rows.Err()
+}
+
+func (b *bundleImportResolver) resolve(importID, nzbPath string) (string, bool) {
+	if b.ids[importID] {
+		return importID, true
+	}
+	if nzbPath == "" {
+		return "", false
+	}
+	if id, ok := b.byPath[nzbPath]; ok {
+		return id, true
+	}
+	id, ok := b.byBase[filepath.Base(nzbPath)]
+	return id, ok
+}
+
+func (s *Server) registerSettingsBundleRoutes() {
+	// GET /api/v1/export/settings?credentials=include|redact (default redact)
+	// Config + manual tree + library overrides as one JSON file. Unlike /api/v1/backups
+	// this is not a sqlite snapshot: jobs, catalog and health state are left out.
+	s.mux.HandleFunc("/api/v1/export/settings", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if s.jobs == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "db not configured"})
+			return
+		}
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		creds := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("credentials")))
+		switch creds {
+		case "", "redact", "redacted":
+			creds = "redacted"
+		case "include", "included":
+			creds = "included"
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "credentials must be include or redact"})
+			return
+		}
+		cfg := s.Config()
+		if creds == "redacted" {
+			cfg = cfg.Redacted()
+		}
+		cfgJSON, err := json.Marshal(cfg)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		b := settingsBundle{
+			Version:          settingsBundleVersion,
+			ExportedAt:       time.Now().Unix(),
+			AppVersion:       version.Version,
+			Credentials:      creds,
+			Config:           cfgJSON,
+			ManualDirs:       make([]bundleManualDir, 0),
+			ManualItems:      make([]bundleItem, 0),
+			LibraryOverrides: make([]bundleOverride, 0),
+		}
+		db := s.jobs.DB().SQL
+		ctx := r.Context()
+
+		rows, err := db.QueryContext(ctx, `SELECT id, parent_id, name, source FROM manual_dirs ORDER BY id`)
+		if err == nil {
+			for rows.Next() {
+				var d bundleManualDir
+				if rows.Scan(&d.ID, &d.ParentID, &d.Name, &d.Source) == nil {
+					b.ManualDirs = append(b.ManualDirs, d)
+				}
+			}
+			rows.Close()
+		}
+		rows, err = db.QueryContext(ctx, `
+			SELECT m.id, m.dir_id, m.label, m.import_id, m.file_idx, COALESCE(i.path,'')
+			FROM manual_items m LEFT JOIN nzb_imports i ON i.id=m.import_id
+			ORDER BY m.id
+		`)
+		if err == nil {
+			for rows.Next() {
+				var it bundleItem
+				if rows.Scan(&it.ID, &it.DirID, &it.Label, &it.ImportID, &it.FileIdx, &it.NZBPath) == nil {
+					b.ManualItems = append(b.ManualItems, it)
+				}
+			}
+			rows.Close()
+		}
+		rows, err = db.QueryContext(ctx, `
+			SELECT o.import_id, o.file_idx, COALESCE(i.path,''), o.kind, o.title, o.year, o.quality, o.tmdb_id, o.season, o.episode, o.updated_at
+			FROM library_overrides o LEFT JOIN nzb_imports i ON i.id=o.import_id
+			ORDER BY o.import_id, o.file_idx
+		`)
+		if err == nil {
+			for rows.Next() {
+				var o bundleOverride
+				if rows.Scan(&o.ImportID, &o.FileIdx, &o.NZBPath, &o.Kind, &o.Title, &o.Year, &o.Quality, &o.TMDBID, &o.Season, &o.Episode, &o.UpdatedAt) == nil {
+					b.LibraryOverrides = append(b.LibraryOverrides, o)
+				}
+			}
+			rows.Close()
+		}
+
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "edrmount-settings-"+time.Now().Format("20060102-150405")+".json"))
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(b)
+	})
+
+	// POST /api/v1/import/settings (body: export bundle)
+	// Applies the config (redacted credentials keep the current values), replaces the
+	// manual tree and upserts overrides. Rows whose import can't be matched on this
+	// instance are skipped and counted.
+	s.mux.HandleFunc("/api/v1/import/settings", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if s.jobs == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "db not configured"})
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var b settingsBundle
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<20)).Decode(&b); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		if b.Version <= 0 || b.Version > settingsBundleVersion {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("unsupported bundle version %d", b.Version)})
+			return
+		}
+
+		var next config.Config
+		hasConfig := len(b.Config) > 0 && string(b.Config) != "null"
+		if hasConfig {
+			next = config.Default()
+			if err := json.Unmarshal(b.Config, &next); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": "config: " + err.Error()})
+				return
+			}
+			// Blank credentials keep the current ones: redacted exports have them all
+			// blank, and even an "included" bundle may lack some (e.g. no Plex token).
+			next = next.WithCredentialsFrom(s.Config()).WithSetupFrom(s.Config())
+			if err := next.Validate(); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+			if err := next.ValidateTools(); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+		}
+
+		ctx := r.Context()
+		db := s.jobs.DB().SQL
+		res, err := newBundleImportResolver(ctx, db)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		defer func() { _ = tx.Rollback() }()
+		fail := func(err error) {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		}
+
+		dirs, items, itemsSkipped, overrides, overridesSkipped := 0, 0, 0, 0, 0
+		if b.ManualDirs != nil || b.ManualItems != nil {
+			for _, q := range []string{`DELETE FROM manual_items`, `DELETE FROM manual_dirs`} {
+				if _, err := tx.ExecContext(ctx, q); err != nil {
+					fail(err)
+					return
+				}
+			}
+			for _, d := range b.ManualDirs {
+				if strings.TrimSpace(d.ID) == "" || strings.TrimSpace(d.Name) == "" {
+					continue
+				}
+				if d.Source == "" {
+					d.Source = "user"
+				}
+				if _, err := tx.ExecContext(ctx, `INSERT OR REPLACE INTO manual_dirs(id,parent_id,name,source) VALUES(?,?,?,?)`, d.ID, d.ParentID, d.Name, d.Source); err != nil {
+					fail(err)
+					return
+				}
+				dirs++
+			}
+			for _, it := range b.ManualItems {
+				importID, ok := res.resolve(it.ImportID, it.NZBPath)
+				if !ok || strings.TrimSpace(it.ID) == "" {
+					itemsSkipped++
+					continue
+				}
+				if _, err := tx.ExecContext(ctx, `INSERT OR REPLACE INTO manual_items(id,dir_id,label,import_id,file_idx) VALUES(?,?,?,?,?)`, it.ID, it.DirID, it.Label, importID, it.FileIdx); err != nil {
+					fail(err)
+					return
+				}
+				items++
+			}
+		}
+		for _, o := range b.LibraryOverrides {
+			importID, ok := res.resolve(o.ImportID, o.NZBPath)
+			if !ok {
+				overridesSkipped++
+				continue
+			}
+			if o.UpdatedAt == 0 {
+				o.UpdatedAt = time.Now().Unix()
+			}
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO library_overrides(import_id,file_idx,kind,title,year,quality,tmdb_id,season,episode,updated_at) VALUES(?,?,?,?,?,?,?,?,?,?)
+				ON CONFLICT(import_id,file_idx) DO UPDATE SET kind=excluded.kind, title=excluded.title, year=excluded.year, quality=excluded.quality,
+					tmdb_id=excluded.tmdb_id, season=excluded.season, episode=excluded.episode, updated_at=excluded.updated_at
+			`, importID, o.FileIdx, o.Kind, o.Title, o.Year, o.Quality, o.TMDBID, o.Season, o.Episode, o.UpdatedAt); err != nil {
+				fail(err)
+				return
+			}
+			overrides++
+		}
+		if err := tx.Commit(); err != nil {
+			fail(err)
+			return
+		}
+		if overrides > 0 {
+			s.jobs.TouchLibrary()
+		}
+
+		if hasConfig {
+			if err := config.Save(s.cfgPath, next); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": "config: " + err.Error()})
+				return
+			}
+			s.setConfig(next)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"ok":                        true,
+			"config_applied":            hasConfig,
+			"credentials":               b.Credentials,
+			"manual_dirs":               dirs,
+			"manual_items":              items,
+			"manual_items_skipped":      itemsSkipped,
+			"library_overrides":         overrides,
+			"library_overrides_skipped": overridesSkipped,
+		})
+	})
+}
@@ -0,0 +1,19 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+func (s *Server) registerSetupRoutes() {
+	// GET /api/v1/setup/status: which subsystems still need configuring (drives the
+	// first-run wizard). Read-only over the current config.
+	s.mux.HandleFunc("/api/v1/setup/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(s.Config().SetupStatus())
+	})
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gaby/EDRmount/internal/config"
+)
+
+// streamClients counts the open raw/play streams per client for
+// server.max_streams_per_client.
+type streamClients struct {
+	mu     sync.Mutex
+	active map[string]int
+}
+
+// streamClientKey identifies the client of r according to server.stream_client_id:
+// "ip" (default) the peer address, "forwarded" the last X-Forwarded-For hop (the one
+// the reverse proxy appended; earlier hops come from the client and can be forged),
+// "header" the server.stream_client_header value. The last two fall back to the peer
+// address when the header is missing.
+func streamClientKey(r *http.Request, cfg config.Config) string {
+	switch strings.ToLower(strings.TrimSpace(cfg.Server.StreamClientID)) {
+	case "header":
+		name := strings.TrimSpace(cfg.Server.StreamClientHeader)
+		if name == "" {
+			name = config.DefaultStreamClientHeader
+		}
+		if v := strings.TrimSpace(r.Header.Get(name)); v != "" {
+			return "id:" + v
+		}
+	case "forwarded":
+		if vs := r.Header.Values("X-Forwarded-For"); len(vs) > 0 {
+			hops := strings.Split(vs[len(vs)-1], ",")
+			if last := strings.TrimSpace(hops[len(hops)-1]); last != "" {
+				return "ip:" + last
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// acquireStream registers a stream for the client of r, or answers 429 when it
+// already has server.max_streams_per_client open. The caller must call release once
+// the stream is done.
+func (s *Server) acquireStream(w http.ResponseWriter, r *http.Request, cfg config.Config) (release func(), ok bool) {
+	limit := cfg.Server.MaxStreamsPerClient
+	if limit <= 0 {
+		return func() {}, true
+	}
+	key := streamClientKey(r, cfg)
+	c := &s.streams
+	c.mu.Lock()
+	if c.active[key] >= limit {
+		c.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", "5")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_ = json.NewEncoder(w).Encode(map[string]any{"error": "too many concurrent streams for this client", "limit": limit})
+		return nil, false
+	}
+	if c.active == nil {
+		c.active = map[string]int{}
+	}
+	c.active[key]++
+	c.mu.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			if c.active[key]--; c.active[key] <= 0 {
+				delete(c.active, key)
+			}
+			c.mu.Unlock()
+		})
+	}, true
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gaby/EDRmount/internal/config"
+	"github.com/gaby/EDRmount/internal/streamer"
+)
+
+// streamDebug handles the stream debugging query params:
+//
+//	?nocache=1          ignore /cache/rawseg (and the full-file cache) and re-download,
+//	                    still writing the fresh result back to the cache.
+//	?provider=<name>    force the fetch through the named download provider; implies nocache.
+//
+// It returns the provider to stream from and a trace (nil when no debugging was requested).
+// On an unknown provider it writes a 400 and returns ok=false.
+func streamDebug(w http.ResponseWriter, r *http.Request, cfg config.Config) (config.DownloadProvider, *streamer.Trace, bool) {
+	q := r.URL.Query()
+	provider := strings.TrimSpace(q.Get("provider"))
+	noCache := q.Get("nocache") == "1" || strings.EqualFold(q.Get("nocache"), "true")
+	if provider == "" && !noCache {
+		return cfg.Download, nil, true
+	}
+	dl, ok := downloadProviderByName(cfg, provider)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "unknown provider: " + provider})
+		return dl, nil, false
+	}
+	w.Header().Set("X-EDR-Provider", dl.Host)
+	w.Header().Set("X-EDR-Nocache", "1")
+	w.Header().Set("Trailer", "X-EDR-Fetch-Ms, X-EDR-Segments-Fetched, X-EDR-Segments-Cached")
+	return dl, &streamer.Trace{NoCache: true}, true
+}
+
+// downloadProviderByName resolves a ?provider= value. There is a single download
+// provider today; it answers to "", "default", "primary" or its host name.
+func downloadProviderByName(cfg config.Config, name string) (config.DownloadProvider, bool) {
+	switch strings.ToLower(name) {
+	case "", "default", "primary":
+		return cfg.Download, true
+	}
+	if strings.EqualFold(name, cfg.Download.Host) {
+		return cfg.Download, true
+	}
+	return config.DownloadProvider{}, false
+}
+
+// writeStreamTrace fills the diagnostics trailers declared by streamDebug.
+func writeStreamTrace(w http.ResponseWriter, tr *streamer.Trace) {
+	if tr == nil {
+		return
+	}
+	w.Header().Set("X-EDR-Fetch-Ms", strconv.FormatInt(tr.FetchTime().Milliseconds(), 10))
+	w.Header().Set("X-EDR-Segments-Fetched", strconv.FormatInt(tr.Fetched.Load(), 10))
+	w.Header().Set("X-EDR-Segments-Cached", strconv.FormatInt(tr.CacheHits.Load(), 10))
+}
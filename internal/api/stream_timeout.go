@@ -0,0 +1,27 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/gaby/EDRmount/internal/config"
+	"github.com/gaby/EDRmount/internal/streamer"
+)
+
+// streamContext derives the context for a raw/play stream. With
+// server.stream_timeout_seconds set it is cancelled after that long without
+// progress; every segment the streamer fetches (or reads from cache) pushes the
+// deadline back, so big cold reads are not cut off mid-download.
+func streamContext(parent context.Context, cfg config.Config) (context.Context, context.CancelFunc) {
+	d := time.Duration(cfg.Server.StreamTimeoutSeconds) * time.Second
+	if d <= 0 {
+		return context.WithCancel(parent)
+	}
+	ctx, cancel := context.WithCancel(parent)
+	idle := time.AfterFunc(d, cancel)
+	ctx = streamer.WithProgress(ctx, func() { idle.Reset(d) })
+	return ctx, func() {
+		idle.Stop()
+		cancel()
+	}
+}
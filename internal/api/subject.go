@@ -0,0 +1,59 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gaby/EDRmount/internal/subject"
+)
+
+// subjectExtractor builds the filename extractor from the live config.
+// Invalid patterns are rejected on config save, so errors fall back to the heuristic.
+func (s *Server) subjectExtractor() *subject.Extractor {
+	ex, err := subject.NewExtractor(s.Config().Subject.Patterns)
+	if err != nil {
+		return nil
+	}
+	return ex
+}
+
+func (s *Server) registerSubjectRoutes() {
+	// Try extraction patterns against sample subjects before saving them in config.
+	s.mux.HandleFunc("/api/v1/subject/test", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			Patterns []string `json:"patterns"`
+			Subjects []string `json:"subjects"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		if req.Patterns == nil {
+			req.Patterns = s.Config().Subject.Patterns
+		}
+		ex, err := subject.NewExtractor(req.Patterns)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		type result struct {
+			Subject  string `json:"subject"`
+			Filename string `json:"filename"`
+			OK       bool   `json:"ok"`
+			Pattern  int    `json:"pattern"` // -1 = built-in heuristic
+		}
+		out := make([]result, 0, len(req.Subjects))
+		for _, subj := range req.Subjects {
+			fn, idx, ok := ex.Filename(subj)
+			out = append(out, result{Subject: subj, Filename: fn, OK: ok, Pattern: idx})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "results": out})
+	})
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+func (s *Server) registerSystemToolsRoutes() {
+	// GET /api/v1/system/tools
+	// Reports whether ngpost, nyuu, par2 and filebot are installed and executable, and
+	// which enabled features need them. ok=false when a needed one is missing.
+	s.mux.HandleFunc("/api/v1/system/tools", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		cfg := s.Config()
+		tools := cfg.Tools()
+		ok := true
+		for _, t := range tools {
+			if t.Required && !t.OK {
+				ok = false
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"ok":            ok,
+			"tools":         tools,
+			"missing_tools": cfg.Runner.MissingTools,
+		})
+	})
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gaby/EDRmount/internal/config"
+	"github.com/gaby/EDRmount/internal/trash"
+)
+
+// trashNZBRoot is the folder trashed NZBs are relative to (and restored into).
+func trashNZBRoot(cfg config.Config) string {
+	root := strings.TrimSpace(cfg.Watch.NZB.Dir)
+	if root == "" {
+		root = strings.TrimSpace(cfg.Paths.NzbInbox)
+	}
+	if root == "" {
+		root = "/host/inbox/nzb"
+	}
+	return filepath.Clean(root)
+}
+
+// trashParRoot is the folder trashed PAR2 files are relative to (and restored into).
+func trashParRoot(cfg config.Config) string {
+	root := strings.TrimSpace(cfg.Upload.Par.Dir)
+	if root == "" {
+		root = "/host/inbox/par2"
+	}
+	return filepath.Clean(root)
+}
+
+func (s *Server) registerTrashRoutes() {
+	// GET /api/v1/trash
+	// Lists the files delete_full moved to the trash, newest first.
+	s.mux.HandleFunc("/api/v1/trash", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		items, err := trash.List(trash.Root)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		var total int64
+		for _, it := range items {
+			total += it.Size
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"items":          items,
+			"count":          len(items),
+			"bytes":          total,
+			"retention_days": s.Config().Trash.RetentionDays,
+		})
+	})
+
+	// POST /api/v1/trash/empty {older_than_days?}
+	// Purges the trash now; without older_than_days everything is removed.
+	s.mux.HandleFunc("/api/v1/trash/empty", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			OlderThanDays int `json:"older_than_days"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		var cutoff time.Time
+		if req.OlderThanDays > 0 {
+			cutoff = time.Now().Add(-time.Duration(req.OlderThanDays) * 24 * time.Hour)
+		}
+		n, size, err := trash.Purge(trash.Root, cutoff)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]any{"error": err.Error(), "removed": n, "bytes": size})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "removed": n, "bytes": size})
+	})
+
+	// POST /api/v1/trash/restore {path}
+	// Moves a trashed file (path as listed by /api/v1/trash) back under the current NZB
+	// or PAR2 root. A restored NZB is no longer suppressed, so the watcher imports it again.
+	s.mux.HandleFunc("/api/v1/trash/restore", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			Path string `json:"path"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		if strings.TrimSpace(req.Path) == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "path required"})
+			return
+		}
+		kind, _, rel, src, err := trash.Resolve(trash.Root, req.Path)
+		if errors.Is(err, os.ErrNotExist) {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "not found in trash"})
+			return
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		cfg := s.Config()
+		root := trashNZBRoot(cfg)
+		if kind == "par2" {
+			root = trashParRoot(cfg)
+		}
+		dst := filepath.Join(root, rel)
+		if _, err := os.Stat(dst); err == nil {
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "destination exists: " + dst})
+			return
+		}
+		if err := relocateFile(src, dst); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		trash.RemoveEmpty(filepath.Dir(src), filepath.Join(trash.Root, kind))
+		if kind == "nzb" && s.jobs != nil {
+			_ = s.jobs.Unsuppress(r.Context(), dst, "nzb")
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "kind": kind, "restored_to": dst})
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/gaby/EDRmount/internal/config"
 	"github.com/gaby/EDRmount/internal/jobs"
 )
 
@@ -31,6 +32,11 @@ func (s *Server) registerManualMediaUploadRoutes() {
 		}
 
 		cfg := s.Config()
+		if !cfg.Runner.HasRole(config.RoleUpload) {
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "upload role disabled (runner.roles)"})
+			return
+		}
 		cacheDir := strings.TrimSpace(cfg.Paths.CacheDir)
 		if cacheDir == "" {
 			cacheDir = "/cache"
@@ -2,23 +2,21 @@ package api
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/gaby/EDRmount/internal/jobs"
 )
 
 type uploadSummary struct {
-	ID        string      `json:"id"`
-	State     jobs.State  `json:"state"`
-	UpdatedAt string      `json:"updated_at"`
-	Path      string      `json:"path"`
-	Phase     string      `json:"phase"`
-	Progress  int         `json:"progress"`
-	LastLine  string      `json:"last_line"`
-	Error     *string     `json:"error,omitempty"`
+	ID        string     `json:"id"`
+	State     jobs.State `json:"state"`
+	UpdatedAt string     `json:"updated_at"`
+	Path      string     `json:"path"`
+	Phase     string     `json:"phase"`
+	Progress  int        `json:"progress"`
+	LastLine  string     `json:"last_line"`
+	Error     *string    `json:"error,omitempty"`
 }
 
 func (s *Server) registerUploadSummaryRoutes() {
@@ -47,47 +45,25 @@ func (s *Server) registerUploadSummaryRoutes() {
 				continue
 			}
 			// payload contains {"path":"..."}
-			var p struct{ Path string `json:"path"` }
+			var p struct {
+				Path string `json:"path"`
+			}
 			_ = json.Unmarshal(j.Payload, &p)
 
 			lines, _ := s.jobs.GetLogs(r.Context(), j.ID, 20)
-			phase := ""
-			progress := 0
 			lastLine := ""
 			if len(lines) > 0 {
 				lastLine = lines[0]
-				for _, ln := range lines {
-					l := strings.TrimSpace(ln)
-					if strings.HasPrefix(l, "PHASE:") {
-						phase = strings.TrimSpace(strings.TrimPrefix(l, "PHASE:"))
-						break
-					}
-				}
-				for _, ln := range lines {
-					l := strings.TrimSpace(ln)
-					if strings.HasPrefix(l, "PROGRESS:") {
-						v := strings.TrimSpace(strings.TrimPrefix(l, "PROGRESS:"))
-						// best-effort parse int
-						for i := 0; i < len(v); i++ {
-							if v[i] < '0' || v[i] > '9' { v = v[:i]; break }
-						}
-						if v != "" {
-							var n int
-							_, _ = fmt.Sscanf(v, "%d", &n)
-							if n >= 0 && n <= 100 { progress = n }
-						}
-						break
-					}
-				}
 			}
+			prog, _ := parseJobProgress(lines)
 
 			out = append(out, uploadSummary{
 				ID:        j.ID,
 				State:     j.State,
 				UpdatedAt: j.UpdatedAt.Format(time.RFC3339),
 				Path:      p.Path,
-				Phase:     phase,
-				Progress:  progress,
+				Phase:     prog.Phase,
+				Progress:  prog.Percent,
 				LastLine:  lastLine,
 				Error:     j.Error,
 			})
@@ -22,6 +22,10 @@ type Config struct {
 	EveryMins  int
 	Keep       int
 	CompressGZ bool
+
+	RetryMins  int    // retry delay after a failed scheduled run
+	AlertAfter int    // consecutive failures before the alert is critical
+	WebhookURL string // failure notifications ("" = log only)
 }
 
 type Item struct {
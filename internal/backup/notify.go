@@ -0,0 +1,44 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Alert is the JSON body POSTed to backups.webhook_url when a scheduled backup fails.
+type Alert struct {
+	Event               string `json:"event"`    // "backup_failed"
+	Severity            string `json:"severity"` // "warning" | "critical" (streak >= alert_after_failures)
+	Error               string `json:"error"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	Dir                 string `json:"dir"`
+	Time                string `json:"time"`
+}
+
+// Notify POSTs a to url.
+func Notify(ctx context.Context, url string, a Alert) error {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook status %d", resp.StatusCode)
+	}
+	return nil
+}
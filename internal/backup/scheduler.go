@@ -2,7 +2,11 @@ package backup
 
 import (
 	"context"
+	"log"
+	"strings"
 	"time"
+
+	"github.com/gaby/EDRmount/internal/jobs"
 )
 
 type ConfigProvider func() Config
@@ -10,9 +14,13 @@ type ConfigProvider func() Config
 type Scheduler struct {
 	DBPath string
 	Cfg    ConfigProvider
+	// Jobs, when set, records every attempt in backup_runs.
+	Jobs *jobs.Store
 
-	lastKey string
-	lastRun time.Time
+	lastKey  string
+	lastRun  time.Time
+	lastFail time.Time
+	failures int // consecutive failures when Jobs is nil
 }
 
 func (s *Scheduler) Run(ctx context.Context) {
@@ -32,21 +40,74 @@ func (s *Scheduler) Run(ctx context.Context) {
 			if s.lastKey != key {
 				s.lastKey = key
 				s.lastRun = time.Time{}
+				s.lastFail = time.Time{}
 			}
 
 			if !s.lastRun.IsZero() && time.Since(s.lastRun) < time.Duration(cfg.EveryMins)*time.Minute {
 				continue
 			}
+			retry := cfg.RetryMins
+			if retry <= 0 {
+				retry = 5
+			}
+			if !s.lastFail.IsZero() && time.Since(s.lastFail) < time.Duration(retry)*time.Minute {
+				continue
+			}
 
-			_, err := RunOnce(ctx, s.DBPath, cfg.Dir, cfg.CompressGZ)
+			started := time.Now()
+			path, err := RunOnce(ctx, s.DBPath, cfg.Dir, cfg.CompressGZ)
+			if s.Jobs != nil {
+				_ = s.Jobs.RecordBackupRun(ctx, "scheduled", started, path, err)
+			}
 			if err == nil {
 				s.lastRun = time.Now()
+				s.lastFail = time.Time{}
+				s.failures = 0
 				Rotate(cfg.Dir, cfg.Keep)
+				continue
 			}
+			s.lastFail = time.Now()
+			s.failures++
+			s.alert(ctx, cfg, err)
 		}
 	}
 }
 
+// alert logs a failed scheduled backup and notifies the webhook, escalating to critical
+// once the failure streak reaches cfg.AlertAfter.
+func (s *Scheduler) alert(ctx context.Context, cfg Config, runErr error) {
+	streak := s.failures
+	if s.Jobs != nil {
+		if n, err := s.Jobs.BackupFailureStreak(ctx); err == nil {
+			streak = n
+		}
+	}
+	after := cfg.AlertAfter
+	if after <= 0 {
+		after = 3
+	}
+	severity := "warning"
+	if streak >= after {
+		severity = "critical"
+	}
+	log.Printf("backup: scheduled backup failed (%s, %d in a row): %v", severity, streak, runErr)
+	url := strings.TrimSpace(cfg.WebhookURL)
+	if url == "" {
+		return
+	}
+	a := Alert{
+		Event:               "backup_failed",
+		Severity:            severity,
+		Error:               runErr.Error(),
+		ConsecutiveFailures: streak,
+		Dir:                 cfg.Dir,
+		Time:                time.Now().Format(time.RFC3339),
+	}
+	if err := Notify(ctx, url, a); err != nil {
+		log.Printf("backup: webhook: %v", err)
+	}
+}
+
 func itoa(i int) string {
 	if i == 0 {
 		return "0"
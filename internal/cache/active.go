@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Eviction policies (paths.cache_eviction).
+const (
+	EvictOldest  = "oldest"  // oldest files first, whatever they belong to
+	EvictProtect = "protect" // skip imports being streamed and paths.cache_pinned_imports
+)
+
+// activeLinger keeps an import in use for a while after its last read ends: players
+// read in bursts and the segments prefetched for the next burst must survive the gap.
+const activeLinger = 30 * time.Second
+
+type activeImport struct {
+	refs int
+	last time.Time
+}
+
+// The streamers are created per request, so the set of imports being streamed is
+// package level.
+var (
+	activeMu sync.Mutex
+	active   = map[string]*activeImport{}
+)
+
+// Eviction is paths.cache_eviction with paths.cache_pinned_imports. The zero value is
+// the "oldest" policy.
+type Eviction struct {
+	protect bool
+	pinned  map[string]bool
+}
+
+// NewEviction returns the eviction settings of policy (EvictOldest unless EvictProtect)
+// and the pinned import ids.
+func NewEviction(policy string, pinnedImports []string) Eviction {
+	if policy != EvictProtect {
+		return Eviction{}
+	}
+	ev := Eviction{protect: true, pinned: make(map[string]bool, len(pinnedImports))}
+	for _, id := range pinnedImports {
+		if id = strings.TrimSpace(id); id != "" {
+			ev.pinned[id] = true
+		}
+	}
+	return ev
+}
+
+// Acquire marks importID as being streamed until the returned func is called.
+func Acquire(importID string) (release func()) {
+	activeMu.Lock()
+	a := active[importID]
+	if a == nil {
+		a = &activeImport{}
+		active[importID] = a
+	}
+	a.refs++
+	activeMu.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			activeMu.Lock()
+			defer activeMu.Unlock()
+			a.refs--
+			a.last = time.Now()
+		})
+	}
+}
+
+// InUse reports whether importID is being streamed (or was, within activeLinger).
+func InUse(importID string) bool {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	return inUseLocked(importID, time.Now())
+}
+
+func inUseLocked(importID string, now time.Time) bool {
+	a := active[importID]
+	if a == nil {
+		return false
+	}
+	if a.refs > 0 || now.Sub(a.last) < activeLinger {
+		return true
+	}
+	delete(active, importID)
+	return false
+}
+
+// protection is what eviction must spare under ev; nil with "oldest".
+type protection struct {
+	inUse, pinned map[string]bool
+}
+
+func (ev Eviction) protection() *protection {
+	if !ev.protect {
+		return nil
+	}
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	now := time.Now()
+	p := &protection{inUse: map[string]bool{}, pinned: ev.pinned}
+	for id := range active {
+		if inUseLocked(id, now) {
+			p.inUse[id] = true
+		}
+	}
+	return p
+}
+
+// Protected reports whether the data of importID should be kept by eviction under ev.
+func (ev Eviction) Protected(importID string) bool {
+	if !ev.protect {
+		return false
+	}
+	if ev.pinned[importID] {
+		return true
+	}
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	return inUseLocked(importID, time.Now())
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 )
 
@@ -13,9 +14,12 @@ type fileInfo struct {
 	mt   time.Time
 }
 
-// EnforceSizeLimit removes oldest files under dir until total <= maxBytes.
-// Best-effort; ignores errors.
-func EnforceSizeLimit(dir string, maxBytes int64) {
+// EnforceSizeLimit removes oldest files under dir (recursively) until total <= maxBytes.
+// Best-effort; ignores errors. The first path element under dir is the import id
+// (rawseg/<importID>/..., raw/<importID>/...): with the "protect" policy the files of
+// imports being streamed are only evicted when nothing else is left, and pinned imports
+// never are (they may keep the cache above maxBytes).
+func EnforceSizeLimit(dir string, maxBytes int64, ev Eviction) {
 	if maxBytes <= 0 {
 		return
 	}
@@ -40,11 +44,44 @@ func EnforceSizeLimit(dir string, maxBytes int64) {
 		return
 	}
 	sort.Slice(files, func(i, j int) bool { return files[i].mt.Before(files[j].mt) })
+	prot := ev.protection()
+	if prot == nil {
+		evict(dir, files, &total, maxBytes, nil)
+		return
+	}
+	root := filepath.Clean(dir)
+	importOf := func(p string) string {
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return ""
+		}
+		id, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+		return id
+	}
+	files = evict(dir, files, &total, maxBytes, func(f fileInfo) bool {
+		id := importOf(f.path)
+		return prot.inUse[id] || prot.pinned[id]
+	})
+	evict(dir, files, &total, maxBytes, func(f fileInfo) bool {
+		return prot.pinned[importOf(f.path)]
+	})
+}
+
+// evict removes files (oldest first) not kept by keep until total <= maxBytes, and
+// returns the ones left.
+func evict(dir string, files []fileInfo, total *int64, maxBytes int64, keep func(fileInfo) bool) []fileInfo {
+	left := files[:0]
 	for _, f := range files {
-		if total <= maxBytes {
-			break
+		if *total <= maxBytes || (keep != nil && keep(f)) {
+			left = append(left, f)
+			continue
 		}
 		_ = os.Remove(f.path)
-		total -= f.size
+		*total -= f.size
+		// Drop the now-empty shard directory; fails harmlessly if it still has files.
+		if d := filepath.Dir(f.path); d != filepath.Clean(dir) {
+			_ = os.Remove(d)
+		}
 	}
+	return left
 }
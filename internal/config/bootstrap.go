@@ -38,6 +38,8 @@ func EnsureConfigFile(path string) error {
 	cfg.Plex.Enabled = false
 	// Health can stay enabled but scanning is off by default.
 	cfg.Health.Scan.Enabled = false
+	// Nothing works until providers are configured; say so (GET /api/v1/setup/status).
+	cfg.SetupRequired = true
 
 	b, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
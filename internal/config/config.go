@@ -3,7 +3,12 @@ package config
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -16,15 +21,114 @@ type Paths struct {
 
 	// CacheMaxBytes is a best-effort size limit for /cache contents.
 	CacheMaxBytes int64 `json:"cache_max_bytes"`
+
+	// StagingDir holds upload staging (nzb-staging, par-staging). Empty = CacheDir.
+	StagingDir string `json:"staging_dir"`
+	// StagingMaxAgeHours: abandoned staging artifacts older than this are swept.
+	StagingMaxAgeHours int `json:"staging_max_age_hours"`
+
+	// ServeCachedFiles serves play requests straight from a complete /cache/raw copy
+	// (native Range/multi-range) instead of going through the segment streamer.
+	ServeCachedFiles bool `json:"serve_cached_files"`
+
+	// CompressCache stores new /cache/rawseg segments zstd-compressed. Video barely
+	// shrinks; it pays off for other payloads at some CPU cost on every read.
+	CompressCache bool `json:"compress_cache"`
+
+	// CacheEviction is how cache_max_bytes is enforced: "oldest" (default) evicts the
+	// oldest files whatever they belong to; "protect" spares the imports being streamed
+	// (evicted only as a last resort) and CachePinnedImports (never evicted, they may
+	// keep the cache above the limit).
+	CacheEviction      string   `json:"cache_eviction"`
+	CachePinnedImports []string `json:"cache_pinned_imports"`
+}
+
+// StagingRoot returns the directory used for upload staging artifacts.
+func (p Paths) StagingRoot() string {
+	if d := strings.TrimSpace(p.StagingDir); d != "" {
+		return d
+	}
+	if d := strings.TrimSpace(p.CacheDir); d != "" {
+		return d
+	}
+	return "/cache"
 }
 
 type Server struct {
 	Addr string `json:"addr"`
+
+	// Auth protects the API and UI (everything except /live).
+	Auth ServerAuth `json:"auth"`
+
+	// PlayHistoryDays keeps /api/v1/play/history rows this many days (default 90, -1 = forever).
+	PlayHistoryDays int `json:"play_history_days"`
+
+	// StreamTimeoutSeconds aborts a /api/v1/raw or /api/v1/play stream that makes no
+	// progress (no segment fetched or served) for this long. It is an idle timeout, not
+	// a cap: a long cold read keeps going while segments arrive. Default 90; 0 = no
+	// timeout, only the client connection bounds the request.
+	StreamTimeoutSeconds int `json:"stream_timeout_seconds"`
+
+	// MaxStreamsPerClient caps concurrent /api/v1/raw and /api/v1/play streams of one
+	// client; more get 429 so a single player cannot drain the NNTP pool. 0 = no limit.
+	MaxStreamsPerClient int `json:"max_streams_per_client"`
+	// StreamClientID is how clients are told apart: "ip" (default, peer address),
+	// "forwarded" (last X-Forwarded-For hop, added by the reverse proxy) or "header"
+	// (the StreamClientHeader value, default X-Client-Id).
+	StreamClientID     string `json:"stream_client_id"`
+	StreamClientHeader string `json:"stream_client_header"`
+}
+
+// DefaultStreamClientHeader identifies stream clients with server.stream_client_id=header.
+const DefaultStreamClientHeader = "X-Client-Id"
+
+type ServerAuth struct {
+	Mode string `json:"mode"` // "none" (default) | "basic" | "token"
+
+	// basic
+	User string `json:"user"`
+	Pass string `json:"pass"`
+
+	// token: "Authorization: Bearer <token>" or ?token=<token> (for player URLs)
+	Token string `json:"token"`
 }
 
 type Runner struct {
 	Enabled bool   `json:"enabled"`
 	Mode    string `json:"mode"` // "stub" or "exec" (dev)
+
+	// ImportConcurrency bounds parallel NZB imports (DB transactions + TMDB enrichment). Default 2.
+	ImportConcurrency int `json:"import_concurrency"`
+
+	// MissingTools is what happens when a binary needed by an enabled feature (ngpost,
+	// nyuu, par2, filebot) is missing: "warn" (default) logs it at startup, "fail"
+	// rejects the config. See GET /api/v1/system/tools.
+	MissingTools string `json:"missing_tools"`
+
+	// Roles are the job kinds this instance runs: "import", "upload", "health" (unset =
+	// all; an empty list is rejected). Without "upload" the runner never picks up upload
+	// jobs and the media watcher is off, for setups that only stream NZBs they already have.
+	Roles []string `json:"roles"`
+}
+
+// Runner roles (runner.roles).
+const (
+	RoleImport = "import"
+	RoleUpload = "upload"
+	RoleHealth = "health"
+)
+
+// HasRole reports whether role is enabled in runner.roles.
+func (r Runner) HasRole(role string) bool {
+	if r.Roles == nil {
+		return true
+	}
+	for _, v := range r.Roles {
+		if strings.EqualFold(strings.TrimSpace(v), role) {
+			return true
+		}
+	}
+	return false
 }
 
 type UploadPar struct {
@@ -32,11 +136,89 @@ type UploadPar struct {
 	RedundancyPercent int    `json:"redundancy_percent"` // e.g. 20
 	KeepParityFiles   bool   `json:"keep_parity_files"`
 	Dir               string `json:"dir"` // where to store parity files if KeepParityFiles=true (e.g. /host/inbox/par2)
+
+	// MaxConcurrent caps "par2 c" runs across upload and health jobs (default 1); parity
+	// generation is CPU-bound and parallel runs starve streaming.
+	MaxConcurrent int `json:"max_concurrent"`
+	// Nice (1-19) and IONice (idle I/O class) lower par2's priority through the nice and
+	// ionice tools when installed. 0/false = normal priority.
+	Nice   int  `json:"nice"`
+	IONice bool `json:"ionice"`
+
+	// UploadParity also posts the parity files with the release, listed in its NZB, so
+	// anyone with only the NZB can repair it. Health repairs fall back to them when no
+	// local set exists. Default false: parity stays local (KeepParityFiles).
+	UploadParity bool `json:"upload_parity"`
 }
 
 type Upload struct {
 	Provider string    `json:"provider"` // "ngpost" | "nyuu"
 	Par      UploadPar `json:"par"`
+
+	// Layout of the NZB written after an upload (under ngpost.output_dir):
+	//   "organized" (default): MOVIES/<quality>/<initial>/Title (Year).nzb, SERIES/<initial>/Show (Year)/...
+	//   "flat": <output_dir>/<name>.nzb, for external tooling that expects a single folder.
+	// Flat output loses the quality/initial split, so the manual tree gets one folder per NZB at its
+	// root, and the same title in two qualities maps to the same file (the second upload is skipped).
+	Layout string `json:"layout"`
+
+	// ImportImmediately enqueues the import of the finished NZB right away instead of
+	// waiting for the NZB watcher to pick it up on its next scan.
+	ImportImmediately bool `json:"import_immediately"`
+
+	// Groups overrides ngpost.groups per category. Empty = use ngpost.groups.
+	Groups UploadGroups `json:"groups"`
+
+	// MoviesOutputDir / SeriesOutputDir write uploaded NZBs of that category under their
+	// own root (e.g. separate disks). Empty = ngpost.output_dir. The NZB watcher, health
+	// scan and the library-manual raw view cover every root.
+	MoviesOutputDir string `json:"movies_output_dir"`
+	SeriesOutputDir string `json:"series_output_dir"`
+
+	// Nyuu article subject (--subject) and NZB file subject (--nzb-subject) templates,
+	// passed through to nyuu as-is. Empty = the defaults below.
+	// NyuuNZBSubject must keep {filename}: imports recover the file name from the NZB
+	// subject (subject.FilenameFromSubject looks for a "quoted" name, or the
+	// subject.filename_regexes patterns), so dropping or unquoting it breaks re-importing
+	// what was uploaded.
+	NyuuSubject    string `json:"nyuu_subject"`
+	NyuuNZBSubject string `json:"nyuu_nzb_subject"`
+
+	// MaxFileBytes fails an upload before posting when any source file is larger
+	// (some providers reject oversized posts). 0 = no limit. There is no automatic
+	// splitting: split the file and upload the parts.
+	MaxFileBytes int64 `json:"max_file_bytes"`
+
+	// MaxRetries re-queues an upload that failed with a transient error (timeouts,
+	// dropped connections, provider busy) up to this many times before marking it
+	// failed. 0 = no retries. Permanent errors (bad credentials, missing files) fail
+	// right away. RetryDelaySeconds is the wait before the first retry; it doubles
+	// on every further attempt (default 60).
+	MaxRetries        int `json:"max_retries"`
+	RetryDelaySeconds int `json:"retry_delay_seconds"`
+}
+
+const (
+	DefaultNyuuSubject    = "${rand(40)} yEnc ({part}/{parts})"
+	DefaultNyuuNZBSubject = `"{filename}" yEnc ({part}/{parts})`
+)
+
+type UploadGroups struct {
+	Movies string `json:"movies"` // e.g. alt.binaries.movies
+	Series string `json:"series"` // e.g. alt.binaries.tv
+}
+
+// GroupsFor returns the comma-separated groups to post a release to, falling back
+// to the global list when no category-specific groups are configured.
+func (u Upload) GroupsFor(isSeries bool, fallback string) string {
+	g := u.Groups.Movies
+	if isSeries {
+		g = u.Groups.Series
+	}
+	if g = strings.TrimSpace(g); g != "" {
+		return g
+	}
+	return fallback
 }
 
 type FileBot struct {
@@ -59,11 +241,37 @@ type WatchKind struct {
 	Enabled   bool   `json:"enabled"`
 	Dir       string `json:"dir"`
 	Recursive bool   `json:"recursive"`
+
+	// StableSeconds is how long a file must stay unchanged before it is enqueued.
+	// Media defaults to 60; NZB defaults to 0 (enqueue immediately).
+	StableSeconds int `json:"stable_seconds"`
+	// FolderStableSeconds applies to media season folders uploaded as one pack (default 60).
+	FolderStableSeconds int `json:"folder_stable_seconds"`
+
+	// NZB only. IgnorePatterns are globs matched against the lower-cased file name
+	// (temp files of non-atomic writers); unset = DefaultNZBIgnorePatterns, [] = none.
+	// RequireComplete skips NZBs whose XML isn't closed yet (no trailing </nzb>) until
+	// a later scan (default true).
+	IgnorePatterns  []string `json:"ignore_patterns"`
+	RequireComplete bool     `json:"require_complete"`
+
+	// Media only. DeleteAfterUpload removes an uploaded file (or season folder) from the
+	// inbox once its NZB has been written; with DoneDir set it is moved there instead,
+	// keeping its path below dir. Default false: the inbox is left alone.
+	DeleteAfterUpload bool   `json:"delete_after_upload"`
+	DoneDir           string `json:"done_dir"`
 }
 
+// DefaultNZBIgnorePatterns skips hidden/partial files written next to the final .nzb.
+var DefaultNZBIgnorePatterns = []string{".*", "~*", "*.tmp.nzb", "*.part.nzb", "*.partial.nzb"}
+
 type Watch struct {
 	NZB   WatchKind `json:"nzb"`
 	Media WatchKind `json:"media"`
+
+	// DeleteCooldownHours keeps the watcher from re-enqueueing an NZB (same path or
+	// same file name) for this long after it was deleted via delete_full. 0 disables.
+	DeleteCooldownHours int `json:"delete_cooldown_hours"`
 }
 
 type Backups struct {
@@ -73,6 +281,13 @@ type Backups struct {
 	Keep        int    `json:"keep"`         // rotation count
 	CompressGZ  bool   `json:"compress_gz"`  // store .gz
 	AutoRestore bool   `json:"auto_restore"` // reserved
+
+	// A failed scheduled backup is retried after RetryMins (default 5) instead of
+	// waiting a full EveryMins. Each failure POSTs to WebhookURL (if set); once
+	// AlertAfterFailures (default 3) happen in a row the alert is escalated to critical.
+	RetryMins          int    `json:"retry_mins"`
+	AlertAfterFailures int    `json:"alert_after_failures"`
+	WebhookURL         string `json:"webhook_url"`
 }
 
 type Config struct {
@@ -83,19 +298,32 @@ type Config struct {
 	NgPost   NgPost           `json:"ngpost"`
 	Download DownloadProvider `json:"download"`
 
-	Library  Library      `json:"library"`
-	Metadata Metadata     `json:"metadata"`
-	Plex     Plex         `json:"plex"`
-	Upload   Upload       `json:"upload"`
-	Rename   Rename       `json:"rename"`
-	Watch    Watch        `json:"watch"`
-	Backups  Backups      `json:"backups"`
-	Health   HealthConfig `json:"health"`
+	Library   Library      `json:"library"`
+	Metadata  Metadata     `json:"metadata"`
+	Plex      Plex         `json:"plex"`
+	Upload    Upload       `json:"upload"`
+	Rename    Rename       `json:"rename"`
+	Watch     Watch        `json:"watch"`
+	Backups   Backups      `json:"backups"`
+	Health    HealthConfig `json:"health"`
+	Subject   Subject      `json:"subject"`
+	Transcode Transcode    `json:"transcode"`
+	Import    Import       `json:"import"`
+	Trash     Trash        `json:"trash"`
+	HostFS    HostFS       `json:"hostfs"`
+
+	Thumbnails Thumbnails `json:"thumbnails"`
+	Hooks      Hooks      `json:"hooks"`
+
+	// SetupRequired marks the config written on first run (see EnsureConfigFile) until
+	// a save leaves every required item configured (see WithSetupFrom); GET
+	// /api/v1/setup/status reports it. Values sent by clients are ignored.
+	SetupRequired bool `json:"setup_required,omitempty"`
 }
 
 func Default() Config {
 	return Config{
-		Server: Server{Addr: ":1516"},
+		Server: Server{Addr: ":1516", Auth: ServerAuth{Mode: "none"}, PlayHistoryDays: 90, StreamTimeoutSeconds: 90},
 		Paths: Paths{
 			HostRoot:      "/host",
 			MountPoint:    "/host/mount",
@@ -103,15 +331,19 @@ func Default() Config {
 			MediaInbox:    "/host/inbox/media",
 			CacheDir:      "/cache",
 			CacheMaxBytes: 50 * 1024 * 1024 * 1024,
+
+			StagingMaxAgeHours: 24,
+			ServeCachedFiles:   true,
+			CacheEviction:      "oldest",
 		},
-		Runner: Runner{Enabled: true, Mode: "exec"}, // default: real execution (not stub)
+		Runner: Runner{Enabled: true, Mode: "exec", ImportConcurrency: 2, MissingTools: "warn", Roles: []string{RoleImport, RoleUpload, RoleHealth}}, // default: real execution (not stub)
 
 		NgPost:   NgPost{Enabled: false, Port: 563, SSL: true, Connections: 20, Threads: 2, OutputDir: "/host/inbox/nzb", Obfuscate: true},
 		Download: DownloadProvider{Enabled: false, Port: 563, SSL: true, Connections: 20, PrefetchSegments: 50},
-		Library:  (Library{Enabled: true, UppercaseFolders: true}).withDefaults(),
+		Library:  (Library{Enabled: true, UppercaseFolders: true, PruneManualDirs: true, MergeSeriesByTMDB: true}).withDefaults(),
 		Metadata: (Metadata{}).withDefaults(),
 		Plex:     (Plex{}).withDefaults(),
-		Upload:   Upload{Provider: "ngpost", Par: UploadPar{Enabled: true, RedundancyPercent: 20, KeepParityFiles: true, Dir: "/host/inbox/par2"}},
+		Upload:   Upload{Provider: "ngpost", Layout: "organized", NyuuSubject: DefaultNyuuSubject, NyuuNZBSubject: DefaultNyuuNZBSubject, RetryDelaySeconds: 60, Par: UploadPar{Enabled: true, RedundancyPercent: 20, KeepParityFiles: true, Dir: "/host/inbox/par2", MaxConcurrent: 1}},
 		Rename: Rename{Provider: "filebot", FileBot: FileBot{
 			Enabled:      true,
 			Binary:       "/usr/local/bin/filebot",
@@ -123,13 +355,16 @@ func Default() Config {
 			Action:       "test",
 		}},
 		Watch: Watch{
-			NZB:   WatchKind{Enabled: true, Dir: "/host/inbox/nzb", Recursive: true},
-			Media: WatchKind{Enabled: true, Dir: "/host/inbox/media", Recursive: true},
+			NZB:                 WatchKind{Enabled: true, Dir: "/host/inbox/nzb", Recursive: true, IgnorePatterns: slices.Clone(DefaultNZBIgnorePatterns), RequireComplete: true},
+			Media:               WatchKind{Enabled: true, Dir: "/host/inbox/media", Recursive: true, StableSeconds: 60, FolderStableSeconds: 60},
+			DeleteCooldownHours: 24,
 		},
-		Backups: (Backups{Enabled: false, Dir: "/backups", EveryMins: 0, Keep: 30, CompressGZ: true}),
+		Backups: (Backups{Enabled: false, Dir: "/backups", EveryMins: 0, Keep: 30, CompressGZ: true, RetryMins: 5, AlertAfterFailures: 3}),
 		Health: HealthConfig{
-			Enabled:   true,
-			BackupDir: "/cache/health-bak",
+			Enabled:             true,
+			BackupDir:           "/cache/health-bak",
+			ReuploadAfterRepair: true,
+			VerifyAfterRepair:   true,
 			Scan: HealthScanConfig{
 				Enabled:            true,
 				IntervalHours:      24,
@@ -139,6 +374,12 @@ func Default() Config {
 			},
 			Lock: HealthLockConfig{LockTTLHours: 6},
 		},
+		Transcode:  Transcode{FFmpegPath: "ffmpeg"},
+		Thumbnails: Thumbnails{}.withDefaults(),
+		Hooks:      Hooks{}.withDefaults(),
+		Import:     Import{ContentDedupe: "allow", MaxFilesPerNZB: DefaultMaxFilesPerNZB, MaxSegmentsPerFile: DefaultMaxSegmentsPerFile},
+		Trash:      Trash{RetentionDays: 30},
+		HostFS:     HostFS{HiddenPatterns: slices.Clone(DefaultHostFSHiddenPatterns)},
 	}
 }
 
@@ -174,15 +415,59 @@ func Load(path string) (Config, error) {
 	cfg.Library.Enabled = true
 	cfg.Metadata = cfg.Metadata.withDefaults()
 	cfg.Plex = cfg.Plex.withDefaults()
+	cfg.Thumbnails = cfg.Thumbnails.withDefaults()
+	cfg.Hooks = cfg.Hooks.withDefaults()
 	if cfg.Runner.Mode == "" {
 		cfg.Runner.Mode = "exec"
 	}
 	if !runnerEnabledPresent {
 		cfg.Runner.Enabled = true
 	}
+	if cfg.Runner.ImportConcurrency <= 0 {
+		cfg.Runner.ImportConcurrency = 2
+	}
+	if cfg.Runner.MissingTools == "" {
+		cfg.Runner.MissingTools = "warn"
+	}
 	if cfg.Upload.Provider == "" {
 		cfg.Upload.Provider = "ngpost"
 	}
+	if cfg.Transcode.FFmpegPath == "" {
+		cfg.Transcode.FFmpegPath = "ffmpeg"
+	}
+	if cfg.Upload.Layout == "" {
+		cfg.Upload.Layout = "organized"
+	}
+	if strings.TrimSpace(cfg.Upload.NyuuSubject) == "" {
+		cfg.Upload.NyuuSubject = DefaultNyuuSubject
+	}
+	if strings.TrimSpace(cfg.Upload.NyuuNZBSubject) == "" {
+		cfg.Upload.NyuuNZBSubject = DefaultNyuuNZBSubject
+	}
+	if cfg.Upload.RetryDelaySeconds <= 0 {
+		cfg.Upload.RetryDelaySeconds = 60
+	}
+	if cfg.Server.Auth.Mode == "" {
+		cfg.Server.Auth.Mode = "none"
+	}
+	if cfg.Server.PlayHistoryDays == 0 {
+		cfg.Server.PlayHistoryDays = 90
+	}
+	if cfg.Trash.RetentionDays == 0 {
+		cfg.Trash.RetentionDays = 30
+	}
+	if cfg.Import.ContentDedupe == "" {
+		cfg.Import.ContentDedupe = "allow"
+	}
+	if cfg.Import.MaxFilesPerNZB <= 0 {
+		cfg.Import.MaxFilesPerNZB = DefaultMaxFilesPerNZB
+	}
+	if cfg.Import.MaxSegmentsPerFile <= 0 {
+		cfg.Import.MaxSegmentsPerFile = DefaultMaxSegmentsPerFile
+	}
+	if cfg.Paths.StagingMaxAgeHours <= 0 {
+		cfg.Paths.StagingMaxAgeHours = 24
+	}
 	// FileBot is mandatory for both rename phases.
 	cfg.Rename.Provider = "filebot"
 	cfg.Rename.FileBot.Enabled = true
@@ -206,7 +491,16 @@ func Load(path string) (Config, error) {
 	if cfg.Upload.Par.KeepParityFiles && cfg.Upload.Par.Dir == "" {
 		cfg.Upload.Par.Dir = "/host/inbox/par2"
 	}
+	if cfg.Upload.Par.MaxConcurrent <= 0 {
+		cfg.Upload.Par.MaxConcurrent = 1
+	}
 	// Health defaults
+	if h, ok := raw["health"].(map[string]any); !ok || h["reupload_after_repair"] == nil {
+		cfg.Health.ReuploadAfterRepair = true
+	}
+	if h, ok := raw["health"].(map[string]any); !ok || h["verify_after_repair"] == nil {
+		cfg.Health.VerifyAfterRepair = true
+	}
 	if strings.TrimSpace(cfg.Health.BackupDir) == "" {
 		cfg.Health.BackupDir = "/cache/health-bak"
 	}
@@ -234,6 +528,15 @@ func Load(path string) (Config, error) {
 	if cfg.Watch.Media.Dir == "" {
 		cfg.Watch.Media.Dir = cfg.Paths.MediaInbox
 	}
+	if cfg.Watch.Media.StableSeconds <= 0 {
+		cfg.Watch.Media.StableSeconds = 60
+	}
+	if cfg.Watch.Media.FolderStableSeconds <= 0 {
+		cfg.Watch.Media.FolderStableSeconds = 60
+	}
+	if l, ok := raw["library"].(map[string]any); !ok || l["merge_series_by_tmdb"] == nil {
+		cfg.Library.MergeSeriesByTMDB = true
+	}
 	// Backward compat: if watch.enabled fields are missing, keep previous behavior when runner.enabled=true.
 	// (Older configs had no watch section.)
 	// We detect presence via raw map keys.
@@ -243,12 +546,35 @@ func Load(path string) (Config, error) {
 		cfg.Watch.NZB.Recursive = true
 		cfg.Watch.Media.Recursive = true
 	}
+	if sv, ok := raw["server"].(map[string]any); !ok || sv["stream_timeout_seconds"] == nil {
+		cfg.Server.StreamTimeoutSeconds = 90
+	}
+	if wr, ok := raw["watch"].(map[string]any); !ok || wr["delete_cooldown_hours"] == nil {
+		cfg.Watch.DeleteCooldownHours = 24
+	}
+	if cfg.Watch.NZB.IgnorePatterns == nil {
+		cfg.Watch.NZB.IgnorePatterns = slices.Clone(DefaultNZBIgnorePatterns)
+	}
+	if cfg.HostFS.HiddenPatterns == nil {
+		cfg.HostFS.HiddenPatterns = slices.Clone(DefaultHostFSHiddenPatterns)
+	}
+	if wr, ok := raw["watch"].(map[string]any); !ok {
+		cfg.Watch.NZB.RequireComplete = true
+	} else if n, ok := wr["nzb"].(map[string]any); !ok || n["require_complete"] == nil {
+		cfg.Watch.NZB.RequireComplete = true
+	}
 	if cfg.Backups.Dir == "" {
 		cfg.Backups.Dir = "/backups"
 	}
 	if cfg.Backups.Keep <= 0 {
 		cfg.Backups.Keep = 30
 	}
+	if cfg.Backups.RetryMins <= 0 {
+		cfg.Backups.RetryMins = 5
+	}
+	if cfg.Backups.AlertAfterFailures <= 0 {
+		cfg.Backups.AlertAfterFailures = 3
+	}
 	return cfg, nil
 }
 
@@ -259,13 +585,102 @@ func (c Config) Validate() error {
 	if c.Paths.MountPoint == "" {
 		return errors.New("paths.mount_point required")
 	}
+	switch c.Paths.CacheEviction {
+	case "", "oldest", "protect":
+	default:
+		return errors.New("paths.cache_eviction must be oldest|protect")
+	}
+	for _, id := range c.Paths.CachePinnedImports {
+		if strings.TrimSpace(id) == "" {
+			return errors.New("paths.cache_pinned_imports must not contain empty ids")
+		}
+	}
+	switch c.Server.Auth.Mode {
+	case "", "none":
+	case "basic":
+		if strings.TrimSpace(c.Server.Auth.User) == "" || c.Server.Auth.Pass == "" {
+			return errors.New("server.auth.user and server.auth.pass required for mode basic")
+		}
+	case "token":
+		if len(strings.TrimSpace(c.Server.Auth.Token)) < 16 {
+			return errors.New("server.auth.token must be at least 16 characters for mode token")
+		}
+	default:
+		return errors.New("server.auth.mode must be none|basic|token")
+	}
+	if c.Server.StreamTimeoutSeconds < 0 {
+		return errors.New("server.stream_timeout_seconds must be >= 0")
+	}
+	if c.Server.MaxStreamsPerClient < 0 {
+		return errors.New("server.max_streams_per_client must be >= 0")
+	}
+	switch strings.ToLower(strings.TrimSpace(c.Server.StreamClientID)) {
+	case "", "ip", "forwarded", "header":
+		// ok
+	default:
+		return errors.New("server.stream_client_id must be ip|forwarded|header")
+	}
 	// Runner
+	if c.Runner.Roles != nil && len(c.Runner.Roles) == 0 {
+		return errors.New("runner.roles needs at least one role (import, upload, health)")
+	}
+	for _, role := range c.Runner.Roles {
+		switch strings.ToLower(strings.TrimSpace(role)) {
+		case RoleImport, RoleUpload, RoleHealth:
+		default:
+			return fmt.Errorf("runner.roles: unknown role %q (import|upload|health)", role)
+		}
+	}
 	switch c.Runner.Mode {
 	case "", "stub", "exec":
 		// ok
 	default:
 		return errors.New("runner.mode must be stub|exec")
 	}
+	if c.Runner.ImportConcurrency < 0 || c.Runner.ImportConcurrency > 32 {
+		return errors.New("runner.import_concurrency must be 0..32")
+	}
+	switch c.Runner.MissingTools {
+	case "", "warn", "fail":
+	default:
+		return errors.New("runner.missing_tools must be warn|fail")
+	}
+	if c.Watch.NZB.StableSeconds < 0 || c.Watch.Media.StableSeconds < 0 || c.Watch.Media.FolderStableSeconds < 0 {
+		return errors.New("watch stable_seconds must be >= 0")
+	}
+	if c.Watch.DeleteCooldownHours < 0 {
+		return errors.New("watch.delete_cooldown_hours must be >= 0")
+	}
+	if done := strings.TrimSpace(c.Watch.Media.DoneDir); done != "" && c.Watch.Media.Dir != "" {
+		// Inside the inbox the watcher would upload everything again.
+		if rel, err := filepath.Rel(filepath.Clean(c.Watch.Media.Dir), filepath.Clean(done)); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return errors.New("watch.media.done_dir must be outside watch.media.dir")
+		}
+	}
+	for _, pat := range c.Watch.NZB.IgnorePatterns {
+		if _, err := filepath.Match(pat, ""); err != nil {
+			return errors.New("watch.nzb.ignore_patterns: invalid pattern " + pat)
+		}
+	}
+	for _, pat := range c.HostFS.HiddenPatterns {
+		if _, err := filepath.Match(pat, ""); err != nil {
+			return errors.New("hostfs.hidden_patterns: invalid pattern " + pat)
+		}
+	}
+	switch c.Import.ContentDedupe {
+	case "", "allow", "skip", "replace":
+	default:
+		return errors.New("import.content_dedupe must be allow|skip|replace")
+	}
+	if c.Import.StreamBatchSegments < 0 {
+		return errors.New("import.stream_batch_segments must be >= 0")
+	}
+	switch c.Upload.Layout {
+	case "", "organized", "flat":
+		// ok
+	default:
+		return errors.New("upload.layout must be organized|flat")
+	}
 	// Upload provider
 	switch c.Upload.Provider {
 	case "", "ngpost", "nyuu":
@@ -273,6 +688,67 @@ func (c Config) Validate() error {
 	default:
 		return errors.New("upload.provider must be ngpost|nyuu")
 	}
+	for name, d := range map[string]string{"upload.movies_output_dir": c.Upload.MoviesOutputDir, "upload.series_output_dir": c.Upload.SeriesOutputDir} {
+		if d = strings.TrimSpace(d); d != "" && !filepath.IsAbs(d) {
+			return errors.New(name + " must be an absolute path")
+		}
+	}
+	if c.Upload.MaxFileBytes < 0 {
+		return errors.New("upload.max_file_bytes must be >= 0")
+	}
+	if c.Upload.MaxRetries < 0 || c.Upload.MaxRetries > 10 {
+		return errors.New("upload.max_retries must be between 0 and 10")
+	}
+	if c.Upload.RetryDelaySeconds < 0 {
+		return errors.New("upload.retry_delay_seconds must be >= 0")
+	}
+	if c.Upload.Par.MaxConcurrent < 0 {
+		return errors.New("upload.par.max_concurrent must be >= 0")
+	}
+	if c.Upload.Par.Nice < 0 || c.Upload.Par.Nice > 19 {
+		return errors.New("upload.par.nice must be between 0 and 19")
+	}
+	if v := strings.TrimSpace(c.Upload.NyuuNZBSubject); v != "" && !strings.Contains(v, "{filename}") {
+		return errors.New("upload.nyuu_nzb_subject must contain {filename} (needed to re-import uploaded NZBs)")
+	}
+	// Both providers post with the ngpost section; catch missing fields here instead of
+	// at upload time.
+	if c.NgPost.Enabled {
+		name := "ngpost"
+		if c.Upload.Provider == "nyuu" {
+			name = "nyuu"
+		}
+		if strings.TrimSpace(c.NgPost.Host) == "" {
+			return fmt.Errorf("ngpost.host required when ngpost.enabled (upload provider %s)", name)
+		}
+		if c.NgPost.Port < 0 || c.NgPost.Port > 65535 {
+			return errors.New("ngpost.port must be 1..65535")
+		}
+		if strings.TrimSpace(c.NgPost.User) == "" || c.NgPost.Pass == "" {
+			return fmt.Errorf("ngpost.user and ngpost.pass required when ngpost.enabled (upload provider %s)", name)
+		}
+		if strings.TrimSpace(c.NgPost.Groups) == "" && (strings.TrimSpace(c.Upload.Groups.Movies) == "" || strings.TrimSpace(c.Upload.Groups.Series) == "") {
+			return errors.New("ngpost.groups required when ngpost.enabled (or set both upload.groups.movies and upload.groups.series)")
+		}
+	}
+	// Download provider: FUSE reads and /api/v1/play stream from it.
+	if c.Download.Enabled {
+		if strings.TrimSpace(c.Download.Host) == "" {
+			return errors.New("download.host required when download.enabled")
+		}
+		if c.Download.Port < 0 || c.Download.Port > 65535 {
+			return errors.New("download.port must be 1..65535")
+		}
+		if strings.TrimSpace(c.Download.User) != "" && c.Download.Pass == "" {
+			return errors.New("download.pass required when download.user is set")
+		}
+		if c.Download.Connections < 0 {
+			return errors.New("download.connections must be >= 0")
+		}
+		if err := c.Download.validateTLS("download"); err != nil {
+			return err
+		}
+	}
 	// Rename provider (mandatory: filebot)
 	if strings.TrimSpace(c.Rename.Provider) != "" && c.Rename.Provider != "filebot" {
 		return errors.New("rename.provider must be filebot")
@@ -290,11 +766,131 @@ func (c Config) Validate() error {
 			return errors.New("plex.plex_root required when plex.enabled")
 		}
 	}
+	switch c.Plex.RefreshMode {
+	case "", "path", "section", "both":
+	default:
+		return errors.New("plex.refresh_mode must be path|section|both")
+	}
+	if c.Plex.RefreshDebounceSeconds < 0 {
+		return errors.New("plex.refresh_debounce_seconds must be >= 0")
+	}
+
+	// Metadata
+	switch strings.ToLower(strings.TrimSpace(c.Metadata.TMDB.OriginalTitle)) {
+	case "", OriginalTitleFallback, OriginalTitlePrefer, OriginalTitleNever:
+	default:
+		return errors.New("metadata.tmdb.original_title must be fallback|prefer|never")
+	}
+	if c.Metadata.EnrichConcurrency < 0 || c.Metadata.EnrichConcurrency > 32 {
+		return errors.New("metadata.enrich_concurrency must be between 0 and 32")
+	}
+
+	// Thumbnails
+	if c.Thumbnails.SeekSeconds < 0 || c.Thumbnails.MaxHeadMB < 0 || c.Thumbnails.Width < 0 {
+		return errors.New("thumbnails.seek_seconds, max_head_mb and width must be >= 0")
+	}
+
+	// Hooks
+	if c.Hooks.TimeoutSeconds < 0 {
+		return errors.New("hooks.timeout_seconds must be >= 0")
+	}
 
 	// Health
 	if strings.TrimSpace(c.Health.BackupDir) == "" {
 		return errors.New("health.backup_dir required")
 	}
+	if c.Health.Scan.MinAgeHours < 0 {
+		return errors.New("health.scan.min_age_hours must be >= 0")
+	}
+	if c.Health.Scan.RepairedCooldownHours < 0 {
+		return errors.New("health.scan.repaired_cooldown_hours must be >= 0")
+	}
+	for i, p := range c.Health.RepairProviders {
+		if !p.Enabled {
+			continue
+		}
+		if strings.TrimSpace(p.Host) == "" {
+			return fmt.Errorf("health.repair_providers[%d].host required", i)
+		}
+		if p.Port < 0 || p.Port > 65535 {
+			return fmt.Errorf("health.repair_providers[%d].port must be 1..65535", i)
+		}
+		if err := p.validateTLS(fmt.Sprintf("health.repair_providers[%d]", i)); err != nil {
+			return err
+		}
+	}
+
+	// Library
+	bucketNames := map[string]bool{}
+	for i, b := range c.Library.QualityBuckets {
+		name := strings.TrimSpace(b.Name)
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			return fmt.Errorf("library.quality_buckets[%d].name must be a folder name", i)
+		}
+		if bucketNames[strings.ToLower(name)] {
+			return fmt.Errorf("library.quality_buckets: duplicate name %q", name)
+		}
+		bucketNames[strings.ToLower(name)] = true
+		if len(b.Match) == 0 {
+			return fmt.Errorf("library.quality_buckets[%d].match required", i)
+		}
+		for _, m := range b.Match {
+			for _, tok := range strings.Split(m, "+") {
+				if strings.TrimSpace(tok) == "" {
+					return fmt.Errorf("library.quality_buckets[%d].match: empty token in %q", i, m)
+				}
+			}
+		}
+	}
+	if len(c.Library.QualityBuckets) > 0 {
+		if q := strings.TrimSpace(c.Library.DefaultQuality); q != "" && !bucketNames[strings.ToLower(q)] {
+			return errors.New("library.default_quality must name one of library.quality_buckets")
+		}
+	} else {
+		switch strings.ToUpper(strings.TrimSpace(c.Library.DefaultQuality)) {
+		case "", "4K", "2160", "1080", "720", "SD", "576", "480":
+			// ok
+		default:
+			return errors.New("library.default_quality must be 4K|2160|1080|720|576|480|SD")
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(c.Library.BucketScheme)) {
+	case "", "alpha", "none", "decade":
+		// ok
+	default:
+		return errors.New("library.bucket_scheme must be alpha|none|decade")
+	}
+	switch strings.ToLower(strings.TrimSpace(c.Library.TitleSanitize)) {
+	case "", "safe", "minimal":
+		// ok
+	default:
+		return errors.New("library.title_sanitize must be safe|minimal")
+	}
+	for i, tag := range c.Library.VersionTags {
+		if strings.TrimSpace(tag) == "" || strings.ContainsAny(tag, `/\[]`) {
+			return fmt.Errorf("library.version_tags[%d] must be a non-empty word without / \\ [ ]", i)
+		}
+	}
+	if c.Library.ListingLimit < 0 {
+		return errors.New("library.listing_limit must be >= 0")
+	}
+	for i, seg := range c.Library.UppercaseSegments {
+		seg = strings.ToLower(strings.TrimSpace(seg))
+		switch seg {
+		case "movies_root", "series_root", "series_status", "emision_folder", "finalizadas_folder":
+			continue
+		}
+		if n, err := strconv.Atoi(seg); err != nil || n < 1 {
+			return fmt.Errorf("library.uppercase_segments[%d] must be a root variable or a folder depth >= 1", i)
+		}
+	}
+
+	// Subject extraction rules
+	for i, p := range c.Subject.Patterns {
+		if _, err := regexp.Compile(p); err != nil {
+			return fmt.Errorf("subject.patterns[%d] invalid: %v", i, err)
+		}
+	}
 
 	// Backups
 	if c.Backups.Dir == "" {
@@ -306,5 +902,8 @@ func (c Config) Validate() error {
 	if c.Backups.EveryMins < 0 {
 		return errors.New("backups.every_mins must be >= 0")
 	}
+	if u := strings.TrimSpace(c.Backups.WebhookURL); u != "" && !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+		return errors.New("backups.webhook_url must be an http(s) URL")
+	}
 	return nil
 }
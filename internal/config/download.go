@@ -1,5 +1,15 @@
 package config
 
+import (
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gaby/EDRmount/internal/nntp"
+)
+
 type DownloadProvider struct {
 	Enabled bool `json:"enabled"`
 
@@ -11,4 +21,193 @@ type DownloadProvider struct {
 
 	Connections      int `json:"connections"`
 	PrefetchSegments int `json:"prefetch_segments"`
+
+	// TolerateMissingTail makes streaming treat a segment that is missing on the server as EOF
+	// (the player stops) instead of an I/O error. Transient errors are still retried.
+	TolerateMissingTail bool `json:"tolerate_missing_tail"`
+
+	// ValidateCRC checks each decoded segment against its yEnc (p)crc32 trailer and treats
+	// a mismatch like a missing segment, so corrupted articles are never cached or served.
+	ValidateCRC bool `json:"validate_crc"`
+
+	// TLSPinSHA256 pins the provider certificate (with ssl): the SHA-256 fingerprint of
+	// its leaf certificate in hex, as printed by "openssl x509 -noout -fingerprint
+	// -sha256" (colons optional). A pinned certificate is trusted without CA verification
+	// (self-signed works) and any other one is refused; list two during a rotation.
+	// Empty = default CA and hostname verification.
+	TLSPinSHA256 []string `json:"tls_pin_sha256"`
+	// TLSMinVersion is the oldest TLS version accepted: "1.2" or "1.3". Empty = Go's
+	// default (1.2).
+	TLSMinVersion string `json:"tls_min_version"`
+	// TLSCipherSuites restricts the TLS 1.2 cipher suites, by Go name (e.g.
+	// "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"). TLS 1.3 suites are not configurable.
+	// Empty = Go's default list.
+	TLSCipherSuites []string `json:"tls_cipher_suites"`
+
+	// DialTimeoutSeconds bounds connecting to the provider (default 10).
+	DialTimeoutSeconds int `json:"dial_timeout_seconds"`
+	// IOTimeoutSeconds is the per-command deadline, e.g. a full BODY fetch (default 60).
+	IOTimeoutSeconds int `json:"io_timeout_seconds"`
+
+	// BreakerFailures: after this many consecutive connect failures streaming fails fast
+	// for BreakerCooldownSeconds instead of every read timing out (default 5, -1 = off).
+	BreakerFailures        int `json:"breaker_failures"`
+	BreakerCooldownSeconds int `json:"breaker_cooldown_seconds"`
+
+	// WarmOnStart pre-fetches the first WarmMiB of the WarmCount most recently played
+	// files into the segment cache after startup (defaults 10 files, 8 MiB).
+	WarmOnStart bool `json:"warm_on_start"`
+	WarmCount   int  `json:"warm_count"`
+	WarmMiB     int  `json:"warm_mib"`
+}
+
+// DialTimeout returns the configured dial timeout (default 10s).
+func (d DownloadProvider) DialTimeout() time.Duration {
+	if d.DialTimeoutSeconds <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(d.DialTimeoutSeconds) * time.Second
+}
+
+// IOTimeout returns the configured per-command timeout (default 60s).
+func (d DownloadProvider) IOTimeout() time.Duration {
+	if d.IOTimeoutSeconds <= 0 {
+		return 60 * time.Second
+	}
+	return time.Duration(d.IOTimeoutSeconds) * time.Second
+}
+
+// BreakerThreshold returns the consecutive dial failures that open the breaker (0 = off).
+func (d DownloadProvider) BreakerThreshold() int {
+	switch {
+	case d.BreakerFailures < 0:
+		return 0
+	case d.BreakerFailures == 0:
+		return 5
+	}
+	return d.BreakerFailures
+}
+
+// BreakerCooldown returns how long an open breaker fails fast (default 30s).
+func (d DownloadProvider) BreakerCooldown() time.Duration {
+	if d.BreakerCooldownSeconds <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(d.BreakerCooldownSeconds) * time.Second
+}
+
+// WarmCountOrDefault returns how many recent files the startup warmer fetches (default 10).
+func (d DownloadProvider) WarmCountOrDefault() int {
+	if d.WarmCount <= 0 {
+		return 10
+	}
+	return d.WarmCount
+}
+
+// WarmBytes returns how much of each file the startup warmer fetches (default 8 MiB).
+func (d DownloadProvider) WarmBytes() int64 {
+	if d.WarmMiB <= 0 {
+		return 8 << 20
+	}
+	return int64(d.WarmMiB) << 20
+}
+
+// TLSPins returns tls_pin_sha256 normalized to lowercase hex without separators; pins
+// that are not a SHA-256 are dropped (Validate reports them).
+func (d DownloadProvider) TLSPins() []string {
+	out := make([]string, 0, len(d.TLSPinSHA256))
+	for _, p := range d.TLSPinSHA256 {
+		if pin, err := normalizeTLSPin(p); err == nil {
+			out = append(out, pin)
+		}
+	}
+	return out
+}
+
+// TLSVersion returns tls_min_version as a crypto/tls version (0 = default).
+func (d DownloadProvider) TLSVersion() uint16 {
+	v, _ := parseTLSVersion(d.TLSMinVersion)
+	return v
+}
+
+// TLSCiphers returns tls_cipher_suites as crypto/tls ids (nil = default); unknown names
+// are dropped (Validate reports them).
+func (d DownloadProvider) TLSCiphers() []uint16 {
+	var out []uint16
+	for _, name := range d.TLSCipherSuites {
+		if id, err := parseTLSCipher(name); err == nil {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// NNTPConfig returns the client settings of the provider: credentials, timeouts, the
+// circuit breaker, TLS policy and the shared download.connections limiter. Every NNTP
+// consumer dials through it so none of them drops a setting.
+func (d DownloadProvider) NNTPConfig() nntp.Config {
+	return nntp.Config{
+		Host:            d.Host,
+		Port:            d.Port,
+		SSL:             d.SSL,
+		User:            d.User,
+		Pass:            d.Pass,
+		DialTimeout:     d.DialTimeout(),
+		IOTimeout:       d.IOTimeout(),
+		BreakerFailures: d.BreakerThreshold(),
+		BreakerCooldown: d.BreakerCooldown(),
+		Limiter:         nntp.GlobalLimiter(),
+		TLSPinSHA256:    d.TLSPins(),
+		TLSMinVersion:   d.TLSVersion(),
+		TLSCipherSuites: d.TLSCiphers(),
+	}
+}
+
+// validateTLS checks the TLS settings of a provider; prefix names it in errors.
+func (d DownloadProvider) validateTLS(prefix string) error {
+	for _, p := range d.TLSPinSHA256 {
+		if _, err := normalizeTLSPin(p); err != nil {
+			return fmt.Errorf("%s.tls_pin_sha256: %v", prefix, err)
+		}
+	}
+	if _, err := parseTLSVersion(d.TLSMinVersion); err != nil {
+		return fmt.Errorf("%s.tls_min_version: %v", prefix, err)
+	}
+	for _, name := range d.TLSCipherSuites {
+		if _, err := parseTLSCipher(name); err != nil {
+			return fmt.Errorf("%s.tls_cipher_suites: %v", prefix, err)
+		}
+	}
+	return nil
+}
+
+func normalizeTLSPin(p string) (string, error) {
+	pin := strings.ToLower(strings.NewReplacer(":", "", " ", "").Replace(strings.TrimSpace(p)))
+	if b, err := hex.DecodeString(pin); err != nil || len(b) != 32 {
+		return "", fmt.Errorf("%q is not a hex SHA-256 fingerprint", p)
+	}
+	return pin, nil
+}
+
+func parseTLSVersion(v string) (uint16, error) {
+	switch strings.TrimSpace(v) {
+	case "":
+		return 0, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("must be 1.2 or 1.3, got %q", v)
+}
+
+// parseTLSCipher accepts the secure suites of crypto/tls only.
+func parseTLSCipher(name string) (uint16, error) {
+	name = strings.TrimSpace(name)
+	for _, cs := range tls.CipherSuites() {
+		if cs.Name == name {
+			return cs.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown or insecure cipher suite %q", name)
 }
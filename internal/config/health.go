@@ -15,6 +15,15 @@ type HealthScanConfig struct {
 
 	// AutoRepair enqueues a health_repair_nzb job for each BROKEN NZB found.
 	AutoRepair bool `json:"auto_repair"`
+
+	// MinAgeHours is a grace period for fresh uploads: an NZB with missing articles that
+	// is newer than this (NZB mtime or import time, whichever is later) is marked
+	// "pending-propagation" instead of broken and never auto-repaired. 0 = off.
+	MinAgeHours int `json:"min_age_hours"`
+
+	// RepairedCooldownHours skips NZBs repaired less than this long ago (last_repaired_at)
+	// in scan runs: the repair just replaced them with a fresh upload. 0 = off.
+	RepairedCooldownHours int `json:"repaired_cooldown_hours"`
 }
 
 type HealthLockConfig struct {
@@ -30,6 +39,21 @@ type HealthConfig struct {
 	// If empty, defaults to "/cache/health-bak".
 	BackupDir string `json:"backup_dir"`
 
+	// ReuploadAfterRepair re-posts the repaired media directly (clean NZB + PAR2 regen) and
+	// replaces the original NZB. When false, the repaired media is handed to the media inbox
+	// and the media watcher re-uploads it. Default: true.
+	ReuploadAfterRepair bool `json:"reupload_after_repair"`
+
+	// VerifyAfterRepair runs "par2 v" on the repaired file and fails the job (workdir is
+	// kept for inspection) unless every block verifies. Default: true.
+	VerifyAfterRepair bool `json:"verify_after_repair"`
+
+	// RepairProviders are backup accounts (e.g. another backbone) tried in order when
+	// "par2 r" reports too few recovery blocks: the segments still missing are fetched
+	// from them and the repair runs again. Only host/port/ssl/user/pass/timeouts and
+	// the tls_* settings are used.
+	RepairProviders []DownloadProvider `json:"repair_providers"`
+
 	Scan HealthScanConfig `json:"scan"`
 	Lock HealthLockConfig `json:"lock"`
 }
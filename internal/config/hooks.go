@@ -0,0 +1,26 @@
+package config
+
+// Hooks are user scripts the runner calls at fixed points, for policy that does not
+// belong in the config (e.g. routing anime to its own tree).
+type Hooks struct {
+	// OnImport is an executable run after every NZB import. It gets the import as JSON
+	// on stdin (id, nzb path, and the guessed kind/title/year/season/episode of each
+	// file) and may print JSON overrides (category, kind, title, year, quality, tmdb_id,
+	// season, episode; for every file or per file idx), which are stored as library
+	// overrides. A series needs season and episode. Empty = off. A failing hook only
+	// logs a warning.
+	OnImport string `json:"on_import"`
+	// TimeoutSeconds bounds one hook run (default 30).
+	TimeoutSeconds int `json:"timeout_seconds"`
+}
+
+func (h Hooks) withDefaults() Hooks {
+	out := h
+	if out.TimeoutSeconds <= 0 {
+		out.TimeoutSeconds = 30
+	}
+	return out
+}
+
+// Defaults returns a copy of the hooks config with empty fields filled.
+func (h Hooks) Defaults() Hooks { return h.withDefaults() }
@@ -0,0 +1,13 @@
+package config
+
+// HostFS configures the host file browser (/api/v1/hostfs/*).
+type HostFS struct {
+	// HiddenPatterns are globs matched against the lower-cased entry name; matching
+	// files and folders are left out of the listing unless ?show_hidden=1.
+	// Unset = DefaultHostFSHiddenPatterns, [] = show everything.
+	HiddenPatterns []string `json:"hidden_patterns"`
+}
+
+// DefaultHostFSHiddenPatterns hides dotfiles (.trash included) and the temp/partial
+// files written by uploads, moves and the NZB watcher's non-atomic writers.
+var DefaultHostFSHiddenPatterns = []string{".*", "~*", "*.tmp", "*.part", "*.partial", "*.tmp.nzb", "*.part.nzb", "*.partial.nzb"}
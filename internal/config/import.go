@@ -0,0 +1,38 @@
+package config
+
+// Import controls NZB import behavior.
+type Import struct {
+	// ContentDedupe decides what happens when an NZB at a new path has the same set of
+	// message-ids as an existing import (the same post saved under another name):
+	//   "allow" (default): import it again (library-auto shows both),
+	//   "skip": keep the existing import and ignore the new NZB,
+	//   "replace": point the existing import at the new NZB path.
+	ContentDedupe string `json:"content_dedupe"`
+
+	// MaxFilesPerNZB / MaxSegmentsPerFile reject malformed or hostile NZBs before they
+	// are loaded into the DB. <= 0 uses the defaults, which never trip on real posts.
+	MaxFilesPerNZB     int `json:"max_files_per_nzb"`
+	MaxSegmentsPerFile int `json:"max_segments_per_file"`
+
+	// IncrementalCommit commits an NZB's files in batches while it is imported, so a
+	// large NZB fills the catalog progressively instead of appearing all at once. An
+	// import that fails halfway is removed again. Default false (one transaction).
+	IncrementalCommit bool `json:"incremental_commit"`
+
+	// StreamBatchSegments imports an NZB without loading it whole: it is decoded one file
+	// at a time (read twice: totals/content hash, then inserts) and committed about every
+	// this many segments, with the stored counts reconciled at the end. For low-memory
+	// hosts and giant NZBs. 0 = off (default).
+	StreamBatchSegments int `json:"stream_batch_segments"`
+
+	// PrecomputeDecodedSizes downloads every segment once after the import (a
+	// decoded_sizes_import job) to record its decoded size, so streams seek to exact
+	// offsets instead of estimating them from the NZB's encoded sizes. Costs a full
+	// download of each import. Default false.
+	PrecomputeDecodedSizes bool `json:"precompute_decoded_sizes"`
+}
+
+const (
+	DefaultMaxFilesPerNZB     = 20000
+	DefaultMaxSegmentsPerFile = 1000000
+)
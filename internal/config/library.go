@@ -11,6 +11,58 @@ type Library struct {
 	FinalizadasFolder string `json:"finalizadas_folder"` // e.g. FINALIZADAS
 
 	UppercaseFolders bool `json:"uppercase_folders"`
+	// UppercaseSegments limits uppercase_folders to some folders: root variables
+	// (movies_root, series_root, series_status, emision_folder, finalizadas_folder) or
+	// folder depths ("1" = top level). Empty = every folder (all-caps, the default), so
+	// ["1", "series_status"] gives PELICULAS/1080/A/Title (2020) instead of TITLE (2020).
+	UppercaseSegments []string `json:"uppercase_segments"`
+
+	// MountRaw also mounts the per-import raw tree at <mount_point>/raw.
+	MountRaw bool `json:"mount_raw"`
+	// MountCollections mounts one folder per collection at <mount_point>/collections.
+	MountCollections bool `json:"mount_collections"`
+
+	// GenerateNFO exposes movie.nfo / tvshow.nfo (title, year, tmdb id) next to
+	// TMDB-matched titles in library-auto. Built from the DB, no network access.
+	GenerateNFO bool `json:"generate_nfo"`
+	// GeneratePosters exposes poster.jpg once it has been fetched from TMDB into
+	// <cache_dir>/artwork (fetched in the background the first time a folder is listed).
+	GeneratePosters bool `json:"generate_posters"`
+
+	// PruneManualDirs removes library-manual folders that were auto-seeded from NZB
+	// paths once they hold no items and no subfolders (after an import delete and
+	// periodically). Folders created in the UI are never pruned.
+	PruneManualDirs bool `json:"prune_manual_dirs"`
+
+	// ManualLabelsToOverrides turns a label edited in library-manual into a
+	// library_override for that file (as POST /api/v1/manual/items/{id}/promote does), so
+	// library-auto shows the corrected title too. Default false.
+	ManualLabelsToOverrides bool `json:"manual_labels_to_overrides"`
+	// ManualItemsInAuto promotes a manual item added in the UI the same way, so a curated
+	// pick shows up in library-auto under the path its label names. Files that already
+	// have an override keep it. Default false.
+	ManualItemsInAuto bool `json:"manual_items_in_auto"`
+
+	// SplitVersions gives files tagged with one of VersionTags their own library-auto
+	// leaf, e.g. "Movie (2020) [LATINO].mkv" next to the original-language cut, instead
+	// of both colliding on one name. Tags only count after the year/SxxExx of the name.
+	SplitVersions bool `json:"split_versions"`
+	// VersionTags are the language/edition words recognized by SplitVersions, matched as
+	// whole words (case-insensitive). Empty = DefaultVersionTags.
+	VersionTags []string `json:"version_tags"`
+
+	// DefaultQuality is used when the filename has no quality token (4K, 1080, 720, SD).
+	DefaultQuality string `json:"default_quality"`
+
+	// TitleSanitize is how titles, show names and episode titles are cleaned before they
+	// are put into the templates: "safe" (default) also rewrites characters SMB/Windows
+	// reject (: " ? * < > |, trailing dots, device names like CON), "minimal" only
+	// replaces path separators and control characters. A "/" never survives, so
+	// "Part 1/2" cannot create a folder, and over-long titles are shortened.
+	TitleSanitize string `json:"title_sanitize"`
+
+	// BucketScheme controls the {initial} folder: "alpha" (A-Z/#), "none" or "decade".
+	BucketScheme string `json:"bucket_scheme"`
 
 	// Templates (Filebot-ish). Variables are documented in SPEC.md.
 	MovieDirTemplate   string `json:"movie_dir_template"`
@@ -19,8 +71,42 @@ type Library struct {
 	SeriesFileTemplate string `json:"series_file_template"`
 
 	SeasonFolderTemplate string `json:"season_folder_template"` // e.g. "TEMPORADA {season:00}"
+	// SpecialsFolderTemplate is the folder of season 0 episodes (S00E05, and "E05"-style
+	// names without a season once TMDB confirms the show), e.g. "Specials". Empty =
+	// season_folder_template ("TEMPORADA 00").
+	SpecialsFolderTemplate string `json:"specials_folder_template"`
+
+	// ListingCacheSeconds keeps the library-auto virtual tree in memory between directory
+	// reads (default 300, -1 = rebuild on every read). Imports, deletes and overrides
+	// invalidate it right away; the TTL only bounds staleness from other DB writers.
+	ListingCacheSeconds int `json:"listing_cache_seconds"`
+	// ListingLimit caps the imports/files read per FUSE directory listing (library-auto,
+	// library-manual raw/imports, raw). Listings are newest import first, so a cap hides
+	// the oldest entries and is logged. 0 = no limit (default).
+	ListingLimit int `json:"listing_limit"`
+
+	// MergeSeriesByTMDB groups library-auto episodes by TMDB id, so one show never splits
+	// into near-identical folders when imports resolved to slightly different titles or
+	// years (default true).
+	MergeSeriesByTMDB bool `json:"merge_series_by_tmdb"`
+
+	// QualityBuckets replaces the built-in quality tiers (4K, 1080, 720, SD) behind the
+	// {quality} folder of library-auto and the raw NZB layout. Buckets are tried in order
+	// and the first match wins. Empty = built-in tiers.
+	QualityBuckets []QualityBucket `json:"quality_buckets"`
 }
 
+// QualityBucket is one quality folder. A Match rule selects the bucket when every one of
+// its "+"-joined tokens appears in the file name as a whole word (case-insensitive), so
+// ["2160p+hdr", "2160p+dv"] catches HDR 4K while a later ["2160p", "4k"] bucket gets SDR 4K.
+type QualityBucket struct {
+	Name  string   `json:"name"` // folder name, e.g. "2160-HDR"
+	Match []string `json:"match"`
+}
+
+// DefaultVersionTags are the version tags of library.version_tags when none are set.
+var DefaultVersionTags = []string{"LATINO", "CASTELLANO", "DUAL", "EXTENDED", "DC"}
+
 func (l Library) withDefaults() Library {
 	out := l
 	if out.MoviesRoot == "" {
@@ -35,6 +121,15 @@ func (l Library) withDefaults() Library {
 	if out.FinalizadasFolder == "" {
 		out.FinalizadasFolder = "FINALIZADAS"
 	}
+	if out.DefaultQuality == "" {
+		out.DefaultQuality = "1080"
+	}
+	if out.BucketScheme == "" {
+		out.BucketScheme = "alpha"
+	}
+	if out.TitleSanitize == "" {
+		out.TitleSanitize = "safe"
+	}
 	if out.MovieDirTemplate == "" {
 		out.MovieDirTemplate = "{movies_root}/{quality}/{initial}/{title} ({year}) tmdb-{tmdb_id}"
 	}
@@ -50,6 +145,9 @@ func (l Library) withDefaults() Library {
 	if out.SeriesFileTemplate == "" || out.SeriesFileTemplate == "{season:00}x{episode:00} - {episode_title}{ext}" {
 		out.SeriesFileTemplate = "{series} ({year}) - {season:00}x{episode:00} - {episode_title}{ext}"
 	}
+	if out.ListingCacheSeconds == 0 {
+		out.ListingCacheSeconds = 300
+	}
 	return out
 }
 
@@ -1,13 +1,56 @@
 package config
 
+import "strings"
+
 type TMDB struct {
 	Enabled  bool   `json:"enabled"`
 	APIKey   string `json:"api_key"`
 	Language string `json:"language"` // e.g. "es-ES" or "en-US"
+
+	// LanguageFallbacks are tried in order when Language yields no usable title
+	// (e.g. ["en-US"]). The original title is the last resort.
+	LanguageFallbacks []string `json:"language_fallbacks"`
+
+	// OriginalTitle decides when the original-language title (original_title /
+	// original_name) names a match: "fallback" (default) when Language and the
+	// fallbacks have none, "prefer" always, "never" (the filename guess is kept instead).
+	OriginalTitle string `json:"original_title"`
+}
+
+// metadata.tmdb.original_title values.
+const (
+	OriginalTitleFallback = "fallback"
+	OriginalTitlePrefer   = "prefer"
+	OriginalTitleNever    = "never"
+)
+
+// OriginalTitleMode returns metadata.tmdb.original_title, "fallback" when unset.
+func (t TMDB) OriginalTitleMode() string {
+	switch m := strings.ToLower(strings.TrimSpace(t.OriginalTitle)); m {
+	case OriginalTitlePrefer, OriginalTitleNever:
+		return m
+	}
+	return OriginalTitleFallback
 }
 
 type Metadata struct {
 	TMDB TMDB `json:"tmdb"`
+
+	// EnrichConcurrency is how many files of an import are resolved (FileBot/TMDB) at
+	// once (default 4). TMDB throttles with 429s, which send the import to the resolve
+	// retry queue, so keep it modest.
+	EnrichConcurrency int `json:"enrich_concurrency"`
+}
+
+// DefaultEnrichConcurrency is metadata.enrich_concurrency when unset.
+const DefaultEnrichConcurrency = 4
+
+// EnrichWorkers returns metadata.enrich_concurrency, or the default when unset.
+func (m Metadata) EnrichWorkers() int {
+	if m.EnrichConcurrency <= 0 {
+		return DefaultEnrichConcurrency
+	}
+	return m.EnrichConcurrency
 }
 
 func (m Metadata) withDefaults() Metadata {
@@ -19,5 +62,8 @@ func (m Metadata) withDefaults() Metadata {
 	if out.TMDB.Language == "" {
 		out.TMDB.Language = "es-ES"
 	}
+	if out.EnrichConcurrency <= 0 {
+		out.EnrichConcurrency = DefaultEnrichConcurrency
+	}
 	return out
 }
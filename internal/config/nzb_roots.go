@@ -0,0 +1,63 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+const defaultNZBOutputDir = "/host/inbox/nzb"
+
+// NZBOutputDir is the root uploaded NZBs of one category are written under:
+// upload.series_output_dir / upload.movies_output_dir when set, else ngpost.output_dir.
+func (c Config) NZBOutputDir(isSeries bool) string {
+	dir := c.Upload.MoviesOutputDir
+	if isSeries {
+		dir = c.Upload.SeriesOutputDir
+	}
+	if strings.TrimSpace(dir) == "" {
+		dir = c.NgPost.OutputDir
+	}
+	if strings.TrimSpace(dir) == "" {
+		dir = defaultNZBOutputDir
+	}
+	return filepath.Clean(strings.TrimSpace(dir))
+}
+
+// NZBOutputRoots lists the distinct NZB output roots: ngpost.output_dir first, then
+// the category roots that differ from it.
+func (c Config) NZBOutputRoots() []string {
+	out := make([]string, 0, 3)
+	for _, d := range []string{c.NgPost.OutputDir, c.Upload.MoviesOutputDir, c.Upload.SeriesOutputDir} {
+		d = strings.TrimSpace(d)
+		if d == "" {
+			if len(out) > 0 {
+				continue
+			}
+			d = defaultNZBOutputDir
+		}
+		d = filepath.Clean(d)
+		dup := false
+		for _, o := range out {
+			dup = dup || o == d
+		}
+		if !dup {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// NZBRootFor returns the output root p lives under (the deepest one when roots nest).
+func (c Config) NZBRootFor(p string) (string, bool) {
+	p = filepath.Clean(p)
+	best := ""
+	for _, root := range c.NZBOutputRoots() {
+		if p != root && !strings.HasPrefix(p, root+string(filepath.Separator)) {
+			continue
+		}
+		if len(root) > len(best) {
+			best = root
+		}
+	}
+	return best, best != ""
+}
@@ -1,5 +1,10 @@
 package config
 
+import (
+	"path/filepath"
+	"strings"
+)
+
 // Plex config: optional library refresh after new items are imported.
 //
 // Note: Plex may read parts of files during scan/analysis; this can trigger on-demand streaming.
@@ -17,9 +22,69 @@ type Plex struct {
 
 	// RefreshOnImport triggers a targeted refresh when an NZB is imported.
 	RefreshOnImport bool `json:"refresh_on_import"`
+
+	// RefreshMode: "path" (default) refreshes each new folder, "section" refreshes the
+	// whole Plex section (/library/sections/{id}/refresh), "both" does both. Use section
+	// when per-path refreshes don't reliably trigger a scan on your setup.
+	RefreshMode string `json:"refresh_mode"`
+
+	// RefreshDebounceSeconds coalesces the refreshes of imports that arrive close
+	// together: paths are collected and refreshed once no import finished for this long
+	// (at the latest after ten periods). A large batch in "path" mode is refreshed per
+	// section instead. 0 = refresh right after each import (default).
+	RefreshDebounceSeconds int `json:"refresh_debounce_seconds"`
+
+	// SectionMap maps a library-auto folder prefix (e.g. "PELICULAS", "SERIES") to the
+	// Plex section id used by section refreshes. The longest matching prefix wins.
+	SectionMap map[string]int `json:"section_map"`
+
+	// Categories maps an EDRmount category ("movies", "series", "4k") to its Plex
+	// section and the path prefix Plex sees for library-auto in that section.
+	// "4k" applies to 4K items of either kind when present. Missing entries fall back
+	// to plex_root / section_map.
+	Categories map[string]PlexCategory `json:"categories"`
+}
+
+type PlexCategory struct {
+	SectionID int    `json:"section_id"`
+	Root      string `json:"root"` // empty = plex_root
+}
+
+// CategoryFor picks the category mapping for a resolved kind (movie|series) and quality.
+func (p Plex) CategoryFor(kind, quality string) (PlexCategory, bool) {
+	if strings.EqualFold(strings.TrimSpace(quality), "4K") {
+		if c, ok := p.Categories["4k"]; ok {
+			return c, true
+		}
+	}
+	key := "movies"
+	if kind == "series" {
+		key = "series"
+	}
+	c, ok := p.Categories[key]
+	return c, ok
 }
 
 func (p Plex) withDefaults() Plex {
 	out := p
+	if out.RefreshMode == "" {
+		out.RefreshMode = "path"
+	}
 	return out
 }
+
+// SectionFor returns the Plex section id for a library-auto relative path (0 = none).
+func (p Plex) SectionFor(virtualPath string) int {
+	vp := strings.Trim(filepath.ToSlash(virtualPath), "/")
+	best, bestLen := 0, -1
+	for prefix, id := range p.SectionMap {
+		pf := strings.Trim(filepath.ToSlash(prefix), "/")
+		if id <= 0 || len(pf) <= bestLen {
+			continue
+		}
+		if pf == "" || strings.EqualFold(vp, pf) || strings.HasPrefix(strings.ToLower(vp), strings.ToLower(pf)+"/") {
+			best, bestLen = id, len(pf)
+		}
+	}
+	return best
+}
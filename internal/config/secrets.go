@@ -0,0 +1,51 @@
+package config
+
+// Credentials are the config fields that hold secrets (provider passwords, API keys,
+// tokens). Portable exports blank them unless explicitly asked not to. Secrets found
+// in imported data are not kept at all: an NZB <head> password is only recorded as
+// nzb_imports.nzb_has_password.
+
+// Redacted returns a copy of c with every credential field emptied.
+func (c Config) Redacted() Config {
+	out := c
+	out.Server.Auth.Pass = ""
+	out.Server.Auth.Token = ""
+	out.NgPost.Pass = ""
+	out.Download.Pass = ""
+	out.Metadata.TMDB.APIKey = ""
+	out.Plex.Token = ""
+	out.Health.RepairProviders = append([]DownloadProvider(nil), c.Health.RepairProviders...)
+	for i := range out.Health.RepairProviders {
+		out.Health.RepairProviders[i].Pass = ""
+	}
+	return out
+}
+
+// WithCredentialsFrom returns c with each empty credential field taken from cur, so
+// importing a redacted export keeps the secrets already configured.
+func (c Config) WithCredentialsFrom(cur Config) Config {
+	out := c
+	keep := func(dst *string, v string) {
+		if *dst == "" {
+			*dst = v
+		}
+	}
+	keep(&out.Server.Auth.Pass, cur.Server.Auth.Pass)
+	keep(&out.Server.Auth.Token, cur.Server.Auth.Token)
+	keep(&out.NgPost.Pass, cur.NgPost.Pass)
+	keep(&out.Download.Pass, cur.Download.Pass)
+	keep(&out.Metadata.TMDB.APIKey, cur.Metadata.TMDB.APIKey)
+	keep(&out.Plex.Token, cur.Plex.Token)
+	// Repair providers are matched by host and user: the list may be reordered or edited.
+	out.Health.RepairProviders = append([]DownloadProvider(nil), c.Health.RepairProviders...)
+	for i := range out.Health.RepairProviders {
+		p := &out.Health.RepairProviders[i]
+		for _, q := range cur.Health.RepairProviders {
+			if p.Host == q.Host && p.User == q.User {
+				keep(&p.Pass, q.Pass)
+				break
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package config
+
+import "strings"
+
+// SetupItem is the state of one subsystem for a first-run setup wizard.
+type SetupItem struct {
+	Name       string `json:"name"`
+	Configured bool   `json:"configured"`
+	// Required items must be configured before EDRmount does anything useful; the rest
+	// only degrade a feature.
+	Required bool   `json:"required"`
+	Message  string `json:"message,omitempty"` // what doesn't work while unconfigured
+}
+
+// SetupStatus is GET /api/v1/setup/status.
+type SetupStatus struct {
+	// SetupRequired is the setup_required flag of the first-run config.
+	SetupRequired bool `json:"setup_required"`
+	// Ready is true once every required item is configured.
+	Ready bool        `json:"ready"`
+	Items []SetupItem `json:"items"`
+}
+
+// SetupStatus reports which subsystems the config leaves unconfigured. It only reads
+// the config: nothing is dialed or executed.
+func (c Config) SetupStatus() SetupStatus {
+	has := func(s string) bool { return strings.TrimSpace(s) != "" }
+	items := []SetupItem{
+		{
+			Name:       "download",
+			Configured: c.Download.Enabled && has(c.Download.Host),
+			Required:   true,
+			Message:    "no download provider: streaming, library mounts and health checks won't work",
+		},
+		{
+			Name:       "runner",
+			Configured: c.Runner.Enabled,
+			Required:   true,
+			Message:    "job runner disabled: imports and uploads stay queued",
+		},
+		{
+			Name:       "upload",
+			Configured: c.NgPost.Enabled && has(c.NgPost.Host) && has(c.NgPost.User) && c.NgPost.Pass != "",
+			Message:    "no upload provider credentials (ngpost): uploads won't work",
+		},
+		{
+			Name:       "tmdb",
+			Configured: c.Metadata.TMDB.Enabled && has(c.Metadata.TMDB.APIKey),
+			Message:    "no TMDB API key: library-auto names titles from filename guesses",
+		},
+		{
+			Name:       "watch",
+			Configured: c.Watch.NZB.Enabled || c.Watch.Media.Enabled,
+			Message:    "watchers disabled: NZB and media inboxes are not picked up",
+		},
+		{
+			Name:       "plex",
+			Configured: c.Plex.Enabled && has(c.Plex.BaseURL) && has(c.Plex.Token),
+			Message:    "Plex not connected: no library refresh after imports",
+		},
+	}
+	st := SetupStatus{SetupRequired: c.SetupRequired, Ready: true, Items: items}
+	for i := range st.Items {
+		if st.Items[i].Configured {
+			st.Items[i].Message = ""
+		} else if st.Items[i].Required {
+			st.Ready = false
+		}
+	}
+	return st
+}
+
+// WithSetupFrom returns c with the setup_required flag of cur: clients never set it.
+// It is cleared once c has every required item configured, so the first save that
+// completes the setup ends first-run mode.
+func (c Config) WithSetupFrom(cur Config) Config {
+	out := c
+	out.SetupRequired = cur.SetupRequired && !c.SetupStatus().Ready
+	return out
+}
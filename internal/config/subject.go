@@ -0,0 +1,8 @@
+package config
+
+// Subject controls how filenames are derived from NZB subjects.
+type Subject struct {
+	// Patterns are regexes tried in order; the built-in quoted-name heuristic is the last fallback.
+	// Use a named group (?P<name>...) or the first capture group for the filename.
+	Patterns []string `json:"patterns"`
+}
@@ -0,0 +1,47 @@
+package config
+
+// Thumbnails extracts one preview frame per video file after import, for the UI to
+// show something even when TMDB has no poster. The frame is cut from the head of the
+// file (streamed from usenet, at most MaxHeadMB) and cached under
+// <cache_dir>/thumbs; see GET /api/v1/catalog/imports/{id}/files/{idx}/thumb.
+type Thumbnails struct {
+	Enabled bool `json:"enabled"`
+
+	// FFmpegPath is the ffmpeg binary. Empty = transcode.ffmpeg_path, else "ffmpeg".
+	FFmpegPath string `json:"ffmpeg_path"`
+	// SeekSeconds is where the frame is taken (default 180, past intros and black
+	// frames). Files shorter than that, or whose head ends first, use the first frame.
+	SeekSeconds int `json:"seek_seconds"`
+	// MaxHeadMB bounds how much of each file is downloaded to reach the frame (default 256).
+	MaxHeadMB int `json:"max_head_mb"`
+	// Width of the JPEG in pixels, height keeps the aspect ratio (default 320).
+	Width int `json:"width"`
+}
+
+func (t Thumbnails) withDefaults() Thumbnails {
+	out := t
+	if out.SeekSeconds <= 0 {
+		out.SeekSeconds = 180
+	}
+	if out.MaxHeadMB <= 0 {
+		out.MaxHeadMB = 256
+	}
+	if out.Width <= 0 {
+		out.Width = 320
+	}
+	return out
+}
+
+// Defaults returns a copy of the thumbnails config with empty fields filled.
+func (t Thumbnails) Defaults() Thumbnails { return t.withDefaults() }
+
+// ThumbnailFFmpeg returns the ffmpeg binary used for thumbnails.
+func (c Config) ThumbnailFFmpeg() string {
+	if c.Thumbnails.FFmpegPath != "" {
+		return c.Thumbnails.FFmpegPath
+	}
+	if c.Transcode.FFmpegPath != "" {
+		return c.Transcode.FFmpegPath
+	}
+	return "ffmpeg"
+}
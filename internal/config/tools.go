@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Default locations of the external binaries the runner shells out to. par2 is looked
+// up in PATH.
+const (
+	DefaultNgPostBinary  = "/usr/local/bin/ngpost"
+	DefaultNyuuBinary    = "/usr/local/bin/nyuu"
+	DefaultPar2Binary    = "par2"
+	DefaultFileBotBinary = "/usr/local/bin/filebot"
+)
+
+// ToolStatus is the result of checking one external binary.
+type ToolStatus struct {
+	Name       string `json:"name"`
+	Path       string `json:"path"`
+	OK         bool   `json:"ok"` // exists and is executable
+	Required   bool   `json:"required"`
+	RequiredBy string `json:"required_by,omitempty"` // enabled feature(s) that need it
+	Error      string `json:"error,omitempty"`
+}
+
+func checkTool(name, path string, requiredBy []string) ToolStatus {
+	st := ToolStatus{Name: name, Path: path, Required: len(requiredBy) > 0, RequiredBy: strings.Join(requiredBy, ", ")}
+	// LookPath resolves bare names in PATH and checks the executable bit of explicit paths.
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		if _, serr := os.Stat(path); serr == nil {
+			st.Error = "not executable"
+		} else {
+			st.Error = "not found"
+		}
+		return st
+	}
+	st.Path = resolved
+	st.OK = true
+	return st
+}
+
+// Tools checks the external binaries and which enabled features need them.
+func (c Config) Tools() []ToolStatus {
+	execMode := c.Runner.Enabled && c.Runner.Mode == "exec"
+	provider := strings.ToLower(strings.TrimSpace(c.Upload.Provider))
+	if provider == "" {
+		provider = "ngpost"
+	}
+	var ngpost, nyuu, par2, filebot []string
+	if execMode && c.NgPost.Enabled && c.Runner.HasRole(RoleUpload) {
+		if provider == "nyuu" {
+			nyuu = append(nyuu, "upload.provider=nyuu")
+		} else {
+			ngpost = append(ngpost, "upload.provider=ngpost")
+		}
+		if c.Upload.Par.Enabled && c.Upload.Par.RedundancyPercent > 0 {
+			par2 = append(par2, "upload.par")
+		}
+	}
+	if execMode && c.Health.Enabled && c.Runner.HasRole(RoleHealth) {
+		par2 = append(par2, "health")
+		// Health re-uploads always go through ngpost.
+		if c.Health.ReuploadAfterRepair {
+			ngpost = append(ngpost, "health.reupload_after_repair")
+		}
+	}
+	if execMode && strings.EqualFold(strings.TrimSpace(c.Rename.Provider), "filebot") && c.Rename.FileBot.Enabled {
+		filebot = append(filebot, "rename.filebot")
+	}
+	fbBin := strings.TrimSpace(c.Rename.FileBot.Binary)
+	if fbBin == "" {
+		fbBin = DefaultFileBotBinary
+	}
+	tools := []ToolStatus{
+		checkTool("ngpost", DefaultNgPostBinary, ngpost),
+		checkTool("nyuu", DefaultNyuuBinary, nyuu),
+		checkTool("par2", DefaultPar2Binary, par2),
+		checkTool("filebot", fbBin, filebot),
+	}
+	if hook := strings.TrimSpace(c.Hooks.OnImport); hook != "" {
+		var by []string
+		if execMode && c.Runner.HasRole(RoleImport) {
+			by = append(by, "hooks.on_import")
+		}
+		tools = append(tools, checkTool("on_import hook", hook, by))
+	}
+	return tools
+}
+
+// ValidateTools fails when a binary needed by an enabled feature is missing and
+// runner.missing_tools is "fail". With "warn" (default) missing tools are only
+// reported (startup log, /api/v1/system/tools).
+func (c Config) ValidateTools() error {
+	if c.Runner.MissingTools != "fail" {
+		return nil
+	}
+	for _, t := range c.Tools() {
+		if t.Required && !t.OK {
+			return fmt.Errorf("%s binary %s (%s) is %s; install it or disable the feature (runner.missing_tools=fail)", t.Name, t.Path, t.RequiredBy, t.Error)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+package config
+
+// Transcode controls the optional remux-on-the-fly play mode (?remux=mp4).
+// Streams are repackaged with "ffmpeg -c copy" (no re-encoding) into fragmented MP4.
+// The output is not seekable: Range requests are ignored and no Content-Length is sent.
+// ffmpeg is not bundled in the image; point FFmpegPath at an installed binary.
+type Transcode struct {
+	Enabled    bool   `json:"enabled"`
+	FFmpegPath string `json:"ffmpeg_path"` // default: ffmpeg (from PATH)
+}
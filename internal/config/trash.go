@@ -0,0 +1,8 @@
+package config
+
+// Trash controls the delete_full trash (/host/inbox/.trash).
+type Trash struct {
+	// RetentionDays purges trashed NZB/PAR2 files this many days after the delete
+	// (default 30, -1 = keep forever).
+	RetentionDays int `json:"retention_days"`
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CheckResult is the outcome of an integrity self-check.
+type CheckResult struct {
+	OK          bool             `json:"ok"`
+	Integrity   []string         `json:"integrity"`    // "ok" when healthy
+	ForeignKeys []FKViolation    `json:"foreign_keys"` // empty when healthy
+	Tables      map[string]int64 `json:"tables"`       // row counts
+	DurationMS  int64            `json:"duration_ms"`
+}
+
+type FKViolation struct {
+	Table  string `json:"table"`
+	RowID  int64  `json:"rowid"`
+	Parent string `json:"parent"`
+	FKID   int    `json:"fkid"`
+}
+
+// Check runs PRAGMA integrity_check and foreign_key_check and counts rows per table.
+// It only reads; safe to run while the app is serving.
+func (d *DB) Check(ctx context.Context) (CheckResult, error) {
+	start := time.Now()
+	res := CheckResult{Integrity: []string{}, ForeignKeys: []FKViolation{}, Tables: map[string]int64{}}
+
+	rows, err := d.SQL.QueryContext(ctx, `PRAGMA integrity_check`)
+	if err != nil {
+		return res, err
+	}
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err == nil {
+			res.Integrity = append(res.Integrity, line)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return res, err
+	}
+
+	rows, err = d.SQL.QueryContext(ctx, `PRAGMA foreign_key_check`)
+	if err != nil {
+		return res, err
+	}
+	for rows.Next() {
+		var v FKViolation
+		var rowID *int64
+		if err := rows.Scan(&v.Table, &rowID, &v.Parent, &v.FKID); err != nil {
+			continue
+		}
+		if rowID != nil {
+			v.RowID = *rowID
+		}
+		res.ForeignKeys = append(res.ForeignKeys, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return res, err
+	}
+
+	rows, err = d.SQL.QueryContext(ctx, `SELECT name FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+	if err != nil {
+		return res, err
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err == nil {
+			tables = append(tables, name)
+		}
+	}
+	rows.Close()
+	for _, t := range tables {
+		var n int64
+		q := fmt.Sprintf(`SELECT COUNT(1) FROM "%s"`, strings.ReplaceAll(t, `"`, `""`))
+		if err := d.SQL.QueryRowContext(ctx, q).Scan(&n); err != nil {
+			return res, fmt.Errorf("count %s: %w", t, err)
+		}
+		res.Tables[t] = n
+	}
+
+	res.OK = len(res.Integrity) == 1 && res.Integrity[0] == "ok" && len(res.ForeignKeys) == 0
+	res.DurationMS = time.Since(start).Milliseconds()
+	return res, nil
+}
+
+// SizeBytes returns the main database file size as reported by SQLite (page_count * page_size).
+func (d *DB) SizeBytes(ctx context.Context) (int64, error) {
+	var pages, pageSize int64
+	if err := d.SQL.QueryRowContext(ctx, `PRAGMA page_count`).Scan(&pages); err != nil {
+		return 0, err
+	}
+	if err := d.SQL.QueryRowContext(ctx, `PRAGMA page_size`).Scan(&pageSize); err != nil {
+		return 0, err
+	}
+	return pages * pageSize, nil
+}
+
+// Vacuum rebuilds the database file to reclaim free pages and truncates the WAL.
+// It blocks writers for its duration.
+func (d *DB) Vacuum(ctx context.Context) error {
+	if _, err := d.SQL.ExecContext(ctx, `VACUUM`); err != nil {
+		return err
+	}
+	_, err := d.SQL.ExecContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`)
+	return err
+}
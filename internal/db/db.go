@@ -54,6 +54,12 @@ func (d *DB) migrate() error {
 		);`,
 		`CREATE INDEX IF NOT EXISTS idx_jobs_state_updated ON jobs(state, updated_at);`,
 		`CREATE INDEX IF NOT EXISTS idx_jobs_created_at ON jobs(created_at);`,
+		// Manual queue order: lower runs first; ties fall back to created_at (FIFO).
+		`ALTER TABLE jobs ADD COLUMN queue_pos INTEGER NOT NULL DEFAULT 0;`,
+		// Automatic retries: attempts made so far, and the earliest time (unix) a
+		// re-queued job may be claimed again.
+		`ALTER TABLE jobs ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;`,
+		`ALTER TABLE jobs ADD COLUMN not_before INTEGER NOT NULL DEFAULT 0;`,
 		`CREATE TABLE IF NOT EXISTS job_logs (
 			job_id TEXT NOT NULL,
 			ts INTEGER NOT NULL,
@@ -76,6 +82,18 @@ func (d *DB) migrate() error {
 			total_bytes INTEGER NOT NULL
 		);`,
 		`CREATE INDEX IF NOT EXISTS idx_nzb_imports_time ON nzb_imports(imported_at);`,
+		`ALTER TABLE nzb_imports ADD COLUMN needs_extraction INTEGER NOT NULL DEFAULT 0;`,
+		// Fingerprint of the set of message-ids; same post saved under another NZB path.
+		`ALTER TABLE nzb_imports ADD COLUMN content_hash TEXT NOT NULL DEFAULT '';`,
+		`CREATE INDEX IF NOT EXISTS idx_nzb_imports_content_hash ON nzb_imports(content_hash);`,
+		`ALTER TABLE nzb_imports ADD COLUMN notes TEXT NOT NULL DEFAULT '';`,
+		// NZB <head> metadata: <meta type="category">, and whether a <meta type="password">
+		// is declared (the password itself is never stored).
+		`ALTER TABLE nzb_imports ADD COLUMN nzb_category TEXT NOT NULL DEFAULT '';`,
+		`ALTER TABLE nzb_imports ADD COLUMN nzb_has_password INTEGER NOT NULL DEFAULT 0;`,
+		// 'partial' while an incrementally committed import is still loading (or was cut
+		// short by a crash); 'complete' once its last batch is in.
+		`ALTER TABLE nzb_imports ADD COLUMN import_state TEXT NOT NULL DEFAULT 'complete';`,
 
 		`CREATE TABLE IF NOT EXISTS nzb_files (
 			import_id TEXT NOT NULL,
@@ -102,6 +120,9 @@ func (d *DB) migrate() error {
 			PRIMARY KEY(import_id, file_idx, number)
 		);`,
 		`CREATE INDEX IF NOT EXISTS idx_nzb_segments_file ON nzb_segments(import_id, file_idx);`,
+		// Decoded payload size (bytes is the NZB's, usually the encoded size); 0 = unknown.
+		// Filled by import.precompute_decoded_sizes for exact stream offsets.
+		`ALTER TABLE nzb_segments ADD COLUMN decoded_bytes INTEGER NOT NULL DEFAULT 0;`,
 
 		// Manual library view (UI-managed)
 		`CREATE TABLE IF NOT EXISTS manual_dirs (
@@ -110,6 +131,8 @@ func (d *DB) migrate() error {
 			name TEXT NOT NULL
 		);`,
 		`CREATE INDEX IF NOT EXISTS idx_manual_dirs_parent ON manual_dirs(parent_id);`,
+		// source: 'user' (UI) or 'auto' (seeded from the NZB path). Pre-existing dirs stay 'user'.
+		`ALTER TABLE manual_dirs ADD COLUMN source TEXT NOT NULL DEFAULT 'user';`,
 		`CREATE TABLE IF NOT EXISTS manual_items (
 			id TEXT PRIMARY KEY,
 			dir_id TEXT NOT NULL,
@@ -123,7 +146,7 @@ func (d *DB) migrate() error {
 		`CREATE TABLE IF NOT EXISTS library_overrides (
 			import_id TEXT NOT NULL,
 			file_idx INTEGER NOT NULL,
-			kind TEXT NOT NULL, -- "movie" | "tv" (reserved)
+			kind TEXT NOT NULL, -- "movie" | "series"
 			title TEXT NOT NULL,
 			year INTEGER NOT NULL,
 			quality TEXT NOT NULL,
@@ -131,7 +154,12 @@ func (d *DB) migrate() error {
 			updated_at INTEGER NOT NULL,
 			PRIMARY KEY(import_id, file_idx)
 		);`,
+		`ALTER TABLE library_overrides ADD COLUMN season INTEGER NOT NULL DEFAULT 0;`,
+		`ALTER TABLE library_overrides ADD COLUMN episode INTEGER NOT NULL DEFAULT 0;`,
 		`CREATE INDEX IF NOT EXISTS idx_library_overrides_updated ON library_overrides(updated_at);`,
+		// Title search is a substring LIKE, which no index can serve; drop the ones an
+		// earlier build created so they stop costing every override/enrich write.
+		`DROP INDEX IF EXISTS idx_library_overrides_title;`,
 
 		`CREATE TABLE IF NOT EXISTS library_review_dismissed (
 			import_id TEXT NOT NULL,
@@ -162,11 +190,12 @@ func (d *DB) migrate() error {
 		`ALTER TABLE library_resolved ADD COLUMN virtual_name TEXT NOT NULL DEFAULT '';`,
 		`ALTER TABLE library_resolved ADD COLUMN virtual_path TEXT NOT NULL DEFAULT '';`,
 		`CREATE INDEX IF NOT EXISTS idx_library_resolved_import ON library_resolved(import_id);`,
+		`DROP INDEX IF EXISTS idx_library_resolved_title;`,
 
 		// Health scanning state
 		`CREATE TABLE IF NOT EXISTS health_nzb_state (
 			path TEXT PRIMARY KEY,
-			status TEXT NOT NULL, -- "unknown"|"ok"|"broken"|"repairing"|"repaired"|"error"
+			status TEXT NOT NULL, -- "unknown"|"ok"|"broken"|"repairing"|"repaired"|"missing-parity"|"unrepairable"|"pending-propagation"|"error"
 			last_checked_at INTEGER,
 			last_error TEXT,
 			last_repair_job_id TEXT,
@@ -184,6 +213,84 @@ func (d *DB) migrate() error {
 			last_run_completed_at INTEGER
 		);`,
 		`INSERT OR IGNORE INTO health_scan_state(id) VALUES (1);`,
+
+		// Runner pause switch (survives restarts)
+		`CREATE TABLE IF NOT EXISTS runner_state (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			paused INTEGER NOT NULL DEFAULT 0,
+			updated_at INTEGER
+		);`,
+		`INSERT OR IGNORE INTO runner_state(id, paused) VALUES (1, 0);`,
+
+		`CREATE TABLE IF NOT EXISTS suppressed_paths (
+			path TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			reason TEXT NOT NULL,
+			until_at INTEGER NOT NULL,
+			created_at INTEGER NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_suppressed_paths_name ON suppressed_paths(name);`,
+
+		`CREATE TABLE IF NOT EXISTS collections (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			created_at INTEGER NOT NULL
+		);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_collections_name ON collections(name);`,
+		`CREATE TABLE IF NOT EXISTS collection_items (
+			collection_id TEXT NOT NULL,
+			import_id TEXT NOT NULL,
+			file_idx INTEGER NOT NULL,
+			added_at INTEGER NOT NULL,
+			PRIMARY KEY(collection_id, import_id, file_idx)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_collection_items_import ON collection_items(import_id);`,
+
+		// Imports whose TMDB enrichment failed (outage); retried with backoff by the runner.
+		// One row per play (a client's GETs of a file, coalesced); pruned after server.play_history_days.
+		`CREATE TABLE IF NOT EXISTS play_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			import_id TEXT NOT NULL,
+			file_idx INTEGER NOT NULL,
+			started_at INTEGER NOT NULL,
+			ended_at INTEGER NOT NULL DEFAULT 0,
+			bytes_served INTEGER NOT NULL DEFAULT 0,
+			user_agent TEXT NOT NULL DEFAULT ''
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_play_history_started ON play_history(started_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_play_history_import ON play_history(import_id);`,
+
+		// Health repairs handed to the media inbox: the re-upload reuses nzb_path and the
+		// re-import reuses import_id.
+		`CREATE TABLE IF NOT EXISTS health_replacements (
+			media_name TEXT PRIMARY KEY,
+			nzb_path TEXT NOT NULL,
+			import_id TEXT NOT NULL,
+			created_at INTEGER NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_health_replacements_path ON health_replacements(nzb_path);`,
+
+		// One row per backup attempt (scheduled or manual), for /api/v1/backups/status.
+		`CREATE TABLE IF NOT EXISTS backup_runs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			trigger TEXT NOT NULL, -- "scheduled"|"manual"
+			started_at INTEGER NOT NULL,
+			finished_at INTEGER NOT NULL,
+			ok INTEGER NOT NULL,
+			path TEXT NOT NULL DEFAULT '',
+			error TEXT NOT NULL DEFAULT ''
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_backup_runs_started ON backup_runs(started_at);`,
+
+		`CREATE TABLE IF NOT EXISTS library_resolve_pending (
+			import_id TEXT PRIMARY KEY,
+			attempts INTEGER NOT NULL,
+			next_at INTEGER NOT NULL,
+			last_error TEXT NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_library_resolve_pending_next ON library_resolve_pending(next_at);`,
 	}
 	for _, s := range stmts {
 		if _, err := d.SQL.Exec(s); err != nil {
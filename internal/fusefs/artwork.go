@@ -0,0 +1,240 @@
+package fusefs
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"bazil.org/fuse"
+	"bazil.org/fuse/fs"
+
+	"github.com/gaby/EDRmount/internal/meta/tmdb"
+)
+
+// Synthetic Plex/Kodi sidecar files for library-auto (library.generate_nfo /
+// library.generate_posters). Movies get movie.nfo + poster.jpg in the movie folder,
+// series get tvshow.nfo + poster.jpg in the show folder (above the season folders).
+
+type libArt struct {
+	kind    string // movie|series
+	tmdbID  int
+	title   string
+	year    int
+	updated int64 // newest updated_at of the resolved/override rows: the NFO mtime
+}
+
+type artKey struct {
+	importID string
+	fileIdx  int
+}
+
+// artworkEnabled reports whether library-auto should expose any sidecar files.
+func (r *LibraryFS) artworkEnabled() bool {
+	return r.Cfg.Library.GenerateNFO || r.Cfg.Library.GeneratePosters
+}
+
+// artworkScanTTL bounds how long a TMDB id scan is reused. Imports and overrides made
+// through this process invalidate it right away (Jobs.LibraryVersion).
+const artworkScanTTL = time.Minute
+
+type artScan struct {
+	version uint64
+	built   time.Time
+	meta    map[artKey]libArt
+}
+
+// artworkMeta returns the TMDB ids of every resolved file, overrides taking precedence.
+// The full-table scan is cached, so rebuilding the tree (or listing with
+// library.listing_cache_seconds=-1) does not repeat it on every read.
+func (r *LibraryFS) artworkMeta(ctx context.Context) map[artKey]libArt {
+	ver := r.Jobs.LibraryVersion()
+	r.artMu.Lock()
+	defer r.artMu.Unlock()
+	if c := r.artCache; c != nil && c.version == ver && time.Since(c.built) < artworkScanTTL {
+		return c.meta
+	}
+	meta, err := r.scanArtworkMeta(ctx)
+	if err != nil {
+		return meta
+	}
+	r.artCache = &artScan{version: ver, built: time.Now(), meta: meta}
+	return meta
+}
+
+func (r *LibraryFS) scanArtworkMeta(ctx context.Context) (map[artKey]libArt, error) {
+	out := map[artKey]libArt{}
+	rows, err := r.Jobs.DB().SQL.QueryContext(ctx, `
+		SELECT r.import_id, r.file_idx,
+		       COALESCE(NULLIF(o.kind,''), r.kind),
+		       CASE WHEN o.tmdb_id > 0 THEN o.tmdb_id ELSE r.tmdb_id END,
+		       COALESCE(NULLIF(o.title,''), r.title),
+		       CASE WHEN o.year > 0 THEN o.year ELSE r.year END,
+		       MAX(r.updated_at, COALESCE(o.updated_at, 0))
+		FROM library_resolved r
+		LEFT JOIN library_overrides o ON o.import_id=r.import_id AND o.file_idx=r.file_idx
+	`)
+	if err != nil {
+		return out, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var k artKey
+		var a libArt
+		if err := rows.Scan(&k.importID, &k.fileIdx, &a.kind, &a.tmdbID, &a.title, &a.year, &a.updated); err != nil || a.tmdbID <= 0 {
+			continue
+		}
+		out[k] = a
+	}
+	return out, rows.Err()
+}
+
+// artworkDir is the folder that holds the sidecars for a file at virtual path p.
+func artworkDir(a libArt, p string) string {
+	d := filepath.Dir(p)
+	if a.kind == "series" {
+		d = filepath.Dir(d)
+	}
+	if d == "." {
+		return ""
+	}
+	return d
+}
+
+// artworkFiles lists the sidecar names available for a, starting a background
+// poster fetch if it is not cached yet.
+func (r *LibraryFS) artworkFiles(a libArt) []string {
+	var out []string
+	if r.Cfg.Library.GenerateNFO {
+		if a.kind == "series" {
+			out = append(out, "tvshow.nfo")
+		} else {
+			out = append(out, "movie.nfo")
+		}
+	}
+	if r.Cfg.Library.GeneratePosters {
+		if st, err := os.Stat(r.posterPath(a)); err == nil && st.Size() > 0 {
+			out = append(out, "poster.jpg")
+		} else {
+			r.fetchPosterAsync(a)
+		}
+	}
+	return out
+}
+
+func (r *LibraryFS) posterPath(a libArt) string {
+	return filepath.Join(r.Cfg.Paths.CacheDir, "artwork", fmt.Sprintf("%s-%d.jpg", a.kind, a.tmdbID))
+}
+
+const posterRetryAfter = 1 * time.Hour
+
+// fetchPosterAsync downloads the TMDB poster into the artwork cache. One fetch per
+// title at a time; failures are retried at most once per posterRetryAfter.
+func (r *LibraryFS) fetchPosterAsync(a libArt) {
+	t := r.Cfg.Metadata.TMDB
+	if !t.Enabled || strings.TrimSpace(t.APIKey) == "" {
+		return
+	}
+	key := r.posterPath(a)
+	if v, loaded := r.posterFetch.LoadOrStore(key, time.Now()); loaded {
+		if time.Since(v.(time.Time)) < posterRetryAfter {
+			return
+		}
+		r.posterFetch.Store(key, time.Now())
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+		c := tmdb.New(t.APIKey).WithLanguage(t.Language)
+		var posterPath string
+		var err error
+		if a.kind == "series" {
+			var d tmdb.TVDetails
+			d, err = c.GetTV(ctx, a.tmdbID)
+			posterPath = d.PosterPath
+		} else {
+			var d tmdb.MovieDetails
+			d, err = c.GetMovie(ctx, a.tmdbID)
+			posterPath = d.PosterPath
+		}
+		if err == nil && posterPath == "" {
+			err = fmt.Errorf("no poster")
+		}
+		var data []byte
+		if err == nil {
+			data, err = c.GetImage(ctx, posterPath, "w500")
+		}
+		if err == nil {
+			err = writeFileAtomic(key, data)
+		}
+		if err != nil {
+			log.Printf("library artwork: %s tmdb=%d poster: %v", a.kind, a.tmdbID, err)
+		}
+	}()
+}
+
+func writeFileAtomic(p string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	tmp := p + ".part"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+// artworkFile renders a sidecar file. Its mtime is stable (the cached poster's, or the
+// metadata's last update for an NFO) so scanners do not re-read it on every pass.
+func (r *LibraryFS) artworkFile(a libArt, name string) (*libArtFile, error) {
+	if name == "poster.jpg" {
+		p := r.posterPath(a)
+		st, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		return &libArtFile{data: data, mtime: st.ModTime()}, nil
+	}
+	root := "movie"
+	if a.kind == "series" {
+		root = "tvshow"
+	}
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	fmt.Fprintf(&b, "<%s>\n  <title>", root)
+	_ = xml.EscapeText(&b, []byte(a.title))
+	b.WriteString("</title>\n")
+	if a.year > 0 {
+		fmt.Fprintf(&b, "  <year>%d</year>\n", a.year)
+	}
+	fmt.Fprintf(&b, "  <uniqueid type=\"tmdb\" default=\"true\">%d</uniqueid>\n  <tmdbid>%d</tmdbid>\n</%s>\n", a.tmdbID, a.tmdbID, root)
+	return &libArtFile{data: []byte(b.String()), mtime: time.Unix(a.updated, 0)}, nil
+}
+
+// libArtFile is an in-memory sidecar file (NFO or cached poster).
+type libArtFile struct {
+	data  []byte
+	mtime time.Time
+}
+
+func (n *libArtFile) Attr(ctx context.Context, a *fuse.Attr) error {
+	a.Mode = 0o444
+	a.Size = uint64(len(n.data))
+	a.Mtime = n.mtime
+	return nil
+}
+
+func (n *libArtFile) ReadAll(ctx context.Context) ([]byte, error) {
+	return n.data, nil
+}
+
+var _ fs.Node = (*libArtFile)(nil)
+var _ fs.HandleReadAller = (*libArtFile)(nil)
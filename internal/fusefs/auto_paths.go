@@ -9,13 +9,35 @@ import (
 
 	"github.com/gaby/EDRmount/internal/config"
 	"github.com/gaby/EDRmount/internal/jobs"
+	"github.com/gaby/EDRmount/internal/library"
 )
 
+// AutoVirtualItem is a library-auto path with the kind/quality it was resolved to.
+type AutoVirtualItem struct {
+	Path    string
+	Kind    string // movie|series
+	Quality string
+}
+
 // AutoVirtualPathsForImport returns the virtual library-auto paths (relative to the mount root)
 // for MKV payloads of a given import.
 //
 // This uses the same path-building logic as the LibraryFS.
 func AutoVirtualPathsForImport(ctx context.Context, cfg config.Config, st *jobs.Store, importID string) ([]string, error) {
+	items, err := AutoVirtualItemsForImport(ctx, cfg, st, importID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, len(items))
+	for _, it := range items {
+		out = append(out, it.Path)
+	}
+	return out, nil
+}
+
+// AutoVirtualItemsForImport is AutoVirtualPathsForImport with kind/quality per path
+// (resolved metadata and overrides first, filename guess otherwise).
+func AutoVirtualItemsForImport(ctx context.Context, cfg config.Config, st *jobs.Store, importID string) ([]AutoVirtualItem, error) {
 	if st == nil {
 		return nil, fmt.Errorf("jobs store required")
 	}
@@ -24,13 +46,32 @@ func AutoVirtualPathsForImport(ctx context.Context, cfg config.Config, st *jobs.
 	_, _ = lfs.Root()
 	ld := &libDir{fs: lfs, rel: ""}
 
+	// With library.merge_series_by_tmdb an episode's folder depends on the rest of the
+	// library, so take its path from the full tree instead of building it alone.
+	var merged map[int]string
+	if cfg.Library.MergeSeriesByTMDB {
+		t, err := lfs.tree(ctx)
+		if err != nil {
+			return nil, err
+		}
+		merged = map[int]string{}
+		for _, e := range t.entries {
+			if e.row.ImportID == importID {
+				merged[e.row.Idx] = e.path
+			}
+		}
+	}
+
+	var category string
+	_ = st.DB().SQL.QueryRowContext(ctx, `SELECT nzb_category FROM nzb_imports WHERE id=?`, importID).Scan(&category)
+
 	rows, err := st.DB().SQL.QueryContext(ctx, `SELECT idx, filename, subject, total_bytes FROM nzb_files WHERE import_id=? ORDER BY idx`, importID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	out := make([]string, 0)
+	out := make([]AutoVirtualItem, 0)
 	seen := map[string]bool{}
 	for rows.Next() {
 		var idx int
@@ -51,16 +92,35 @@ func AutoVirtualPathsForImport(ctx context.Context, cfg config.Config, st *jobs.
 			continue
 		}
 
-		p := ld.buildPath(ctx, libRow{ImportID: importID, Idx: idx, Filename: name, Bytes: bytes})
+		p, ok := merged[idx]
+		if !ok {
+			p = ld.buildPath(ctx, libRow{ImportID: importID, Idx: idx, Filename: name, Bytes: bytes, Category: category})
+		}
 		p = filepath.Clean(p)
 		p = strings.TrimPrefix(p, string(filepath.Separator))
 		if p == "." || p == "" {
 			continue
 		}
-		if !seen[p] {
-			seen[p] = true
-			out = append(out, p)
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		it := AutoVirtualItem{Path: p}
+		err := st.DB().SQL.QueryRowContext(ctx, `
+			SELECT COALESCE(NULLIF(o.kind,''), r.kind), COALESCE(NULLIF(o.quality,''), r.quality)
+			FROM library_resolved r
+			LEFT JOIN library_overrides o ON o.import_id=r.import_id AND o.file_idx=r.file_idx
+			WHERE r.import_id=? AND r.file_idx=?
+		`, importID, idx).Scan(&it.Kind, &it.Quality)
+		if err != nil {
+			g := library.GuessWithCategory(name, category)
+			it.Kind = "movie"
+			if g.IsSeries || library.CategoryKind(category) == "series" {
+				it.Kind = "series"
+			}
+			it.Quality = library.QualityOr(library.DetectQuality(name, cfg.Library.QualityBuckets), cfg.Library.Defaults().DefaultQuality, cfg.Library.QualityBuckets)
 		}
+		out = append(out, it)
 	}
 	return out, nil
 }
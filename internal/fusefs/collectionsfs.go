@@ -0,0 +1,152 @@
+package fusefs
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sort"
+
+	"bazil.org/fuse"
+	"bazil.org/fuse/fs"
+)
+
+// CollectionsFS exposes each collection as a flat folder of its MKVs:
+//
+//	/<collection name>/<filename>.mkv
+//
+// Files are served by the manual library file node, so streaming behaves exactly
+// like library-manual.
+type CollectionsFS struct {
+	m *ManualFS
+}
+
+func (c *CollectionsFS) Root() (fs.Node, error) { return &collectionsRoot{fs: c.m}, nil }
+
+type collectionsRoot struct {
+	fs *ManualFS
+}
+
+func (n *collectionsRoot) Attr(ctx context.Context, a *fuse.Attr) error {
+	a.Mode = os.ModeDir | 0o555
+	return nil
+}
+
+func (n *collectionsRoot) children(ctx context.Context) ([]folderRow, error) {
+	rows, err := n.fs.Jobs.DB().SQL.QueryContext(ctx, `SELECT id, name FROM collections ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]folderRow, 0)
+	for rows.Next() {
+		var fr folderRow
+		if err := rows.Scan(&fr.ID, &fr.Name); err != nil {
+			continue
+		}
+		out = append(out, fr)
+	}
+	return out, nil
+}
+
+func (n *collectionsRoot) ReadDirAll(ctx context.Context) ([]fuse.Dirent, error) {
+	dirs, err := n.children(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]fuse.Dirent, 0, len(dirs))
+	for _, d := range dirs {
+		out = append(out, fuse.Dirent{Name: d.Name, Type: fuse.DT_Dir})
+	}
+	return out, nil
+}
+
+func (n *collectionsRoot) Lookup(ctx context.Context, name string) (fs.Node, error) {
+	dirs, err := n.children(ctx)
+	if err != nil {
+		return nil, fuse.ENOENT
+	}
+	for _, d := range dirs {
+		if d.Name == name {
+			return &collectionDir{fs: n.fs, id: d.ID}, nil
+		}
+	}
+	return nil, fuse.ENOENT
+}
+
+type collectionDir struct {
+	fs *ManualFS
+	id string
+}
+
+func (n *collectionDir) Attr(ctx context.Context, a *fuse.Attr) error {
+	a.Mode = os.ModeDir | 0o555
+	return nil
+}
+
+func (n *collectionDir) children(ctx context.Context) ([]itemRow, error) {
+	rows, err := n.fs.Jobs.DB().SQL.QueryContext(ctx, `
+		SELECT i.import_id, i.file_idx, f.total_bytes, f.filename
+		FROM collection_items i
+		JOIN nzb_files f ON f.import_id=i.import_id AND f.idx=i.file_idx
+		WHERE i.collection_id=?
+		  AND LOWER(COALESCE(f.filename, '')) LIKE '%.mkv'
+		ORDER BY f.filename, i.import_id, i.file_idx
+	`, n.id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]itemRow, 0)
+	seen := map[string]int{}
+	for rows.Next() {
+		var it itemRow
+		var fn sql.NullString
+		if err := rows.Scan(&it.ImportID, &it.FileIdx, &it.Bytes, &fn); err != nil {
+			continue
+		}
+		if fn.Valid {
+			it.RealName = fn.String
+		}
+		it.DispName = safeName(it.RealName)
+		if it.DispName == "" || it.DispName == "." {
+			it.DispName = fmt.Sprintf("file_%04d.bin", it.FileIdx)
+		}
+		seen[it.DispName]++
+		if seen[it.DispName] > 1 {
+			it.DispName = withSuffixBeforeExt(it.DispName, seen[it.DispName])
+		}
+		items = append(items, it)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].DispName < items[j].DispName })
+	return items, nil
+}
+
+func (n *collectionDir) ReadDirAll(ctx context.Context) ([]fuse.Dirent, error) {
+	items, err := n.children(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]fuse.Dirent, 0, len(items))
+	for _, it := range items {
+		out = append(out, fuse.Dirent{Name: it.DispName, Type: fuse.DT_File})
+	}
+	return out, nil
+}
+
+func (n *collectionDir) Lookup(ctx context.Context, name string) (fs.Node, error) {
+	items, err := n.children(ctx)
+	if err != nil {
+		return nil, fuse.ENOENT
+	}
+	for _, it := range items {
+		if it.DispName == name {
+			real := it.RealName
+			if real == "" {
+				real = it.DispName
+			}
+			return &manualFile{fs: n.fs, importID: it.ImportID, fileIdx: it.FileIdx, displayName: it.DispName, realName: real, size: it.Bytes}, nil
+		}
+	}
+	return nil, fuse.ENOENT
+}
@@ -88,6 +88,12 @@ func MountLibraryAuto(ctx context.Context, cfg config.Config, jobs *jobs.Store)
 	return Start(ctx, MountOptions{Mountpoint: mp, AllowOther: true}, lfs)
 }
 
+func MountCollections(ctx context.Context, cfg config.Config, jobs *jobs.Store) (*Mount, error) {
+	mp := filepath.Join(cfg.Paths.MountPoint, "collections")
+	cfs := &CollectionsFS{m: &ManualFS{Cfg: cfg, Jobs: jobs}}
+	return Start(ctx, MountOptions{Mountpoint: mp, AllowOther: true}, cfs)
+}
+
 func detachStaleMount(mp string) {
 	if strings.TrimSpace(mp) == "" {
 		return
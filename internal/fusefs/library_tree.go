@@ -0,0 +1,111 @@
+package fusefs
+
+import (
+	"context"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// libTree is the library-auto virtual tree: every exposed file with its virtual path.
+// Building it costs a full nzb_files scan plus per-file override/resolved lookups, so
+// it is cached on LibraryFS and shared by all directory reads.
+type libTree struct {
+	version uint64 // Jobs.LibraryVersion() when the build started
+	built   time.Time
+	entries []libEntry
+	arts    map[artKey]libArt // nil unless artwork is enabled
+}
+
+type libEntry struct {
+	path string // virtual path, no leading separator
+	row  libRow
+}
+
+// tree returns the cached virtual tree, rebuilding it after an import/override change
+// (Jobs.TouchLibrary) or once library.listing_cache_seconds has passed.
+func (r *LibraryFS) tree(ctx context.Context) (*libTree, error) {
+	ttl := time.Duration(r.Cfg.Library.Defaults().ListingCacheSeconds) * time.Second
+	ver := r.Jobs.LibraryVersion()
+
+	r.treeMu.Lock()
+	defer r.treeMu.Unlock()
+	if t := r.treeCache; t != nil && ttl > 0 && t.version == ver && time.Since(t.built) < ttl {
+		return t, nil
+	}
+
+	d := &libDir{fs: r}
+	rows, err := d.rows(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t := &libTree{version: ver, built: time.Now(), entries: make([]libEntry, 0, len(rows))}
+	hits0, misses0 := r.metaStats()
+	for _, row := range rows {
+		p := filepath.Clean(d.buildPath(ctx, row))
+		t.entries = append(t.entries, libEntry{path: strings.TrimPrefix(p, string(filepath.Separator)), row: row})
+	}
+	// Only rebuilds that had to query are worth a line; a warm cache is the steady state.
+	if hits, misses := r.metaStats(); misses > misses0 {
+		log.Printf("library-auto: tree rebuilt: %d files, metadata cache hits=%d misses=%d (%d queries)", len(rows), hits-hits0, misses-misses0, 2*(misses-misses0))
+	}
+	var meta map[artKey]libArt
+	if r.artworkEnabled() || r.Cfg.Library.MergeSeriesByTMDB {
+		meta = r.artworkMeta(ctx)
+	}
+	if r.Cfg.Library.MergeSeriesByTMDB {
+		mergeSeriesFolders(t.entries, meta)
+	}
+	if r.artworkEnabled() {
+		t.arts = meta
+	}
+	if ttl > 0 {
+		r.treeCache = t
+	}
+	return t, nil
+}
+
+// mergeSeriesFolders moves every episode of a TMDB show into one show folder. Imports
+// resolved with slightly different titles/years would otherwise render the same show
+// (and season) twice; the folder used by most episodes wins, ties go to the shorter name.
+func mergeSeriesFolders(entries []libEntry, meta map[artKey]libArt) {
+	type showRef struct {
+		i   int
+		dir string
+	}
+	byShow := map[int][]showRef{}
+	for i, e := range entries {
+		a, ok := meta[artKey{e.row.ImportID, e.row.Idx}]
+		if !ok || a.kind != "series" || a.tmdbID <= 0 {
+			continue
+		}
+		dir := artworkDir(a, e.path)
+		if dir == "" || dir == string(filepath.Separator) {
+			continue
+		}
+		byShow[a.tmdbID] = append(byShow[a.tmdbID], showRef{i: i, dir: dir})
+	}
+	for _, refs := range byShow {
+		counts := map[string]int{}
+		for _, ref := range refs {
+			counts[ref.dir]++
+		}
+		if len(counts) < 2 {
+			continue
+		}
+		best := ""
+		for dir, n := range counts {
+			if best == "" || n > counts[best] || (n == counts[best] && (len(dir) < len(best) || (len(dir) == len(best) && dir < best))) {
+				best = dir
+			}
+		}
+		for _, ref := range refs {
+			if ref.dir == best {
+				continue
+			}
+			rest := strings.TrimPrefix(entries[ref.i].path, ref.dir+string(filepath.Separator))
+			entries[ref.i].path = filepath.Join(best, rest)
+		}
+	}
+}
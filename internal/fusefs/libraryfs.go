@@ -18,6 +18,7 @@ import (
 	"bazil.org/fuse"
 	"bazil.org/fuse/fs"
 
+	"github.com/gaby/EDRmount/internal/cache"
 	"github.com/gaby/EDRmount/internal/config"
 	"github.com/gaby/EDRmount/internal/jobs"
 	"github.com/gaby/EDRmount/internal/library"
@@ -38,6 +39,16 @@ type LibraryFS struct {
 	resolver *library.Resolver
 	streamMu sync.Mutex
 	stream   *streamer.Streamer
+
+	posterFetch sync.Map // poster cache path -> time.Time of last fetch attempt
+
+	treeMu    sync.Mutex
+	treeCache *libTree
+
+	artMu    sync.Mutex
+	artCache *artScan // TMDB ids for sidecars and series merging
+
+	meta metaCache // per-file override/resolved rows
 }
 
 func (r *LibraryFS) Root() (fs.Node, error) {
@@ -52,6 +63,8 @@ func (r *LibraryFS) getStreamer() *streamer.Streamer {
 	defer r.streamMu.Unlock()
 	if r.stream == nil {
 		r.stream = streamer.New(r.Cfg.Download, r.Jobs, r.Cfg.Paths.CacheDir, r.Cfg.Paths.CacheMaxBytes)
+		r.stream.CompressCache = r.Cfg.Paths.CompressCache
+		r.stream.Eviction = cache.NewEviction(r.Cfg.Paths.CacheEviction, r.Cfg.Paths.CachePinnedImports)
 	}
 	return r.stream
 }
@@ -132,7 +145,12 @@ func (n *libFile) Read(ctx context.Context, req *fuse.ReadRequest, resp *fuse.Re
 	}
 	if err := st.StreamRange(ctx, n.importID, n.fileIdx, n.name, start, fetchEnd, buf, prefetch); err != nil {
 		if errors.Is(err, io.EOF) {
-			resp.Data = nil
+			// Missing tail: hand back what was read (uncached); the next read gets EOF.
+			part := buf.Bytes()
+			if need := (end - start) + 1; int64(len(part)) > need {
+				part = part[:need]
+			}
+			resp.Data = append([]byte(nil), part...)
 			return nil
 		}
 		log.Printf("fuse library read error import=%s fileIdx=%d: %v", n.importID, n.fileIdx, err)
@@ -173,20 +191,31 @@ type libRow struct {
 	Idx      int
 	Filename string
 	Bytes    int64
+	Category string // NZB <meta type="category">, a movie/series hint for the guess
+
+	Art *libArt // set for synthetic sidecar files (NFO/poster)
 }
 
 func (n *libDir) rows(ctx context.Context) ([]libRow, error) {
-	rows, err := n.fs.Jobs.DB().SQL.QueryContext(ctx, `SELECT import_id, idx, filename, subject, total_bytes FROM nzb_files ORDER BY import_id, idx LIMIT 5000`)
+	limit := n.fs.Cfg.Library.ListingLimit
+	q, args := listingQuery(`
+		SELECT f.import_id, f.idx, f.filename, f.subject, f.total_bytes, i.nzb_category
+		FROM nzb_files f JOIN nzb_imports i ON i.id=f.import_id
+		ORDER BY i.imported_at DESC, f.import_id, f.idx`, limit)
+	rows, err := n.fs.Jobs.DB().SQL.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	out := make([]libRow, 0)
+	seen := 0
+	defer func() { warnListingCap("library-auto", seen, limit) }()
 	for rows.Next() {
+		seen++
 		var r libRow
 		var subj string
 		var fn sql.NullString
-		if err := rows.Scan(&r.ImportID, &r.Idx, &fn, &subj, &r.Bytes); err != nil {
+		if err := rows.Scan(&r.ImportID, &r.Idx, &fn, &subj, &r.Bytes, &r.Category); err != nil {
 			continue
 		}
 		if fn.Valid && fn.String != "" {
@@ -209,40 +238,58 @@ func (n *libDir) rows(ctx context.Context) ([]libRow, error) {
 
 func (n *libDir) buildPath(ctx context.Context, row libRow) string {
 	l := n.fs.Cfg.Library.Defaults()
-	g := library.GuessFromFilename(row.Filename)
+	g := library.GuessWithCategory(row.Filename, row.Category)
+	g.Quality = library.DetectQuality(row.Filename, l.QualityBuckets)
 
 	// Overrides: allow manual correction while still exposing it in library-auto.
 	// (Plex can continue to point at library-auto.)
-	{
-		var kind, title, quality string
-		var year, tmdbID int
-		err := n.fs.Jobs.DB().SQL.QueryRowContext(ctx, `SELECT kind,title,year,quality,tmdb_id FROM library_overrides WHERE import_id=? AND file_idx=?`, row.ImportID, row.Idx).Scan(&kind, &title, &year, &quality, &tmdbID)
-		if err == nil {
-			kind = strings.TrimSpace(kind)
-			if kind == "" {
-				kind = "movie"
+	// Both rows come from the per-file LRU (see metaCache), not a query per listing.
+	meta := n.fs.fileMeta(ctx, row.ImportID, row.Idx)
+	seriesOverride := false
+	seriesOverrideTMDB := 0
+	if o := meta.override; o != nil {
+		kind, title, quality := o.kind, o.title, o.quality
+		year, tmdbID, season, episode := o.year, o.tmdbID, o.season, o.episode
+		kind = strings.TrimSpace(kind)
+		if kind == "" {
+			kind = "movie"
+		}
+		// Series overrides (season pack fixes) pin title/season/episode.
+		if kind == "series" {
+			seriesOverride = true
+			seriesOverrideTMDB = tmdbID
+			g.IsSeries = true
+			if strings.TrimSpace(title) != "" {
+				g.Title = strings.TrimSpace(title)
 			}
-			// For now, implement movie overrides (tv reserved).
-			if kind == "movie" {
-				if strings.TrimSpace(title) != "" {
-					g.Title = strings.TrimSpace(title)
-				}
-				if year > 0 {
-					g.Year = year
-				}
-				if strings.TrimSpace(quality) != "" {
-					g.Quality = strings.TrimSpace(quality)
-				}
-				// store tmdb id in a local var via vars below
-				// (we still try to resolve if tmdbID==0 to enrich titles, but it's optional)
-				varsTMDBOverride := tmdbID
-				_ = varsTMDBOverride
+			if year > 0 {
+				g.Year = year
 			}
+			if strings.TrimSpace(quality) != "" {
+				g.Quality = strings.TrimSpace(quality)
+			}
+			g.Season = season
+			g.Episode = episode
+		}
+		if kind == "movie" {
+			if strings.TrimSpace(title) != "" {
+				g.Title = strings.TrimSpace(title)
+			}
+			if year > 0 {
+				g.Year = year
+			}
+			if strings.TrimSpace(quality) != "" {
+				g.Quality = strings.TrimSpace(quality)
+			}
+			// store tmdb id in a local var via vars below
+			// (we still try to resolve if tmdbID==0 to enrich titles, but it's optional)
+			varsTMDBOverride := tmdbID
+			_ = varsTMDBOverride
 		}
 	}
 
-	initial := library.InitialFolder(g.Title)
-	quality := g.Quality
+	initial := library.BucketFolder(l.BucketScheme, g.Title, 0)
+	quality := library.QualityOr(g.Quality, l.DefaultQuality, l.QualityBuckets)
 	ext := g.Ext
 	if ext == "" {
 		ext = filepath.Ext(row.Filename)
@@ -271,14 +318,27 @@ func (n *libDir) buildPath(ctx context.Context, row libRow) string {
 	{
 		var kind, title, q, status, epTitle, virtualPath string
 		var y, tmdbID, season, episode int
-		err := n.fs.Jobs.DB().SQL.QueryRowContext(ctx, `SELECT kind,title,year,quality,tmdb_id,series_status,season,episode,episode_title,virtual_path FROM library_resolved WHERE import_id=? AND file_idx=?`, row.ImportID, row.Idx).Scan(&kind, &title, &y, &q, &tmdbID, &status, &season, &episode, &epTitle, &virtualPath)
-		if err == nil {
+		var err error = sql.ErrNoRows
+		if res := meta.resolved; res != nil {
+			kind, title, q, status, epTitle, virtualPath = res.kind, res.title, res.quality, res.status, res.epTitle, res.virtualPath
+			y, tmdbID, season, episode = res.year, res.tmdbID, res.season, res.episode
+			err = nil
+		}
+		if err == nil && seriesOverride {
+			// Keep the override's title/season/episode; only borrow presentation fields.
+			if strings.TrimSpace(epTitle) != "" && season == g.Season && episode == g.Episode {
+				vars["episode_title"] = epTitle
+			}
+			if strings.TrimSpace(status) != "" {
+				vars["series_status"] = status
+			}
+			if seriesOverrideTMDB <= 0 && strings.EqualFold(kind, "series") {
+				seriesOverrideTMDB = tmdbID
+			}
+		} else if err == nil {
 			if strings.TrimSpace(virtualPath) != "" {
 				vp := library.CleanPath(virtualPath)
-				if n.fs.Cfg.Library.Defaults().UppercaseFolders {
-					vp = library.ApplyUppercaseFolders(vp)
-				}
-				return vp
+				return library.UppercaseFolders(l, vp)
 			}
 			if strings.TrimSpace(title) != "" {
 				g.Title = title
@@ -313,7 +373,9 @@ func (n *libDir) buildPath(ctx context.Context, row libRow) string {
 		movieTitle := g.Title
 		tmdbID := 0
 		// Respect explicit override tmdb_id/title/year if present.
-		_ = n.fs.Jobs.DB().SQL.QueryRowContext(ctx, `SELECT tmdb_id,title,year FROM library_overrides WHERE import_id=? AND file_idx=?`, row.ImportID, row.Idx).Scan(&tmdbID, &movieTitle, &year)
+		if o := meta.override; o != nil {
+			tmdbID, movieTitle, year = o.tmdbID, o.title, o.year
+		}
 		if strings.TrimSpace(movieTitle) == "" {
 			movieTitle = g.Title
 		}
@@ -326,14 +388,14 @@ func (n *libDir) buildPath(ctx context.Context, row libRow) string {
 		}
 		vars["title"] = movieTitle
 		vars["tmdb_id"] = fmt.Sprintf("%d", tmdbID)
+		vars["initial"] = library.BucketFolder(l.BucketScheme, g.Title, year)
+		library.SanitizeVars(l.TitleSanitize, vars)
 
 		dir := library.CleanPath(library.Render(l.MovieDirTemplate, vars, nums))
 		file := library.CleanPath(library.Render(l.MovieFileTemplate, vars, nums))
+		file = library.WithVersion(file, library.LibraryVersion(l, row.Filename))
 		p := filepath.Join(dir, file)
-		if l.UppercaseFolders {
-			p = library.ApplyUppercaseFolders(p)
-		}
-		return p
+		return library.UppercaseFolders(l, p)
 	}
 
 	// Series (fast path): avoid external resolvers on each directory listing.
@@ -347,19 +409,22 @@ func (n *libDir) buildPath(ctx context.Context, row libRow) string {
 		vars["episode_title"] = "Episode"
 	}
 	vars["series"] = seriesName
+	if seriesOverride {
+		seriesTMDB = seriesOverrideTMDB
+		vars["tmdb_id"] = fmt.Sprintf("%d", seriesTMDB)
+	}
 	if vars["tmdb_id"] == "" {
 		vars["tmdb_id"] = fmt.Sprintf("%d", seriesTMDB)
 	}
 	vars["series_status"] = bucket
+	library.SanitizeVars(l.TitleSanitize, vars)
 
 	baseDir := library.CleanPath(library.Render(l.SeriesDirTemplate, vars, nums))
-	seasonDirName := library.CleanPath(library.Render(l.SeasonFolderTemplate, vars, nums))
+	seasonDirName := library.CleanPath(library.Render(library.SeasonFolderTemplate(l, nums["season"]), vars, nums))
 	file := library.CleanPath(library.Render(l.SeriesFileTemplate, vars, nums))
+	file = library.WithVersion(file, library.LibraryVersion(l, row.Filename))
 	p := filepath.Join(baseDir, seasonDirName, file)
-	if l.UppercaseFolders {
-		p = library.ApplyUppercaseFolders(p)
-	}
-	return p
+	return library.UppercaseFolders(l, p)
 }
 
 func maxInt(a, b int) int {
@@ -377,17 +442,24 @@ func ctxbg(ctx context.Context) context.Context {
 }
 
 func (n *libDir) children(ctx context.Context) (dirs []string, files map[string]libRow, err error) {
-	rows, err := n.rows(ctx)
+	tree, err := n.fs.tree(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
 	prefix := strings.Trim(n.rel, string(filepath.Separator))
 	files = map[string]libRow{}
+	leaves := map[string][]libRow{}
 	seenDir := map[string]bool{}
-
-	for _, r := range rows {
-		p := filepath.Clean(n.buildPath(ctx, r))
-		p = strings.TrimPrefix(p, string(filepath.Separator))
+	arts := tree.arts
+	var artHere *libArt
+
+	for _, e := range tree.entries {
+		r, p := e.row, e.path
+		if artHere == nil && prefix != "" {
+			if a, ok := arts[artKey{r.ImportID, r.Idx}]; ok && artworkDir(a, p) == prefix {
+				artHere = &a
+			}
+		}
 
 		// match prefix
 		if prefix != "" {
@@ -407,7 +479,7 @@ func (n *libDir) children(ctx context.Context) (dirs []string, files map[string]
 		name := parts[0]
 		if len(parts) == 1 {
 			// file at this level
-			files[name] = r
+			leaves[name] = append(leaves[name], r)
 			continue
 		}
 		if !seenDir[name] {
@@ -416,6 +488,35 @@ func (n *libDir) children(ctx context.Context) (dirs []string, files map[string]
 		}
 	}
 	sort.Strings(dirs)
+
+	// Resolve virtual path collisions (same title imported twice, bad match) by
+	// appending [id8] before the extension instead of hiding one of them.
+	for name, rs := range leaves {
+		if len(rs) == 1 {
+			files[name] = rs[0]
+			continue
+		}
+		ext := filepath.Ext(name)
+		stem := strings.TrimSuffix(name, ext)
+		for _, r := range rs {
+			sfx := r.ImportID
+			if len(sfx) > 8 {
+				sfx = sfx[:8]
+			}
+			alt := fmt.Sprintf("%s [%s]%s", stem, sfx, ext)
+			if _, dup := files[alt]; dup {
+				alt = fmt.Sprintf("%s [%s-%d]%s", stem, sfx, r.Idx, ext)
+			}
+			files[alt] = r
+		}
+	}
+	if artHere != nil {
+		for _, name := range n.fs.artworkFiles(*artHere) {
+			if _, taken := files[name]; !taken {
+				files[name] = libRow{Filename: name, Art: artHere}
+			}
+		}
+	}
 	return dirs, files, nil
 }
 
@@ -453,6 +554,13 @@ func (n *libDir) Lookup(ctx context.Context, name string) (fs.Node, error) {
 			return &libDir{fs: n.fs, rel: rel}, nil
 		}
 	}
+	if r, ok := files[name]; ok && r.Art != nil {
+		f, err := n.fs.artworkFile(*r.Art, name)
+		if err != nil {
+			return nil, fuse.ENOENT
+		}
+		return f, nil
+	}
 	if r, ok := files[name]; ok {
 		return &libFile{fs: n.fs, importID: r.ImportID, fileIdx: r.Idx, name: r.Filename, size: r.Bytes}, nil
 	}
@@ -0,0 +1,32 @@
+package fusefs
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Directory listings are ordered newest import first, so when library.listing_limit
+// is set it is the oldest imports that drop out, never the recent ones.
+
+// listingQuery appends the library.listing_limit clause (if any) to q.
+func listingQuery(q string, limit int) (string, []any) {
+	if limit <= 0 {
+		return q, nil
+	}
+	return q + ` LIMIT ?`, []any{limit}
+}
+
+var listingCapWarned sync.Map // listing name -> time.Time of last warning
+
+// warnListingCap logs (at most hourly per listing) that a listing hit the limit.
+func warnListingCap(what string, n, limit int) {
+	if limit <= 0 || n < limit {
+		return
+	}
+	if v, ok := listingCapWarned.Load(what); ok && time.Since(v.(time.Time)) < time.Hour {
+		return
+	}
+	listingCapWarned.Store(what, time.Now())
+	log.Printf("fuse %s: listing truncated at library.listing_limit=%d; older imports are hidden", what, limit)
+}
@@ -18,6 +18,7 @@ import (
 	"bazil.org/fuse"
 	"bazil.org/fuse/fs"
 
+	"github.com/gaby/EDRmount/internal/cache"
 	"github.com/gaby/EDRmount/internal/config"
 	"github.com/gaby/EDRmount/internal/jobs"
 	"github.com/gaby/EDRmount/internal/streamer"
@@ -44,6 +45,8 @@ func (m *ManualFS) getStreamer() *streamer.Streamer {
 	defer m.streamMu.Unlock()
 	if m.stream == nil {
 		m.stream = streamer.New(m.Cfg.Download, m.Jobs, m.Cfg.Paths.CacheDir, m.Cfg.Paths.CacheMaxBytes)
+		m.stream.CompressCache = m.Cfg.Paths.CompressCache
+		m.stream.Eviction = cache.NewEviction(m.Cfg.Paths.CacheEviction, m.Cfg.Paths.CachePinnedImports)
 	}
 	return m.stream
 }
@@ -70,44 +73,38 @@ func (n *manualRawRoot) Attr(ctx context.Context, a *fuse.Attr) error {
 
 type manualImportPath struct {
 	ID  string
-	Rel string // relative path from NZB root to the NZB file
-}
-
-func (n *manualRawRoot) nzbRoot() string {
-	root := strings.TrimSpace(n.fs.Cfg.NgPost.OutputDir)
-	if root == "" {
-		root = "/host/inbox/nzb"
-	}
-	return filepath.Clean(root)
+	Rel string // relative path from its NZB output root to the NZB file
 }
 
 func (n *manualRawRoot) importPaths(ctx context.Context) ([]manualImportPath, error) {
 	if n.fs == nil || n.fs.Jobs == nil {
 		return nil, nil
 	}
-	rows, err := n.fs.Jobs.DB().SQL.QueryContext(ctx, `SELECT id, path FROM nzb_imports ORDER BY imported_at DESC LIMIT 5000`)
+	limit := n.fs.Cfg.Library.ListingLimit
+	q, args := listingQuery(`SELECT id, path FROM nzb_imports ORDER BY imported_at DESC`, limit)
+	rows, err := n.fs.Jobs.DB().SQL.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	root := n.nzbRoot()
 	out := make([]manualImportPath, 0)
+	seen := 0
+	defer func() { warnListingCap("library-manual raw", seen, limit) }()
 	for rows.Next() {
+		seen++
 		var id, p string
 		if err := rows.Scan(&id, &p); err != nil {
 			continue
 		}
+		// Relative to whichever output root (ngpost.output_dir or a category root) holds it;
+		// NZBs outside every root are left out of the RAW-like view.
 		p = filepath.Clean(p)
-		rel := ""
-		if p == root {
-			rel = ""
-		} else if strings.HasPrefix(p, root+string(filepath.Separator)) {
-			rel = strings.TrimPrefix(p, root+string(filepath.Separator))
-		} else {
-			// Not under the configured root; skip from the RAW-like view.
+		root, ok := n.fs.Cfg.NZBRootFor(p)
+		if !ok {
 			continue
 		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(p, root), string(filepath.Separator))
 		if strings.TrimSpace(rel) == "" {
 			continue
 		}
@@ -252,12 +249,15 @@ func (n *manualImportsDir) Attr(ctx context.Context, a *fuse.Attr) error {
 }
 
 func (n *manualImportsDir) ReadDirAll(ctx context.Context) ([]fuse.Dirent, error) {
-	rows, err := n.fs.Jobs.DB().SQL.QueryContext(ctx, `SELECT id FROM nzb_imports ORDER BY imported_at DESC LIMIT 500`)
+	limit := n.fs.Cfg.Library.ListingLimit
+	q, args := listingQuery(`SELECT id FROM nzb_imports ORDER BY imported_at DESC`, limit)
+	rows, err := n.fs.Jobs.DB().SQL.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	out := make([]fuse.Dirent, 0)
+	defer func() { warnListingCap("library-manual imports", len(out), limit) }()
 	for rows.Next() {
 		var id string
 		if err := rows.Scan(&id); err != nil {
@@ -567,7 +567,12 @@ func (n *manualFile) Read(ctx context.Context, req *fuse.ReadRequest, resp *fuse
 	}
 	if err := st.StreamRange(ctx, n.importID, n.fileIdx, n.realName, start, fetchEnd, buf, prefetch); err != nil {
 		if errors.Is(err, io.EOF) {
-			resp.Data = nil
+			// Missing tail: hand back what was read (uncached); the next read gets EOF.
+			part := buf.Bytes()
+			if need := (end - start) + 1; int64(len(part)) > need {
+				part = part[:need]
+			}
+			resp.Data = append([]byte(nil), part...)
 			return nil
 		}
 		log.Printf("fuse manual read error import=%s fileIdx=%d: %v", n.importID, n.fileIdx, err)
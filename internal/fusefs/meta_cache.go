@@ -0,0 +1,116 @@
+package fusefs
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// metaCacheSize bounds the per-file metadata LRU (entries, one per import file).
+const metaCacheSize = 20000
+
+type metaKey struct {
+	importID string
+	idx      int
+}
+
+type libOverride struct {
+	kind, title, quality string
+	year, tmdbID         int
+	season, episode      int
+}
+
+type libResolved struct {
+	kind, title, quality, status, epTitle, virtualPath string
+	year, tmdbID, season, episode                      int
+}
+
+// fileMeta is the library_overrides / library_resolved state of one file, as read by
+// libDir.buildPath.
+type fileMeta struct {
+	override *libOverride
+	resolved *libResolved
+}
+
+type metaEntry struct {
+	key     metaKey
+	meta    fileMeta
+	version uint64 // Jobs.LibraryVersion() the entry is known to be current for
+}
+
+// metaCache is an LRU of fileMeta. Entries are checked against Jobs.LibraryChangedSince,
+// so an override or enrich touching an import drops only that import's entries.
+type metaCache struct {
+	mu    sync.Mutex
+	ll    *list.List
+	items map[metaKey]*list.Element
+
+	hits, misses uint64
+}
+
+// fileMeta returns the override/resolved rows of a file, from the LRU when still current.
+func (r *LibraryFS) fileMeta(ctx context.Context, importID string, idx int) fileMeta {
+	c := &r.meta
+	key := metaKey{importID, idx}
+	ver := r.Jobs.LibraryVersion()
+
+	c.mu.Lock()
+	if c.items == nil {
+		c.items = map[metaKey]*list.Element{}
+		c.ll = list.New()
+	}
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*metaEntry)
+		if !r.Jobs.LibraryChangedSince(e.version, importID) {
+			e.version = ver
+			c.ll.MoveToFront(el)
+			c.hits++
+			m := e.meta
+			c.mu.Unlock()
+			return m
+		}
+		c.ll.Remove(el)
+		delete(c.items, key)
+	}
+	c.misses++
+	c.mu.Unlock()
+
+	m := r.loadFileMeta(ctx, importID, idx)
+	if ctx.Err() != nil {
+		return m
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.ll.Remove(el)
+	}
+	c.items[key] = c.ll.PushFront(&metaEntry{key: key, meta: m, version: ver})
+	for c.ll.Len() > metaCacheSize {
+		old := c.ll.Back()
+		c.ll.Remove(old)
+		delete(c.items, old.Value.(*metaEntry).key)
+	}
+	return m
+}
+
+func (r *LibraryFS) loadFileMeta(ctx context.Context, importID string, idx int) fileMeta {
+	var m fileMeta
+	db := r.Jobs.DB().SQL
+	var o libOverride
+	if err := db.QueryRowContext(ctx, `SELECT kind,title,year,quality,tmdb_id,season,episode FROM library_overrides WHERE import_id=? AND file_idx=?`, importID, idx).Scan(&o.kind, &o.title, &o.year, &o.quality, &o.tmdbID, &o.season, &o.episode); err == nil {
+		m.override = &o
+	}
+	var res libResolved
+	if err := db.QueryRowContext(ctx, `SELECT kind,title,year,quality,tmdb_id,series_status,season,episode,episode_title,virtual_path FROM library_resolved WHERE import_id=? AND file_idx=?`, importID, idx).Scan(&res.kind, &res.title, &res.year, &res.quality, &res.tmdbID, &res.status, &res.season, &res.episode, &res.epTitle, &res.virtualPath); err == nil {
+		m.resolved = &res
+	}
+	return m
+}
+
+// metaStats returns the LRU hit/miss counters (each miss costs two queries).
+func (r *LibraryFS) metaStats() (hits, misses uint64) {
+	r.meta.mu.Lock()
+	defer r.meta.mu.Unlock()
+	return r.meta.hits, r.meta.misses
+}
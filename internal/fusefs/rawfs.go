@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -16,6 +18,7 @@ import (
 	"bazil.org/fuse/fs"
 	"golang.org/x/sync/singleflight"
 
+	"github.com/gaby/EDRmount/internal/cache"
 	"github.com/gaby/EDRmount/internal/config"
 	"github.com/gaby/EDRmount/internal/jobs"
 	"github.com/gaby/EDRmount/internal/streamer"
@@ -41,6 +44,17 @@ func (c *chunkCache) key(importID string, fileIdx int, offset int64) string {
 	return fmt.Sprintf("%s:%d:%d", importID, fileIdx, offset)
 }
 
+// chunkImportID is the import id of a key (ids may contain ':', the rest does not).
+func chunkImportID(key string) string {
+	if i := strings.LastIndexByte(key, ':'); i >= 0 {
+		key = key[:i]
+	}
+	if i := strings.LastIndexByte(key, ':'); i >= 0 {
+		key = key[:i]
+	}
+	return key
+}
+
 func (c *chunkCache) get(importID string, fileIdx int, offset int64, size int) ([]byte, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -53,16 +67,25 @@ func (c *chunkCache) get(importID string, fileIdx int, offset int64, size int) (
 	return data[:size], true
 }
 
-func (c *chunkCache) set(importID string, fileIdx int, offset int64, data []byte) {
+func (c *chunkCache) set(importID string, fileIdx int, offset int64, data []byte, ev cache.Eviction) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	// Si estamos por encima del límite, limpiar entradas antiguas
 	if c.size+int64(len(data)) > c.maxSize && len(c.chunks) > 0 {
-		// Eliminar la mitad de las entradas (simple LRU aproximado)
-		for k, v := range c.chunks {
-			delete(c.chunks, k)
-			c.size -= int64(len(v))
+		// Eliminar la mitad de las entradas (simple LRU aproximado); los chunks de imports
+		// en reproducción o fijados (paths.cache_eviction=protect) solo si no queda otra.
+		for _, spare := range []bool{true, false} {
+			for k, v := range c.chunks {
+				if spare && ev.Protected(chunkImportID(k)) {
+					continue
+				}
+				delete(c.chunks, k)
+				c.size -= int64(len(v))
+				if c.size < c.maxSize/2 {
+					break
+				}
+			}
 			if c.size < c.maxSize/2 {
 				break
 			}
@@ -105,6 +128,8 @@ func (r *RawFS) getStreamer() *streamer.Streamer {
 	defer r.streamMu.Unlock()
 	if r.stream == nil {
 		r.stream = streamer.New(r.Cfg.Download, r.Jobs, r.Cfg.Paths.CacheDir, r.Cfg.Paths.CacheMaxBytes)
+		r.stream.CompressCache = r.Cfg.Paths.CompressCache
+		r.stream.Eviction = cache.NewEviction(r.Cfg.Paths.CacheEviction, r.Cfg.Paths.CachePinnedImports)
 	}
 	return r.stream
 }
@@ -135,12 +160,15 @@ func (n *rawImportsDir) Attr(ctx context.Context, a *fuse.Attr) error {
 }
 
 func (n *rawImportsDir) ReadDirAll(ctx context.Context) ([]fuse.Dirent, error) {
-	rows, err := n.fs.Jobs.DB().SQL.QueryContext(ctx, `SELECT id FROM nzb_imports ORDER BY imported_at DESC LIMIT 500`)
+	limit := n.fs.Cfg.Library.ListingLimit
+	q, args := listingQuery(`SELECT id FROM nzb_imports ORDER BY imported_at DESC`, limit)
+	rows, err := n.fs.Jobs.DB().SQL.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	out := make([]fuse.Dirent, 0)
+	defer func() { warnListingCap("raw imports", len(out), limit) }()
 	for rows.Next() {
 		var id string
 		if err := rows.Scan(&id); err != nil {
@@ -189,6 +217,7 @@ func (n *rawImportDir) listFiles(ctx context.Context) ([]fileEntry, error) {
 	defer rows.Close()
 	out := make([]fileEntry, 0)
 	seen := map[string]int{}
+	ex, _ := subject.NewExtractor(n.fs.Cfg.Subject.Patterns)
 	for rows.Next() {
 		var e fileEntry
 		var dbfn sql.NullString
@@ -199,7 +228,7 @@ func (n *rawImportDir) listFiles(ctx context.Context) ([]fileEntry, error) {
 		if dbfn.Valid {
 			base = dbfn.String
 		} else {
-			f2, ok := subject.FilenameFromSubject(e.Subject)
+			f2, _, ok := ex.Filename(e.Subject)
 			if ok {
 				base = f2
 			}
@@ -315,13 +344,17 @@ func (n *rawFile) Read(ctx context.Context, req *fuse.ReadRequest, resp *fuse.Re
 		buf := &bytes.Buffer{}
 		// Aumentar prefetch a 30 segmentos para mejor rendimiento
 		if err := st.StreamRange(ctx, n.importID, n.fileIdx, n.name, start, end, buf, 30); err != nil {
+			if errors.Is(err, io.EOF) {
+				// Missing tail: return the partial data without caching it.
+				return buf.Bytes(), nil
+			}
 			return nil, err
 		}
 		data := buf.Bytes()
 
 		// Guardar en caché
 		if len(data) > 0 {
-			globalChunkCache.set(n.importID, n.fileIdx, start, data)
+			globalChunkCache.set(n.importID, n.fileIdx, start, data, st.Eviction)
 		}
 		return data, nil
 	})
@@ -2,12 +2,19 @@ package importer
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gaby/EDRmount/internal/config"
@@ -21,6 +28,32 @@ import (
 
 type Importer struct {
 	jobs *jobs.Store
+
+	// SubjectPatterns are optional filename extraction regexes (config subject.patterns).
+	SubjectPatterns []string
+
+	// NZBRoots are stripped from an NZB path to seed the manual tree (watch dir, upload
+	// output dir). /host/inbox/nzb is always tried.
+	NZBRoots []string
+
+	// ContentDedupe is config import.content_dedupe: "allow" (or empty), "skip", "replace".
+	ContentDedupe string
+
+	// Limits guards against malformed NZBs (import.max_files_per_nzb / max_segments_per_file).
+	Limits nzb.Limits
+
+	// ReuseImportID stores the import under this id instead of the job id (a health
+	// repair replacing an import in place).
+	ReuseImportID string
+
+	// IncrementalCommit is config import.incremental_commit: commit files in batches so
+	// a large NZB shows up in the catalog while it is still being imported.
+	IncrementalCommit bool
+
+	// StreamBatchSegments is config import.stream_batch_segments: > 0 streams the NZB
+	// file by file instead of loading it whole, committing about this many segments per
+	// transaction. 0 keeps the parsed document and a single transaction.
+	StreamBatchSegments int
 }
 
 func New(j *jobs.Store) *Importer { return &Importer{jobs: j} }
@@ -32,19 +65,45 @@ func (i *Importer) ImportNZB(ctx context.Context, jobID string, path string) (fi
 	}
 	defer f.Close()
 
-	doc, err := nzb.Parse(f)
+	// Streaming reads the NZB twice rather than holding it: once for the totals and the
+	// content hash (only message-ids are kept), then file by file while inserting.
+	streaming := i.StreamBatchSegments > 0
+	var doc *nzb.NZB
+	var sum nzbSummary
+	if streaming {
+		sum, err = summarizeNZB(f, i.Limits)
+	} else if doc, err = nzb.ParseLimited(f, i.Limits); err == nil {
+		sum = summarizeDoc(doc)
+	}
 	if err != nil {
 		return 0, 0, err
 	}
-
-	files = len(doc.Files)
-	for _, nf := range doc.Files {
-		for _, s := range nf.Segments {
-			totalBytes += s.Bytes
+	files, totalBytes = sum.files, sum.bytes
+	eachFile := func(fn func(idx int, nf nzb.File) error) error {
+		if !streaming {
+			for idx, nf := range doc.Files {
+				if err := fn(idx, nf); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
 		}
+		idx := 0
+		_, err := nzb.Walk(f, i.Limits, func(nf nzb.File) error {
+			err := fn(idx, nf)
+			idx++
+			return err
+		})
+		return err
 	}
 
 	importID := jobID
+	if i.ReuseImportID != "" {
+		importID = i.ReuseImportID
+	}
 	if importID == "" {
 		importID = fmt.Sprintf("%d", time.Now().UnixNano())
 	}
@@ -57,52 +116,118 @@ func (i *Importer) ImportNZB(ctx context.Context, jobID string, path string) (fi
 	// Persist import summary + per-file rows
 	db := i.jobs.DB().SQL
 
+	// An incrementally committed import left 'partial' (the process died between
+	// batches) is dropped and imported again from scratch.
+	if err := i.dropStalePartialImports(ctx, path); err != nil {
+		return 0, 0, err
+	}
+
 	// Deduplicate by NZB path: if this path was already imported, skip creating a second import.
 	var existingID string
 	var existingFiles int
 	var existingBytes int64
-	if err := db.QueryRowContext(ctx, `SELECT id,files_count,total_bytes FROM nzb_imports WHERE path=? ORDER BY imported_at DESC LIMIT 1`, path).Scan(&existingID, &existingFiles, &existingBytes); err == nil {
+	if err := db.QueryRowContext(ctx, `SELECT id,files_count,total_bytes FROM nzb_imports WHERE path=? AND import_state='complete' ORDER BY imported_at DESC LIMIT 1`, path).Scan(&existingID, &existingFiles, &existingBytes); err == nil {
 		return existingFiles, existingBytes, nil
 	}
 
+	// Deduplicate by content: the same post saved under another NZB path. An NZB without
+	// message-ids has no fingerprint ("", like imports from before content_hash).
+	contentHash := sum.hash
+	if mode := strings.ToLower(strings.TrimSpace(i.ContentDedupe)); contentHash != "" && (mode == "skip" || mode == "replace") {
+		var otherPath string
+		err := db.QueryRowContext(ctx, `SELECT id,path,files_count,total_bytes FROM nzb_imports WHERE content_hash=? AND path<>? AND import_state='complete' ORDER BY imported_at DESC LIMIT 1`, contentHash, path).Scan(&existingID, &otherPath, &existingFiles, &existingBytes)
+		if err == nil {
+			if mode == "replace" {
+				if _, err := db.ExecContext(ctx, `UPDATE nzb_imports SET path=?, imported_at=? WHERE id=?`, path, time.Now().Unix(), existingID); err != nil {
+					return 0, 0, err
+				}
+				i.jobs.TouchLibrary(existingID)
+			}
+			if jobID != "" {
+				_ = i.jobs.AppendLog(ctx, jobID, fmt.Sprintf("same content as import %s (%s); content_dedupe=%s", existingID, otherPath, mode))
+			}
+			return existingFiles, existingBytes, nil
+		}
+	}
+
+	// With IncrementalCommit (every progress tick) or StreamBatchSegments (every batch)
+	// the files are committed in batches at file boundaries, so the catalog fills while a
+	// big NZB is still loading; a failed import is then removed again. A health
+	// replacement always stays atomic: it overwrites a live import. Until the last
+	// batch is in the row stays import_state='partial', so a crash in between is not
+	// mistaken for a finished import.
+	incremental := i.IncrementalCommit && i.ReuseImportID == ""
+	batchSegs := 0
+	if i.ReuseImportID == "" {
+		batchSegs = i.StreamBatchSegments
+	}
+	state := "complete"
+	if incremental || batchSegs > 0 {
+		state = "partial"
+	}
+	committed := false
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, 0, err
 	}
 	defer func() {
 		_ = tx.Rollback()
+		if committed && err != nil {
+			i.dropPartialImport(importID)
+		}
 	}()
 	now := time.Now().Unix()
-	_, err = tx.ExecContext(ctx, `INSERT OR REPLACE INTO nzb_imports(id,path,imported_at,files_count,total_bytes) VALUES(?,?,?,?,?)`,
-		importID, path, now, files, totalBytes)
+	head := &nzb.NZB{Head: sum.head}
+	category, password := head.Meta("category"), head.Meta("password")
+	_, err = tx.ExecContext(ctx, `INSERT OR REPLACE INTO nzb_imports(id,path,imported_at,files_count,total_bytes,content_hash,nzb_category,nzb_has_password,import_state) VALUES(?,?,?,?,?,?,?,?,?)`,
+		importID, path, now, files, totalBytes, contentHash, category, boolToInt(password != ""), state)
 	if err != nil {
 		return 0, 0, err
 	}
 
-	stmtFile, err := tx.PrepareContext(ctx, `INSERT OR REPLACE INTO nzb_files(import_id,idx,subject,filename,poster,date,groups_json,segments_count,total_bytes) VALUES(?,?,?,?,?,?,?,?,?)`)
-	if err != nil {
-		return 0, 0, err
+	var stmtFile, stmtSeg *sql.Stmt
+	prepare := func() error {
+		var err error
+		if stmtFile, err = tx.PrepareContext(ctx, `INSERT OR REPLACE INTO nzb_files(import_id,idx,subject,filename,poster,date,groups_json,segments_count,total_bytes) VALUES(?,?,?,?,?,?,?,?,?)`); err != nil {
+			return err
+		}
+		stmtSeg, err = tx.PrepareContext(ctx, `INSERT OR REPLACE INTO nzb_segments(import_id,file_idx,number,bytes,message_id) VALUES(?,?,?,?,?)`)
+		return err
 	}
-	defer stmtFile.Close()
-	stmtSeg, err := tx.PrepareContext(ctx, `INSERT OR REPLACE INTO nzb_segments(import_id,file_idx,number,bytes,message_id) VALUES(?,?,?,?,?)`)
-	if err != nil {
+	if err = prepare(); err != nil {
 		return 0, 0, err
 	}
-	defer stmtSeg.Close()
+	defer func() {
+		// Statements belong to the transaction of the current batch.
+		if stmtFile != nil {
+			_ = stmtFile.Close()
+		}
+		if stmtSeg != nil {
+			_ = stmtSeg.Close()
+		}
+	}()
+
+	progress := newImportProgress(files, sum.segs)
 
-	for idx, nf := range doc.Files {
+	// Invalid patterns are rejected by config validation; fall back to the heuristic if any slip through.
+	ex, _ := subject.NewExtractor(i.SubjectPatterns)
+	rarVolumes := 0
+	pending := 0 // segments inserted since the last commit
+	err = eachFile(func(idx int, nf nzb.File) error {
 		var fb int64
 		for _, s := range nf.Segments {
 			fb += s.Bytes
 		}
-		fn, ok := subject.FilenameFromSubject(nf.Subject)
+		fn, _, ok := ex.Filename(nf.Subject)
 		if !ok || fn == "" {
 			fn = fmt.Sprintf("file_%04d.bin", idx)
 		}
-		_, err := stmtFile.ExecContext(ctx,
-			importID, idx, nf.Subject, fn, nf.Poster, nf.Date, groupsToJSON(nf.Groups), len(nf.Segments), fb)
-		if err != nil {
-			return 0, 0, err
+		if isRarVolume(fn) {
+			rarVolumes++
+		}
+		if _, err := stmtFile.ExecContext(ctx,
+			importID, idx, nf.Subject, fn, nf.Poster, nf.Date, groupsToJSON(nf.Groups), len(nf.Segments), fb); err != nil {
+			return err
 		}
 
 		// segments
@@ -111,28 +236,121 @@ func (i *Importer) ImportNZB(ctx context.Context, jobID string, path string) (fi
 			if mid == "" {
 				continue
 			}
-			_, err := stmtSeg.ExecContext(ctx,
-				importID, idx, seg.Number, seg.Bytes, mid)
-			if err != nil {
-				return 0, 0, err
+			if _, err := stmtSeg.ExecContext(ctx,
+				importID, idx, seg.Number, seg.Bytes, mid); err != nil {
+				return err
 			}
 		}
+		pending += len(nf.Segments)
+
+		due := progress.fileDone(len(nf.Segments))
+		if due && jobID != "" {
+			_ = i.jobs.AppendLog(ctx, jobID, progress.line())
+		}
+		if idx >= files-1 || !((incremental && due) || (batchSegs > 0 && pending >= batchSegs)) {
+			return nil
+		}
+		_ = stmtFile.Close()
+		_ = stmtSeg.Close()
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		committed = true
+		pending = 0
+		i.jobs.TouchLibrary(importID)
+		next, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		tx = next
+		return prepare()
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	if streaming {
+		if files, totalBytes, err = reconcileImportCounts(ctx, tx, importID, files, totalBytes); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	// Archive releases (split RAR sets, possibly encrypted) can't be streamed; flag them for the catalog.
+	needsExtraction := rarVolumes > 0
+	if _, err = tx.ExecContext(ctx, `UPDATE nzb_imports SET needs_extraction=?, import_state='complete' WHERE id=?`, boolToInt(needsExtraction), importID); err != nil {
+		return 0, 0, err
 	}
 
 	// Seed Manual tree from NZB path (idempotent):
 	// /host/inbox/nzb/PELICULAS/1080/A/Avatar (2009).nzb ->
 	// root/PELICULAS/1080/A/Avatar (2009) + manual_items for file_idx
-	if err := seedManualFromNZB(ctx, tx, importID, path); err != nil {
+	if err = seedManualFromNZB(ctx, tx, importID, path, i.NZBRoots); err != nil {
 		return 0, 0, err
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err = tx.Commit(); err != nil {
 		return 0, 0, err
 	}
+	i.jobs.TouchLibrary(importID)
+	if progress.logged && jobID != "" {
+		_ = i.jobs.AppendLog(ctx, jobID, "PROGRESS: 100")
+	}
+	if needsExtraction && jobID != "" {
+		msg := fmt.Sprintf("WARN: archive release (%d RAR volume(s)); needs extraction, not streamable", rarVolumes)
+		if password != "" {
+			msg += "; NZB declares a password, archive is likely encrypted"
+		}
+		_ = i.jobs.AppendLog(ctx, jobID, msg)
+	} else if password != "" && jobID != "" {
+		_ = i.jobs.AppendLog(ctx, jobID, "WARN: NZB declares a password; content may be encrypted")
+	}
 	return files, totalBytes, nil
 }
 
-func seedManualFromNZB(ctx context.Context, tx *sql.Tx, importID, nzbPath string) error {
+// contentHasher fingerprints an NZB by the set of its message-ids, independent of file
+// name, subject or segment order, in constant memory: every id is hashed on its own and
+// the digests are added up (mod 2^256), so the order they arrive in does not matter.
+type contentHasher struct {
+	sum [sha256.Size]byte
+	n   uint64
+}
+
+func (c *contentHasher) add(messageID string) {
+	d := sha256.Sum256([]byte(messageID))
+	carry := 0
+	for i := len(c.sum) - 1; i >= 0; i-- {
+		v := int(c.sum[i]) + int(d[i]) + carry
+		c.sum[i], carry = byte(v), v>>8
+	}
+	c.n++
+}
+
+// hex returns the content hash stored in nzb_imports.content_hash, "" when no
+// message-id was added.
+func (c *contentHasher) hex() string {
+	if c.n == 0 {
+		return ""
+	}
+	h := sha256.New()
+	_, _ = h.Write(c.sum[:])
+	_ = binary.Write(h, binary.BigEndian, c.n)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+var reRarVolume = regexp.MustCompile(`(?i)(\.part\d+\.rar|\.rar|\.r\d{2,3})$`)
+
+// isRarVolume reports whether name looks like a RAR volume (.rar, .rNN, .partNN.rar).
+func isRarVolume(name string) bool {
+	return reRarVolume.MatchString(strings.TrimSpace(name))
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func seedManualFromNZB(ctx context.Context, tx *sql.Tx, importID, nzbPath string, roots []string) error {
 	// already seeded somewhere in manual tree
 	var exists int
 	_ = tx.QueryRowContext(ctx, `SELECT COUNT(1) FROM manual_items WHERE import_id=?`, importID).Scan(&exists)
@@ -140,8 +358,7 @@ func seedManualFromNZB(ctx context.Context, tx *sql.Tx, importID, nzbPath string
 		return nil
 	}
 
-	rel := strings.TrimPrefix(filepath.Clean(nzbPath), "/host/inbox/nzb/")
-	rel = strings.TrimPrefix(rel, "./")
+	rel := manualRelPath(nzbPath, roots)
 	if rel == "" || rel == "." {
 		return nil
 	}
@@ -164,7 +381,7 @@ func seedManualFromNZB(ctx context.Context, tx *sql.Tx, importID, nzbPath string
 			return id, nil
 		}
 		id = uuid.NewString()
-		if _, err := tx.ExecContext(ctx, `INSERT INTO manual_dirs(id,parent_id,name) VALUES(?,?,?)`, id, parent, name); err != nil {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO manual_dirs(id,parent_id,name,source) VALUES(?,?,?,'auto')`, id, parent, name); err != nil {
 			return "", err
 		}
 		return id, nil
@@ -201,6 +418,27 @@ func seedManualFromNZB(ctx context.Context, tx *sql.Tx, importID, nzbPath string
 	return nil
 }
 
+// manualRelPath returns nzbPath relative to the first matching NZB root. NZBs outside
+// every root (e.g. a flat upload layout in a custom output_dir) seed at the manual root.
+func manualRelPath(nzbPath string, roots []string) string {
+	p := filepath.Clean(nzbPath)
+	all := append(append([]string{}, roots...), "/host/inbox/nzb")
+	for _, root := range all {
+		root = strings.TrimSpace(root)
+		if root == "" {
+			continue
+		}
+		root = filepath.Clean(root)
+		if strings.HasPrefix(p, root+string(filepath.Separator)) {
+			return strings.TrimPrefix(p, root+string(filepath.Separator))
+		}
+	}
+	if filepath.IsAbs(p) {
+		return filepath.Base(p)
+	}
+	return strings.TrimPrefix(p, "./")
+}
+
 // EnrichLibraryResolvedByPath resolves/stores library metadata for fast FUSE path building.
 func (i *Importer) EnrichLibraryResolvedByPath(ctx context.Context, cfg config.Config, nzbPath string) error {
 	db := i.jobs.DB().SQL
@@ -211,16 +449,24 @@ func (i *Importer) EnrichLibraryResolvedByPath(ctx context.Context, cfg config.C
 	return i.EnrichLibraryResolved(ctx, cfg, importID)
 }
 
+// ErrTMDBUnavailable means TMDB lookups failed during enrichment; rows were written
+// from filename guesses and the import should be retried later.
+var ErrTMDBUnavailable = errors.New("tmdb unavailable; resolved from filename guesses")
+
 func (i *Importer) EnrichLibraryResolved(ctx context.Context, cfg config.Config, importID string) error {
 	db := i.jobs.DB().SQL
+	// NZB <head> category (if any) disambiguates movie vs episode filenames.
+	var category string
+	_ = db.QueryRowContext(ctx, `SELECT nzb_category FROM nzb_imports WHERE id=?`, importID).Scan(&category)
 	rows, err := db.QueryContext(ctx, `SELECT idx, COALESCE(filename,''), subject FROM nzb_files WHERE import_id=? ORDER BY idx`, importID)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
-	res := library.NewResolver(cfg)
-	l := cfg.Library.Defaults()
-	now := time.Now().Unix()
+	type enrichFile struct {
+		idx  int
+		name string
+	}
+	var files []enrichFile
 	for rows.Next() {
 		var idx int
 		var fn, subj string
@@ -231,142 +477,222 @@ func (i *Importer) EnrichLibraryResolved(ctx context.Context, cfg config.Config,
 		if name == "" {
 			name = filepath.Base(subj)
 		}
-		fileCtx, cancel := context.WithTimeout(ctx, 12*time.Second)
-		g := library.GuessFromFilename(name)
-		fbTMDB := 0
-		if fb, ok := library.ResolveWithFileBot(fileCtx, cfg, name); ok {
-			if strings.TrimSpace(fb.Title) != "" {
-				g.Title = fb.Title
-			}
-			if fb.Year > 0 {
-				g.Year = fb.Year
+		files = append(files, enrichFile{idx: idx, name: name})
+	}
+	err = rows.Err()
+	_ = rows.Close()
+	if err != nil {
+		return err
+	}
+	defer i.jobs.TouchLibrary(importID)
+	res := library.NewResolver(cfg)
+	l := cfg.Library.Defaults()
+	now := time.Now().Unix()
+
+	// Files are resolved by metadata.enrich_concurrency workers sharing res (and its
+	// caches); the upserts are serialized. Only the lookup is bounded per file: the write
+	// runs on ctx, so time spent resolving or queued on writeMu never cancels it.
+	var writeMu sync.Mutex
+	var writeErr error
+	writeFailed := 0
+	work := make(chan enrichFile)
+	var wg sync.WaitGroup
+	for w := 0; w < cfg.Metadata.EnrichWorkers(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range work {
+				fileCtx, cancel := context.WithTimeout(ctx, 12*time.Second)
+				rf := resolveLibraryFile(fileCtx, cfg, res, l, category, f.name)
+				cancel()
+				writeMu.Lock()
+				_, err := db.ExecContext(ctx, `
+					INSERT INTO library_resolved(import_id,file_idx,kind,title,year,quality,tmdb_id,series_status,season,episode,episode_title,virtual_dir,virtual_name,virtual_path,updated_at)
+					VALUES(?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)
+					ON CONFLICT(import_id,file_idx) DO UPDATE SET
+					  kind=excluded.kind,
+					  title=excluded.title,
+					  year=excluded.year,
+					  quality=excluded.quality,
+					  tmdb_id=excluded.tmdb_id,
+					  series_status=excluded.series_status,
+					  season=excluded.season,
+					  episode=excluded.episode,
+					  episode_title=excluded.episode_title,
+					  virtual_dir=excluded.virtual_dir,
+					  virtual_name=excluded.virtual_name,
+					  virtual_path=excluded.virtual_path,
+					  updated_at=excluded.updated_at
+				`, importID, f.idx, rf.kind, rf.title, rf.year, rf.quality, rf.tmdbID, rf.seriesStatus, rf.season, rf.episode, rf.episodeTitle, rf.virtualDir, rf.virtualName, rf.virtualPath, now)
+				if err != nil && ctx.Err() == nil {
+					writeFailed++
+					if writeErr == nil {
+						writeErr = err
+					}
+				}
+				writeMu.Unlock()
 			}
-			if fb.TMDB > 0 {
-				fbTMDB = fb.TMDB
+		}()
+	}
+feed:
+	for _, f := range files {
+		select {
+		case work <- f:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	var errs []error
+	if writeErr != nil {
+		errs = append(errs, fmt.Errorf("library_resolved: %d of %d file(s) not written: %w", writeFailed, len(files), writeErr))
+	}
+	if res.Unavailable() {
+		errs = append(errs, ErrTMDBUnavailable)
+	}
+	return errors.Join(errs...)
+}
+
+// resolvedFile is the library_resolved row of one file.
+type resolvedFile struct {
+	kind, title, quality, seriesStatus, episodeTitle string
+	year, tmdbID, season, episode                    int
+	virtualDir, virtualName, virtualPath             string
+}
+
+// resolveLibraryFile guesses name, resolves it with FileBot/TMDB and renders its
+// library-auto path.
+func resolveLibraryFile(fileCtx context.Context, cfg config.Config, res *library.Resolver, l config.Library, category, name string) resolvedFile {
+	g := library.GuessWithCategory(name, category)
+	// An episode without a season ("E05") in a series category is a special, once TMDB
+	// knows the show.
+	if !g.IsSeries && library.CategoryKind(category) == "series" {
+		if sg, ok := library.GuessSpecial(name); ok {
+			if _, ok := res.ResolveTV(fileCtx, sg.Title, sg.Year); ok {
+				g = sg
 			}
 		}
-		kind := "movie"
-		title := g.Title
-		year := g.Year
-		quality := g.Quality
-		tmdbID := 0
-		seriesStatus := l.EmisionFolder
-		season := g.Season
-		episode := g.Episode
-		episodeTitle := "Episode"
-		if g.IsSeries {
-			kind = "series"
-			if fbTMDB > 0 {
-				tmdbID = fbTMDB
+	}
+	fbTMDB := 0
+	if fb, ok := library.ResolveWithFileBot(fileCtx, cfg, name); ok {
+		if strings.TrimSpace(fb.Title) != "" {
+			g.Title = fb.Title
+		}
+		if fb.Year > 0 {
+			g.Year = fb.Year
+		}
+		if fb.TMDB > 0 {
+			fbTMDB = fb.TMDB
+		}
+	}
+	kind := "movie"
+	title := g.Title
+	year := g.Year
+	quality := library.QualityOr(library.DetectQuality(name, l.QualityBuckets), l.DefaultQuality, l.QualityBuckets)
+	tmdbID := 0
+	seriesStatus := l.EmisionFolder
+	season := g.Season
+	episode := g.Episode
+	episodeTitle := "Episode"
+	if g.IsSeries {
+		kind = "series"
+		if fbTMDB > 0 {
+			tmdbID = fbTMDB
+		}
+		if tv, ok := res.ResolveTV(fileCtx, title, year); ok {
+			if strings.TrimSpace(tv.Name) != "" {
+				title = tv.Name
 			}
-			if tv, ok := res.ResolveTV(fileCtx, title, year); ok {
-				if strings.TrimSpace(tv.Name) != "" {
-					title = tv.Name
-				}
-				if y := tv.FirstAirYear(); y > 0 {
-					year = y
-				}
-				tmdbID = tv.ID
-				b := tmdb.MapTVStatusToBucket(tv.Status)
-				if b == tmdb.SeriesBucketFinalizada {
-					seriesStatus = l.FinalizadasFolder
-				} else {
-					seriesStatus = l.EmisionFolder
-				}
-				if season > 0 && episode > 0 {
-					if ep, ok := res.ResolveEpisodeTitle(fileCtx, tv.ID, season, episode); ok && strings.TrimSpace(ep) != "" {
-						episodeTitle = ep
-					}
-				}
+			if y := tv.FirstAirYear(); y > 0 {
+				year = y
 			}
-		} else {
-			if fbTMDB > 0 {
-				tmdbID = fbTMDB
+			tmdbID = tv.ID
+			b := tmdb.MapTVStatusToBucket(tv.Status)
+			if b == tmdb.SeriesBucketFinalizada {
+				seriesStatus = l.FinalizadasFolder
+			} else {
+				seriesStatus = l.EmisionFolder
 			}
-			if mv, ok := res.ResolveMovie(fileCtx, title, year); ok {
-				if strings.TrimSpace(mv.Title) != "" {
-					title = mv.Title
-				}
-				if y := mv.ReleaseYear(); y > 0 {
-					year = y
+			if season > 0 && episode > 0 {
+				if ep, ok := res.ResolveEpisodeTitle(fileCtx, tv.ID, season, episode); ok && strings.TrimSpace(ep) != "" {
+					episodeTitle = ep
 				}
-				tmdbID = mv.ID
 			}
 		}
-		if strings.TrimSpace(title) == "" {
-			title = g.Title
-		}
-		if strings.TrimSpace(quality) == "" {
-			quality = g.Quality
-		}
-		if strings.TrimSpace(episodeTitle) == "" {
-			episodeTitle = "Episode"
-		}
-
-		ext := g.Ext
-		if ext == "" {
-			ext = filepath.Ext(name)
-		}
-		initial := library.InitialFolder(title)
-		if initial == "" {
-			initial = library.InitialFolder(g.Title)
-		}
-		vars := map[string]string{
-			"movies_root":        l.MoviesRoot,
-			"series_root":        l.SeriesRoot,
-			"emision_folder":     l.EmisionFolder,
-			"finalizadas_folder": l.FinalizadasFolder,
-			"quality":            quality,
-			"initial":            initial,
-			"ext":                ext,
-			"title":              title,
-			"tmdb_id":            fmt.Sprintf("%d", tmdbID),
-			"series":             title,
-			"series_status":      seriesStatus,
-			"episode_title":      episodeTitle,
-		}
-		nums := map[string]int{"year": year, "season": season, "episode": episode}
-		virtualDir := ""
-		virtualName := ""
-		virtualPath := ""
-		if kind == "series" {
-			baseDir := library.CleanPath(library.Render(l.SeriesDirTemplate, vars, nums))
-			seasonDirName := library.CleanPath(library.Render(l.SeasonFolderTemplate, vars, nums))
-			virtualDir = filepath.Join(baseDir, seasonDirName)
-			virtualName = library.CleanPath(library.Render(l.SeriesFileTemplate, vars, nums))
-		} else {
-			virtualDir = library.CleanPath(library.Render(l.MovieDirTemplate, vars, nums))
-			virtualName = library.CleanPath(library.Render(l.MovieFileTemplate, vars, nums))
-		}
-		virtualPath = filepath.Join(virtualDir, virtualName)
-		if l.UppercaseFolders {
-			virtualPath = library.ApplyUppercaseFolders(virtualPath)
-			virtualDir = filepath.Dir(virtualPath)
-			virtualName = filepath.Base(virtualPath)
-		}
-
-		if _, err := db.ExecContext(fileCtx, `
-			INSERT INTO library_resolved(import_id,file_idx,kind,title,year,quality,tmdb_id,series_status,season,episode,episode_title,virtual_dir,virtual_name,virtual_path,updated_at)
-			VALUES(?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)
-			ON CONFLICT(import_id,file_idx) DO UPDATE SET
-			  kind=excluded.kind,
-			  title=excluded.title,
-			  year=excluded.year,
-			  quality=excluded.quality,
-			  tmdb_id=excluded.tmdb_id,
-			  series_status=excluded.series_status,
-			  season=excluded.season,
-			  episode=excluded.episode,
-			  episode_title=excluded.episode_title,
-			  virtual_dir=excluded.virtual_dir,
-			  virtual_name=excluded.virtual_name,
-			  virtual_path=excluded.virtual_path,
-			  updated_at=excluded.updated_at
-		`, importID, idx, kind, title, year, quality, tmdbID, seriesStatus, season, episode, episodeTitle, virtualDir, virtualName, virtualPath, now); err != nil {
-			cancel()
-			continue
+	} else {
+		if fbTMDB > 0 {
+			tmdbID = fbTMDB
+		}
+		if mv, ok := res.ResolveMovie(fileCtx, title, year); ok {
+			if strings.TrimSpace(mv.Title) != "" {
+				title = mv.Title
+			}
+			if y := mv.ReleaseYear(); y > 0 {
+				year = y
+			}
+			tmdbID = mv.ID
 		}
-		cancel()
 	}
-	return nil
+	if strings.TrimSpace(title) == "" {
+		title = g.Title
+	}
+	if strings.TrimSpace(episodeTitle) == "" {
+		episodeTitle = "Episode"
+	}
+
+	ext := g.Ext
+	if ext == "" {
+		ext = filepath.Ext(name)
+	}
+	bucketYear := year
+	if kind == "series" {
+		bucketYear = 0
+	}
+	initial := library.BucketFolder(l.BucketScheme, title, bucketYear)
+	vars := map[string]string{
+		"movies_root":        l.MoviesRoot,
+		"series_root":        l.SeriesRoot,
+		"emision_folder":     l.EmisionFolder,
+		"finalizadas_folder": l.FinalizadasFolder,
+		"quality":            quality,
+		"initial":            initial,
+		"ext":                ext,
+		"title":              title,
+		"tmdb_id":            fmt.Sprintf("%d", tmdbID),
+		"series":             title,
+		"series_status":      seriesStatus,
+		"episode_title":      episodeTitle,
+	}
+	library.SanitizeVars(l.TitleSanitize, vars)
+	nums := map[string]int{"year": year, "season": season, "episode": episode}
+	virtualDir := ""
+	virtualName := ""
+	virtualPath := ""
+	if kind == "series" {
+		baseDir := library.CleanPath(library.Render(l.SeriesDirTemplate, vars, nums))
+		seasonDirName := library.CleanPath(library.Render(library.SeasonFolderTemplate(l, season), vars, nums))
+		virtualDir = filepath.Join(baseDir, seasonDirName)
+		virtualName = library.CleanPath(library.Render(l.SeriesFileTemplate, vars, nums))
+	} else {
+		virtualDir = library.CleanPath(library.Render(l.MovieDirTemplate, vars, nums))
+		virtualName = library.CleanPath(library.Render(l.MovieFileTemplate, vars, nums))
+	}
+	virtualName = library.WithVersion(virtualName, library.LibraryVersion(l, name))
+	virtualPath = filepath.Join(virtualDir, virtualName)
+	if l.UppercaseFolders {
+		virtualPath = library.UppercaseFolders(l, virtualPath)
+		virtualDir = filepath.Dir(virtualPath)
+		virtualName = filepath.Base(virtualPath)
+	}
+
+	return resolvedFile{
+		kind: kind, title: title, year: year, quality: quality, tmdbID: tmdbID,
+		seriesStatus: seriesStatus, season: season, episode: episode, episodeTitle: episodeTitle,
+		virtualDir: virtualDir, virtualName: virtualName, virtualPath: virtualPath,
+	}
 }
@@ -0,0 +1,92 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// importProgressEvery is how often a running import logs its progress. Small NZBs
+// finish before the first tick and log nothing.
+const importProgressEvery = 2 * time.Second
+
+// importProgress counts inserted files/segments for the job log, so a big NZB (tens of
+// thousands of segments in one transaction) does not look hung.
+type importProgress struct {
+	files, segs         int
+	doneFiles, doneSegs int
+	next                time.Time
+	logged              bool
+}
+
+func newImportProgress(files, segs int) *importProgress {
+	return &importProgress{files: files, segs: segs, next: time.Now().Add(importProgressEvery)}
+}
+
+// fileDone records a file with segs segments and reports whether a progress line is due.
+func (p *importProgress) fileDone(segs int) bool {
+	p.doneFiles++
+	p.doneSegs += segs
+	if time.Now().Before(p.next) {
+		return false
+	}
+	p.next = time.Now().Add(importProgressEvery)
+	p.logged = true
+	return true
+}
+
+// line is the PROGRESS log line (percent by segments, as for uploads) with the counts.
+func (p *importProgress) line() string {
+	pct := 0
+	if p.segs > 0 {
+		pct = p.doneSegs * 100 / p.segs
+	}
+	return fmt.Sprintf("PROGRESS: %d (files %d/%d, segments %d/%d)", min(pct, 99), p.doneFiles, p.files, p.doneSegs, p.segs)
+}
+
+// dropPartialImport removes the rows an incremental import already committed when it
+// fails later, so the path is not considered imported and the next attempt starts over.
+func (i *Importer) dropPartialImport(importID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	db := i.jobs.DB().SQL
+	for _, q := range []string{
+		`DELETE FROM nzb_segments WHERE import_id=?`,
+		`DELETE FROM nzb_files WHERE import_id=?`,
+		`DELETE FROM nzb_imports WHERE id=?`,
+	} {
+		if _, err := db.ExecContext(ctx, q, importID); err != nil {
+			log.Printf("import %s: cleanup of partial import failed: %v", importID, err)
+			return
+		}
+	}
+	i.jobs.TouchLibrary(importID)
+}
+
+// dropStalePartialImports removes imports of path still marked 'partial': an
+// incremental import whose process died between batches never reached its final commit.
+func (i *Importer) dropStalePartialImports(ctx context.Context, path string) error {
+	rows, err := i.jobs.DB().SQL.QueryContext(ctx, `SELECT id FROM nzb_imports WHERE path=? AND import_state='partial'`, path)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		log.Printf("import %s: dropping unfinished incremental import of %s", id, path)
+		i.dropPartialImport(id)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/gaby/EDRmount/internal/nzb"
+)
+
+// nzbSummary is what ImportNZB needs to know about an NZB before inserting it.
+type nzbSummary struct {
+	files, segs int
+	bytes       int64
+	hash        string // contentHasher
+	head        []nzb.Meta
+}
+
+func summarizeDoc(doc *nzb.NZB) nzbSummary {
+	sum := nzbSummary{files: len(doc.Files), head: doc.Head}
+	var ch contentHasher
+	for _, nf := range doc.Files {
+		sum.segs += len(nf.Segments)
+		for _, seg := range nf.Segments {
+			sum.bytes += seg.Bytes
+			if mid := strings.TrimSpace(seg.ID); mid != "" {
+				ch.add(mid)
+			}
+		}
+	}
+	sum.hash = ch.hex()
+	return sum
+}
+
+// summarizeNZB is summarizeDoc for a streamed NZB; nothing per segment is kept.
+func summarizeNZB(r io.Reader, lim nzb.Limits) (nzbSummary, error) {
+	var sum nzbSummary
+	var ch contentHasher
+	head, err := nzb.Walk(r, lim, func(nf nzb.File) error {
+		sum.files++
+		sum.segs += len(nf.Segments)
+		for _, seg := range nf.Segments {
+			sum.bytes += seg.Bytes
+			if mid := strings.TrimSpace(seg.ID); mid != "" {
+				ch.add(mid)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return sum, err
+	}
+	sum.head = head
+	sum.hash = ch.hex()
+	return sum, nil
+}
+
+// reconcileImportCounts sets files_count/total_bytes of a streamed import to what was
+// actually stored: the NZB is read twice and could have changed in between.
+func reconcileImportCounts(ctx context.Context, tx *sql.Tx, importID string, files int, totalBytes int64) (int, int64, error) {
+	var gotFiles int
+	var gotBytes int64
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(1), COALESCE(SUM(total_bytes),0) FROM nzb_files WHERE import_id=?`, importID).Scan(&gotFiles, &gotBytes); err != nil {
+		return 0, 0, err
+	}
+	if gotFiles == files && gotBytes == totalBytes {
+		return files, totalBytes, nil
+	}
+	log.Printf("import %s: NZB changed while streaming (files %d->%d, bytes %d->%d), counts reconciled", importID, files, gotFiles, totalBytes, gotBytes)
+	if _, err := tx.ExecContext(ctx, `UPDATE nzb_imports SET files_count=?, total_bytes=? WHERE id=?`, gotFiles, gotBytes, importID); err != nil {
+		return 0, 0, fmt.Errorf("reconcile import counts: %w", err)
+	}
+	return gotFiles, gotBytes, nil
+}
@@ -0,0 +1,86 @@
+package jobs
+
+import (
+	"context"
+	"time"
+)
+
+// BackupRun is one backup attempt.
+type BackupRun struct {
+	ID         int64  `json:"id"`
+	Trigger    string `json:"trigger"`
+	StartedAt  int64  `json:"started_at"`
+	FinishedAt int64  `json:"finished_at"`
+	OK         bool   `json:"ok"`
+	Path       string `json:"path,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// BackupStatus summarizes backup_runs.
+type BackupStatus struct {
+	LastSuccess         *BackupRun  `json:"last_success"`
+	LastFailure         *BackupRun  `json:"last_failure"`
+	ConsecutiveFailures int         `json:"consecutive_failures"`
+	Recent              []BackupRun `json:"recent"`
+}
+
+const backupRunsKeep = 500
+
+// RecordBackupRun stores a backup attempt and trims the table to the last backupRunsKeep rows.
+func (s *Store) RecordBackupRun(ctx context.Context, trigger string, started time.Time, path string, runErr error) error {
+	ok, msg := 1, ""
+	if runErr != nil {
+		ok, msg = 0, runErr.Error()
+		path = ""
+	}
+	if _, err := s.db.SQL.ExecContext(ctx, `INSERT INTO backup_runs(trigger,started_at,finished_at,ok,path,error) VALUES(?,?,?,?,?,?)`,
+		trigger, started.Unix(), time.Now().Unix(), ok, path, msg); err != nil {
+		return err
+	}
+	_, err := s.db.SQL.ExecContext(ctx, `DELETE FROM backup_runs WHERE id <= (SELECT id FROM backup_runs ORDER BY id DESC LIMIT 1 OFFSET ?)`, backupRunsKeep)
+	return err
+}
+
+// BackupFailureStreak returns how many attempts failed since the last success.
+func (s *Store) BackupFailureStreak(ctx context.Context) (int, error) {
+	var n int
+	err := s.db.SQL.QueryRowContext(ctx, `SELECT COUNT(1) FROM backup_runs WHERE ok=0 AND id > COALESCE((SELECT MAX(id) FROM backup_runs WHERE ok=1), 0)`).Scan(&n)
+	return n, err
+}
+
+// BackupStatus returns the last success/failure, the current failure streak and the
+// latest `recent` attempts.
+func (s *Store) BackupStatus(ctx context.Context, recent int) (BackupStatus, error) {
+	st := BackupStatus{Recent: make([]BackupRun, 0)}
+	rows, err := s.db.SQL.QueryContext(ctx, `SELECT id,trigger,started_at,finished_at,ok,path,error FROM backup_runs ORDER BY id DESC LIMIT ?`, recent)
+	if err != nil {
+		return st, err
+	}
+	for rows.Next() {
+		var r BackupRun
+		var ok int
+		if err := rows.Scan(&r.ID, &r.Trigger, &r.StartedAt, &r.FinishedAt, &ok, &r.Path, &r.Error); err != nil {
+			continue
+		}
+		r.OK = ok == 1
+		st.Recent = append(st.Recent, r)
+	}
+	rows.Close()
+	for _, want := range []int{1, 0} {
+		var r BackupRun
+		var ok int
+		err := s.db.SQL.QueryRowContext(ctx, `SELECT id,trigger,started_at,finished_at,ok,path,error FROM backup_runs WHERE ok=? ORDER BY id DESC LIMIT 1`, want).
+			Scan(&r.ID, &r.Trigger, &r.StartedAt, &r.FinishedAt, &ok, &r.Path, &r.Error)
+		if err != nil {
+			continue
+		}
+		r.OK = ok == 1
+		if want == 1 {
+			st.LastSuccess = &r
+		} else {
+			st.LastFailure = &r
+		}
+	}
+	st.ConsecutiveFailures, err = s.BackupFailureStreak(ctx)
+	return st, err
+}
@@ -0,0 +1,38 @@
+package jobs
+
+import (
+	"context"
+	"time"
+)
+
+// healthReplaceMaxAge drops replacements whose media never came back through the uploader.
+const healthReplaceMaxAge = 30 * 24 * time.Hour
+
+// SetHealthReplacement records that the repaired media mediaName (handed to the media
+// inbox) replaces the release at nzbPath / importID, so its re-upload lands on the same
+// NZB path and its re-import keeps the same import id.
+func (s *Store) SetHealthReplacement(ctx context.Context, mediaName, nzbPath, importID string) error {
+	now := time.Now()
+	_, _ = s.db.SQL.ExecContext(ctx, `DELETE FROM health_replacements WHERE created_at < ?`, now.Add(-healthReplaceMaxAge).Unix())
+	_, err := s.db.SQL.ExecContext(ctx, `
+		INSERT INTO health_replacements(media_name,nzb_path,import_id,created_at) VALUES(?,?,?,?)
+		ON CONFLICT(media_name) DO UPDATE SET nzb_path=excluded.nzb_path, import_id=excluded.import_id, created_at=excluded.created_at
+	`, mediaName, nzbPath, importID, now.Unix())
+	return err
+}
+
+// HealthReplacementForMedia returns the original NZB path for a repaired media file name.
+func (s *Store) HealthReplacementForMedia(ctx context.Context, mediaName string) (nzbPath string, ok bool) {
+	err := s.db.SQL.QueryRowContext(ctx, `SELECT nzb_path FROM health_replacements WHERE media_name=?`, mediaName).Scan(&nzbPath)
+	return nzbPath, err == nil && nzbPath != ""
+}
+
+// TakeHealthReplacement returns (and forgets) the import id to reuse when nzbPath is
+// imported again after a health re-upload.
+func (s *Store) TakeHealthReplacement(ctx context.Context, nzbPath string) (importID string, ok bool) {
+	if err := s.db.SQL.QueryRowContext(ctx, `SELECT import_id FROM health_replacements WHERE nzb_path=? ORDER BY created_at DESC LIMIT 1`, nzbPath).Scan(&importID); err != nil || importID == "" {
+		return "", false
+	}
+	_, _ = s.db.SQL.ExecContext(ctx, `DELETE FROM health_replacements WHERE nzb_path=?`, nzbPath)
+	return importID, true
+}
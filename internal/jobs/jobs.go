@@ -3,10 +3,13 @@ package jobs
 import (
 	"context"
 	"crypto/rand"
+	"database/sql"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gaby/EDRmount/internal/db"
@@ -21,6 +24,9 @@ const (
 	TypeUpload       Type = "upload_media"
 	TypeHealthRepair Type = "health_repair_nzb"
 	TypeHealthScan   Type = "health_scan_nzb"
+	TypeHealthCheck  Type = "health_check_nzb"
+	TypeThumbnail    Type = "thumbnail_import"
+	TypeDecodedSizes Type = "decoded_sizes_import"
 
 	StateQueued  State = "queued"
 	StateRunning State = "running"
@@ -36,10 +42,16 @@ type Job struct {
 	UpdatedAt time.Time       `json:"updated_at"`
 	Payload   json.RawMessage `json:"payload"`
 	Error     *string         `json:"error,omitempty"`
+	Attempts  int             `json:"attempts,omitempty"` // automatic retries so far (see RetryLater)
 }
 
 type Store struct {
 	db *db.DB
+
+	libVersion atomic.Uint64 // see TouchLibrary
+	libMu      sync.Mutex
+	libChanges []libChange
+	libTrimmed uint64 // newest version dropped from libChanges
 }
 
 func NewStore(d *db.DB) *Store { return &Store{db: d} }
@@ -120,7 +132,7 @@ func (s *Store) List(ctx context.Context, limit int) ([]Job, error) {
 	if limit <= 0 || limit > 500 {
 		limit = 100
 	}
-	rows, err := s.db.SQL.QueryContext(ctx, `SELECT id,type,state,created_at,updated_at,payload_json,error FROM jobs ORDER BY created_at DESC LIMIT ?`, limit)
+	rows, err := s.db.SQL.QueryContext(ctx, `SELECT `+jobColumns+` FROM jobs ORDER BY created_at DESC LIMIT ?`, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -128,27 +140,48 @@ func (s *Store) List(ctx context.Context, limit int) ([]Job, error) {
 
 	out := make([]Job, 0)
 	for rows.Next() {
-		var (
-			id, typ, st, payload string
-			created, updated     int64
-			errStr               *string
-		)
-		if err := rows.Scan(&id, &typ, &st, &created, &updated, &payload, &errStr); err != nil {
+		j, err := scanJob(rows)
+		if err != nil {
 			return nil, err
 		}
-		out = append(out, Job{
-			ID:        id,
-			Type:      Type(typ),
-			State:     State(st),
-			CreatedAt: time.Unix(created, 0),
-			UpdatedAt: time.Unix(updated, 0),
-			Payload:   json.RawMessage(payload),
-			Error:     errStr,
-		})
+		out = append(out, j)
 	}
 	return out, rows.Err()
 }
 
+// Get returns one job.
+func (s *Store) Get(ctx context.Context, id string) (Job, error) {
+	j, err := scanJob(s.db.SQL.QueryRowContext(ctx, `SELECT `+jobColumns+` FROM jobs WHERE id=?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Job{}, ErrJobNotFound
+	}
+	return j, err
+}
+
+const jobColumns = `id,type,state,created_at,updated_at,payload_json,error,attempts`
+
+func scanJob(row interface{ Scan(...any) error }) (Job, error) {
+	var (
+		id, typ, st, payload string
+		created, updated     int64
+		errStr               *string
+		attempts             int
+	)
+	if err := row.Scan(&id, &typ, &st, &created, &updated, &payload, &errStr, &attempts); err != nil {
+		return Job{}, err
+	}
+	return Job{
+		ID:        id,
+		Type:      Type(typ),
+		State:     State(st),
+		CreatedAt: time.Unix(created, 0),
+		UpdatedAt: time.Unix(updated, 0),
+		Payload:   json.RawMessage(payload),
+		Error:     errStr,
+		Attempts:  attempts,
+	}, nil
+}
+
 func (s *Store) AppendLog(ctx context.Context, jobID, line string) error {
 	_, err := s.db.SQL.ExecContext(ctx, `INSERT INTO job_logs(job_id,ts,line) VALUES(?,?,?)`, jobID, time.Now().Unix(), line)
 	return err
@@ -173,3 +206,31 @@ func (s *Store) GetLogs(ctx context.Context, jobID string, limit int) ([]string,
 	}
 	return out, rows.Err()
 }
+
+// LogLine is a job log line with its time.
+type LogLine struct {
+	TS   time.Time `json:"ts"`
+	Line string    `json:"line"`
+}
+
+// GetLogLines is GetLogs with timestamps, newest first.
+func (s *Store) GetLogLines(ctx context.Context, jobID string, limit int) ([]LogLine, error) {
+	if limit <= 0 || limit > 5000 {
+		limit = 500
+	}
+	rows, err := s.db.SQL.QueryContext(ctx, `SELECT ts,line FROM job_logs WHERE job_id=? ORDER BY ts DESC LIMIT ?`, jobID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []LogLine
+	for rows.Next() {
+		var ts int64
+		var line string
+		if err := rows.Scan(&ts, &line); err != nil {
+			return nil, err
+		}
+		out = append(out, LogLine{TS: time.Unix(ts, 0), Line: line})
+	}
+	return out, rows.Err()
+}
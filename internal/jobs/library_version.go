@@ -0,0 +1,57 @@
+package jobs
+
+// libChangeHistory bounds the per-import change log kept for LibraryChangedSince.
+const libChangeHistory = 512
+
+// libChange is one TouchLibrary; importID "" means not limited to one import.
+type libChange struct {
+	ver      uint64
+	importID string
+}
+
+// TouchLibrary records that imports, their files, overrides or resolved metadata
+// changed. The FUSE library caches its virtual tree against LibraryVersion and
+// rebuilds it on the next read after a touch. Passing the affected import ids lets
+// per-file caches (LibraryChangedSince) keep the entries of other imports.
+func (s *Store) TouchLibrary(importIDs ...string) {
+	s.libMu.Lock()
+	defer s.libMu.Unlock()
+	ver := s.libVersion.Add(1)
+	if len(importIDs) == 0 {
+		s.libChanges = append(s.libChanges, libChange{ver: ver})
+	}
+	for _, id := range importIDs {
+		s.libChanges = append(s.libChanges, libChange{ver: ver, importID: id})
+	}
+	if n := len(s.libChanges) - libChangeHistory; n > 0 {
+		s.libTrimmed = s.libChanges[n-1].ver
+		s.libChanges = append(s.libChanges[:0], s.libChanges[n:]...)
+	}
+}
+
+// LibraryVersion is bumped by every TouchLibrary.
+func (s *Store) LibraryVersion() uint64 { return s.libVersion.Load() }
+
+// LibraryChangedSince reports whether importID's rows may have changed after
+// LibraryVersion v: true when a touch since then named importID or no import at all,
+// or when the change log no longer reaches back to v.
+func (s *Store) LibraryChangedSince(v uint64, importID string) bool {
+	if s.libVersion.Load() == v {
+		return false
+	}
+	s.libMu.Lock()
+	defer s.libMu.Unlock()
+	if s.libTrimmed > v {
+		return true
+	}
+	for i := len(s.libChanges) - 1; i >= 0; i-- {
+		c := s.libChanges[i]
+		if c.ver <= v {
+			break
+		}
+		if c.importID == "" || c.importID == importID {
+			return true
+		}
+	}
+	return false
+}
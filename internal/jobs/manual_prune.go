@@ -0,0 +1,25 @@
+package jobs
+
+import "context"
+
+// PruneEmptyManualDirs deletes auto-seeded manual_dirs that have no child dirs and no
+// items, repeating so emptied parents go too. User-created dirs and root are kept.
+func (s *Store) PruneEmptyManualDirs(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		res, err := s.db.SQL.ExecContext(ctx, `
+			DELETE FROM manual_dirs
+			WHERE source='auto' AND id<>'root'
+			  AND NOT EXISTS (SELECT 1 FROM manual_dirs c WHERE c.parent_id=manual_dirs.id AND c.id<>manual_dirs.id)
+			  AND NOT EXISTS (SELECT 1 FROM manual_items i WHERE i.dir_id=manual_dirs.id)
+		`)
+		if err != nil {
+			return total, err
+		}
+		n, _ := res.RowsAffected()
+		if n == 0 {
+			return total, nil
+		}
+		total += int(n)
+	}
+}
@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotQueued is returned when reordering a job that is not in state queued.
+	ErrNotQueued = errors.New("job is not queued")
+	// ErrJobNotFound is returned by Get and when reordering an unknown job id.
+	ErrJobNotFound = errors.New("job not found")
+)
+
+const queuedOrderSQL = `SELECT id,type FROM jobs WHERE state=? ORDER BY queue_pos ASC, created_at ASC, id ASC`
+
+// QueuedJob is an entry of the effective queue order.
+type QueuedJob struct {
+	ID   string `json:"id"`
+	Type Type   `json:"type"`
+}
+
+// QueuedOrder returns queued jobs in the order ClaimNext will pick them.
+func (s *Store) QueuedOrder(ctx context.Context) ([]QueuedJob, error) {
+	rows, err := s.db.SQL.QueryContext(ctx, queuedOrderSQL, string(StateQueued))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]QueuedJob, 0)
+	for rows.Next() {
+		var q QueuedJob
+		var typ string
+		if err := rows.Scan(&q.ID, &typ); err != nil {
+			continue
+		}
+		q.Type = Type(typ)
+		out = append(out, q)
+	}
+	return out, rows.Err()
+}
+
+// Bump moves a queued job to the front of the queue.
+func (s *Store) Bump(ctx context.Context, jobID string) error {
+	return s.Reorder(ctx, []string{jobID})
+}
+
+// Reorder puts the given queued jobs at the front of the queue, in the given order.
+// Queued jobs not listed keep their relative order after them; running jobs are untouched.
+func (s *Store) Reorder(ctx context.Context, ids []string) error {
+	tx, err := s.db.SQL.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			return fmt.Errorf("duplicate job id %s", id)
+		}
+		seen[id] = true
+		var st string
+		if err := tx.QueryRowContext(ctx, `SELECT state FROM jobs WHERE id=?`, id).Scan(&st); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("job %s: %w", id, ErrJobNotFound)
+			}
+			return err
+		}
+		if st != string(StateQueued) {
+			return fmt.Errorf("job %s: %w", id, ErrNotQueued)
+		}
+	}
+
+	var minPos int64
+	if err := tx.QueryRowContext(ctx, `SELECT COALESCE(MIN(queue_pos),0) FROM jobs WHERE state=?`, string(StateQueued)).Scan(&minPos); err != nil {
+		return err
+	}
+	base := minPos - int64(len(ids))
+	for i, id := range ids {
+		if _, err := tx.ExecContext(ctx, `UPDATE jobs SET queue_pos=? WHERE id=? AND state=?`, base+int64(i), id, string(StateQueued)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,25 @@
+package jobs
+
+import (
+	"context"
+	"time"
+)
+
+// Paused reports whether the runner should stop claiming new jobs.
+func (s *Store) Paused(ctx context.Context) (bool, error) {
+	var p int
+	if err := s.db.SQL.QueryRowContext(ctx, `SELECT paused FROM runner_state WHERE id=1`).Scan(&p); err != nil {
+		return false, err
+	}
+	return p != 0, nil
+}
+
+// SetPaused persists the runner pause switch. In-flight jobs are not affected.
+func (s *Store) SetPaused(ctx context.Context, paused bool) error {
+	v := 0
+	if paused {
+		v = 1
+	}
+	_, err := s.db.SQL.ExecContext(ctx, `INSERT INTO runner_state(id,paused,updated_at) VALUES(1,?,?) ON CONFLICT(id) DO UPDATE SET paused=excluded.paused, updated_at=excluded.updated_at`, v, time.Now().Unix())
+	return err
+}
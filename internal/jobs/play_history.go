@@ -0,0 +1,123 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// PlayEntry is one play of a file: the /api/v1/play requests of one client, coalesced.
+type PlayEntry struct {
+	ID          int64  `json:"id"`
+	ImportID    string `json:"import_id"`
+	FileIdx     int    `json:"file_idx"`
+	Filename    string `json:"filename"`
+	StartedAt   int64  `json:"started_at"`
+	EndedAt     int64  `json:"ended_at"` // 0 while still playing
+	BytesServed int64  `json:"bytes_served"`
+	UserAgent   string `json:"user_agent"`
+}
+
+// playCoalesceWindow is how long after its last request a play keeps absorbing new
+// requests from the same client: players seek and buffer with many Range GETs.
+const playCoalesceWindow = 5 * time.Minute
+
+// RecordPlayStart returns the play_history row for a request, reusing the client's
+// play of the same file when it was active within playCoalesceWindow and inserting a
+// new row otherwise. The id is passed to RecordPlayEnd.
+func (s *Store) RecordPlayStart(ctx context.Context, importID string, fileIdx int, userAgent string) (int64, error) {
+	now := time.Now().Unix()
+	var id int64
+	err := s.db.SQL.QueryRowContext(ctx, `
+		SELECT id FROM play_history
+		WHERE import_id=? AND file_idx=? AND user_agent=? AND MAX(started_at, ended_at)>=?
+		ORDER BY id DESC LIMIT 1
+	`, importID, fileIdx, userAgent, now-int64(playCoalesceWindow/time.Second)).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+	res, err := s.db.SQL.ExecContext(ctx, `INSERT INTO play_history(import_id,file_idx,started_at,user_agent) VALUES(?,?,?,?)`, importID, fileIdx, now, userAgent)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// RecordPlayEnd stamps the end time of a play and adds the bytes served by one request.
+func (s *Store) RecordPlayEnd(ctx context.Context, id, bytesServed int64) error {
+	_, err := s.db.SQL.ExecContext(ctx, `UPDATE play_history SET ended_at=?, bytes_served=bytes_served+? WHERE id=?`, time.Now().Unix(), bytesServed, id)
+	return err
+}
+
+// PlayHistory returns the most recent plays, newest first (optionally for one import).
+func (s *Store) PlayHistory(ctx context.Context, importID string, limit int) ([]PlayEntry, error) {
+	rows, err := s.db.SQL.QueryContext(ctx, `
+		SELECT h.id, h.import_id, h.file_idx, COALESCE(f.filename,''), h.started_at, h.ended_at, h.bytes_served, h.user_agent
+		FROM play_history h
+		LEFT JOIN nzb_files f ON f.import_id=h.import_id AND f.idx=h.file_idx
+		WHERE (?='' OR h.import_id=?)
+		ORDER BY h.started_at DESC, h.id DESC
+		LIMIT ?
+	`, importID, importID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]PlayEntry, 0)
+	for rows.Next() {
+		var e PlayEntry
+		if err := rows.Scan(&e.ID, &e.ImportID, &e.FileIdx, &e.Filename, &e.StartedAt, &e.EndedAt, &e.BytesServed, &e.UserAgent); err != nil {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// PrunePlayHistory deletes plays started before cutoff.
+func (s *Store) PrunePlayHistory(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := s.db.SQL.ExecContext(ctx, `DELETE FROM play_history WHERE started_at < ?`, cutoff.Unix())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// PlayedFile is a distinct file from play_history with its last play time.
+type PlayedFile struct {
+	ImportID string
+	FileIdx  int
+	Filename string
+	Size     int64
+	LastPlay int64
+}
+
+// RecentPlayedFiles returns up to limit distinct files, most recently played first,
+// skipping files whose import no longer exists.
+func (s *Store) RecentPlayedFiles(ctx context.Context, limit int) ([]PlayedFile, error) {
+	rows, err := s.db.SQL.QueryContext(ctx, `
+		SELECT h.import_id, h.file_idx, COALESCE(f.filename,''), f.total_bytes, MAX(h.started_at) AS last
+		FROM play_history h
+		JOIN nzb_files f ON f.import_id=h.import_id AND f.idx=h.file_idx
+		GROUP BY h.import_id, h.file_idx
+		ORDER BY last DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]PlayedFile, 0)
+	for rows.Next() {
+		var p PlayedFile
+		if err := rows.Scan(&p.ImportID, &p.FileIdx, &p.Filename, &p.Size, &p.LastPlay); err != nil {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
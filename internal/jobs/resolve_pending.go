@@ -0,0 +1,68 @@
+package jobs
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	resolveRetryBase = 5 * time.Minute
+	resolveRetryMax  = 6 * time.Hour
+)
+
+// resolveRetryDelay doubles from resolveRetryBase per failed attempt, capped at resolveRetryMax.
+func resolveRetryDelay(attempts int) time.Duration {
+	d := resolveRetryBase
+	for i := 1; i < attempts && d < resolveRetryMax; i++ {
+		d *= 2
+	}
+	if d > resolveRetryMax {
+		d = resolveRetryMax
+	}
+	return d
+}
+
+// MarkResolvePending records a failed metadata enrichment for importID and schedules
+// the next retry with exponential backoff.
+func (s *Store) MarkResolvePending(ctx context.Context, importID, lastErr string) error {
+	now := time.Now()
+	attempts := 0
+	_ = s.db.SQL.QueryRowContext(ctx, `SELECT attempts FROM library_resolve_pending WHERE import_id=?`, importID).Scan(&attempts)
+	attempts++
+	next := now.Add(resolveRetryDelay(attempts)).Unix()
+	_, err := s.db.SQL.ExecContext(ctx, `
+		INSERT INTO library_resolve_pending(import_id,attempts,next_at,last_error,created_at) VALUES(?,?,?,?,?)
+		ON CONFLICT(import_id) DO UPDATE SET attempts=excluded.attempts, next_at=excluded.next_at, last_error=excluded.last_error
+	`, importID, attempts, next, lastErr, now.Unix())
+	return err
+}
+
+// ClearResolvePending drops importID from the retry queue (enrichment succeeded).
+func (s *Store) ClearResolvePending(ctx context.Context, importID string) error {
+	_, err := s.db.SQL.ExecContext(ctx, `DELETE FROM library_resolve_pending WHERE import_id=?`, importID)
+	return err
+}
+
+// DueResolvePending returns up to limit import IDs whose retry time has come.
+func (s *Store) DueResolvePending(ctx context.Context, limit int) ([]string, error) {
+	rows, err := s.db.SQL.QueryContext(ctx, `SELECT import_id FROM library_resolve_pending WHERE next_at <= ? ORDER BY next_at ASC LIMIT ?`, time.Now().Unix(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err == nil {
+			out = append(out, id)
+		}
+	}
+	return out, rows.Err()
+}
+
+// ResolvePendingCount returns the number of imports waiting for an enrichment retry.
+func (s *Store) ResolvePendingCount(ctx context.Context) (int, error) {
+	var n int
+	err := s.db.SQL.QueryRowContext(ctx, `SELECT COUNT(1) FROM library_resolve_pending`).Scan(&n)
+	return n, err
+}
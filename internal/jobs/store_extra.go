@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/gaby/EDRmount/internal/db"
@@ -11,8 +12,10 @@ import (
 
 var ErrNoQueuedJobs = errors.New("no queued jobs")
 
-// ClaimNext sets the oldest queued job to running and returns it.
-func (s *Store) ClaimNext(ctx context.Context) (*Job, error) {
+// ClaimNext sets the first queued job (queue order, then oldest) to running and returns it.
+// Jobs waiting for an automatic retry (RetryLater) are skipped until their delay is over.
+// With types, only jobs of those types are considered (runner.roles); others stay queued.
+func (s *Store) ClaimNext(ctx context.Context, types ...Type) (*Job, error) {
 	// sqlite: do a small transaction so claim is atomic.
 	tx, err := s.db.SQL.BeginTx(ctx, &sql.TxOptions{})
 	if err != nil {
@@ -20,20 +23,29 @@ func (s *Store) ClaimNext(ctx context.Context) (*Job, error) {
 	}
 	defer func() { _ = tx.Rollback() }()
 
-	row := tx.QueryRowContext(ctx, `SELECT id,type,state,created_at,updated_at,payload_json,error FROM jobs WHERE state=? ORDER BY created_at ASC LIMIT 1`, string(StateQueued))
+	now := time.Now().Unix()
+	q := `SELECT id,type,state,created_at,updated_at,payload_json,error,attempts FROM jobs WHERE state=? AND not_before<=?`
+	args := []any{string(StateQueued), now}
+	if len(types) > 0 {
+		q += ` AND type IN (?` + strings.Repeat(",?", len(types)-1) + `)`
+		for _, t := range types {
+			args = append(args, string(t))
+		}
+	}
+	row := tx.QueryRowContext(ctx, q+` ORDER BY queue_pos ASC, created_at ASC, id ASC LIMIT 1`, args...)
 	var (
 		id, typ, st, payload string
 		created, updated     int64
 		errStr               *string
+		attempts             int
 	)
-	if err := row.Scan(&id, &typ, &st, &created, &updated, &payload, &errStr); err != nil {
+	if err := row.Scan(&id, &typ, &st, &created, &updated, &payload, &errStr, &attempts); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrNoQueuedJobs
 		}
 		return nil, err
 	}
 
-	now := time.Now().Unix()
 	if _, err := tx.ExecContext(ctx, `UPDATE jobs SET state=?, updated_at=? WHERE id=?`, string(StateRunning), now, id); err != nil {
 		return nil, err
 	}
@@ -49,6 +61,7 @@ func (s *Store) ClaimNext(ctx context.Context) (*Job, error) {
 		UpdatedAt: time.Unix(now, 0),
 		Payload:   []byte(payload),
 		Error:     errStr,
+		Attempts:  attempts,
 	}, nil
 }
 
@@ -62,5 +75,14 @@ func (s *Store) SetFailed(ctx context.Context, jobID string, errMsg string) erro
 	return err
 }
 
+// RetryLater puts a failed job back in the queue for another attempt: it counts the
+// attempt, keeps errMsg as the last error and holds the job back from ClaimNext for delay.
+func (s *Store) RetryLater(ctx context.Context, jobID string, delay time.Duration, errMsg string) error {
+	now := time.Now()
+	_, err := s.db.SQL.ExecContext(ctx, `UPDATE jobs SET state=?, updated_at=?, error=?, attempts=attempts+1, not_before=? WHERE id=?`,
+		string(StateQueued), now.Unix(), errMsg, now.Add(delay).Unix(), jobID)
+	return err
+}
+
 // Expose underlying DB for internal packages that need to store extra state.
 func (s *Store) DB() *db.DB { return s.db }
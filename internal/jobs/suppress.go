@@ -0,0 +1,45 @@
+package jobs
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+)
+
+// Suppress keeps the watcher from enqueueing path (or another file with the same
+// base name) until the given time. Used after deletes so they don't bounce back.
+func (s *Store) Suppress(ctx context.Context, path, kind, reason string, until time.Time) error {
+	path = filepath.Clean(path)
+	_, err := s.db.SQL.ExecContext(ctx, `
+		INSERT INTO suppressed_paths(path,name,kind,reason,until_at,created_at) VALUES(?,?,?,?,?,?)
+		ON CONFLICT(path) DO UPDATE SET name=excluded.name, kind=excluded.kind, reason=excluded.reason, until_at=excluded.until_at, created_at=excluded.created_at
+	`, path, filepath.Base(path), kind, reason, until.Unix(), time.Now().Unix())
+	return err
+}
+
+// Suppressed reports whether path (or its base name) has an active suppression of the given kind.
+func (s *Store) Suppressed(ctx context.Context, path, kind string) (bool, error) {
+	path = filepath.Clean(path)
+	var n int
+	err := s.db.SQL.QueryRowContext(ctx, `
+		SELECT COUNT(1) FROM suppressed_paths
+		WHERE kind=? AND until_at > ? AND (path=? OR name=?)
+	`, kind, time.Now().Unix(), path, filepath.Base(path)).Scan(&n)
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// PruneSuppressed drops expired suppression entries.
+func (s *Store) PruneSuppressed(ctx context.Context) error {
+	_, err := s.db.SQL.ExecContext(ctx, `DELETE FROM suppressed_paths WHERE until_at <= ?`, time.Now().Unix())
+	return err
+}
+
+// Unsuppress lifts any suppression of the given kind on path (or its base name).
+func (s *Store) Unsuppress(ctx context.Context, path, kind string) error {
+	path = filepath.Clean(path)
+	_, err := s.db.SQL.ExecContext(ctx, `DELETE FROM suppressed_paths WHERE kind=? AND (path=? OR name=?)`, kind, path, filepath.Base(path))
+	return err
+}
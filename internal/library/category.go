@@ -0,0 +1,30 @@
+package library
+
+import (
+	"strings"
+	"unicode"
+)
+
+// CategoryKind maps an NZB <meta type="category"> value ("Movies > HD", "TV > HD",
+// "Series", "Peliculas"...) to "movie", "series" or "" when it says neither.
+func CategoryKind(category string) string {
+	words := strings.FieldsFunc(strings.ToLower(category), func(r rune) bool { return !unicode.IsLetter(r) })
+	for _, w := range words {
+		switch w {
+		case "tv", "series", "serie", "shows", "show", "episodes", "anime":
+			return "series"
+		case "movie", "movies", "film", "films", "pelicula", "peliculas", "películas", "película":
+			return "movie"
+		}
+	}
+	return ""
+}
+
+// GuessWithCategory is GuessFromFilename with the NZB category as a hint: for movie
+// categories only an explicit SxxEyy marks the file as an episode.
+func GuessWithCategory(name, category string) Guess {
+	if CategoryKind(category) == "movie" {
+		return guessFromFilename(name, false)
+	}
+	return GuessFromFilename(name)
+}
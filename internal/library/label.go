@@ -0,0 +1,107 @@
+package library
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	reLabelExt  = regexp.MustCompile(`^\.[A-Za-z0-9]{2,4}$`)
+	reLabelYear = regexp.MustCompile(`[(\[]((?:19|20)\d{2})[)\]]\s*$`)
+)
+
+// CleanLabel builds a presentable display name from a release filename, e.g.
+// "Movie.Name.2019.1080p.WEB-DL.x264.mkv" -> "Movie Name (2019).mkv" and
+// "Show.Name.S01E02.720p.mkv" -> "Show Name - 01x02.mkv".
+// It returns "" when nothing usable is left.
+func CleanLabel(filename string) string {
+	g := GuessFromFilename(filename)
+	// Prefer a year after the first word so titles like "1917" keep their name.
+	for i, w := range strings.Fields(g.Title) {
+		if i > 0 && reYear.MatchString(w) && len(w) == 4 {
+			g.Year, _ = strconv.Atoi(w)
+			break
+		}
+	}
+	title := cutReleaseTags(g.Title, g.Year)
+	if title == "" {
+		return ""
+	}
+	if g.IsSeries && g.Season > 0 && g.Episode > 0 {
+		return fmt.Sprintf("%s - %02dx%02d%s", title, g.Season, g.Episode, g.Ext)
+	}
+	if g.Year > 0 && title != strconv.Itoa(g.Year) {
+		return fmt.Sprintf("%s (%d)%s", title, g.Year, g.Ext)
+	}
+	return title + g.Ext
+}
+
+// cutReleaseTags keeps the words before the year or the first release tag, preserving case.
+func cutReleaseTags(title string, year int) string {
+	noise := map[string]bool{}
+	for _, t := range movieNoiseTokens {
+		noise[t] = true
+	}
+	y := ""
+	if year > 0 {
+		y = strconv.Itoa(year)
+	}
+	words := strings.Fields(title)
+	out := make([]string, 0, len(words))
+	for i, w := range words {
+		lw := strings.ToLower(strings.Trim(w, "()[]"))
+		// Keep a leading year-looking word (e.g. "1917") as part of the title.
+		if i > 0 && (lw == y || noise[lw] || isDynamicRange(lw) || DetectQuality(lw, nil) != "") {
+			break
+		}
+		out = append(out, strings.Trim(w, "[]"))
+	}
+	return strings.TrimSpace(strings.Join(out, " "))
+}
+
+// ParseLabel reads a display label back into a Guess, the reverse of CleanLabel:
+// "Movie Name (2019).mkv" and "Show Name - 01x02.mkv" (or S01E02). Unlike
+// GuessFromFilename it keeps the title as typed (dots, dashes, case); a label that is
+// still a release name ("Movie.Name.2019.1080p.mkv") is cleaned first.
+func ParseLabel(label string) Guess {
+	stem := strings.TrimSpace(filepath.Base(label))
+	if !strings.Contains(stem, " ") && strings.ContainsAny(stem, "._") {
+		// Not recursive: a one-word label like "Avatar.mkv" cleans to itself.
+		if c := CleanLabel(stem); c != "" {
+			g := parseDisplayLabel(c)
+			g.Quality = DetectQuality(stem, nil)
+			return g
+		}
+	}
+	return parseDisplayLabel(stem)
+}
+
+// parseDisplayLabel parses a label already in display form ("Title (Year).ext",
+// "Show - 01x02.ext").
+func parseDisplayLabel(stem string) Guess {
+	g := Guess{}
+	if ext := filepath.Ext(stem); reLabelExt.MatchString(ext) {
+		g.Ext = ext
+		stem = strings.TrimSuffix(stem, ext)
+	}
+	g.Quality = DetectQuality(stem, nil)
+	for _, re := range []*regexp.Regexp{reSxxExx, reNxxXxx} {
+		if loc := re.FindStringSubmatchIndex(stem); len(loc) >= 6 {
+			g.IsSeries = true
+			g.Season, _ = strconv.Atoi(stem[loc[2]:loc[3]])
+			g.Episode, _ = strconv.Atoi(stem[loc[4]:loc[5]])
+			stem = stem[:loc[0]]
+			break
+		}
+	}
+	stem = strings.TrimRight(strings.TrimSpace(stem), " -._")
+	if m := reLabelYear.FindStringSubmatchIndex(stem); m != nil {
+		g.Year, _ = strconv.Atoi(stem[m[2]:m[3]])
+		stem = strings.TrimSpace(stem[:m[0]])
+	}
+	g.Title = stem
+	return g
+}
@@ -0,0 +1,37 @@
+package library_test
+
+import (
+	"testing"
+
+	"github.com/gaby/EDRmount/internal/library"
+)
+
+func TestParseLabel(t *testing.T) {
+	tests := []struct {
+		label   string
+		title   string
+		year    int
+		season  int
+		episode int
+		ext     string
+	}{
+		{label: "Movie Name (2019).mkv", title: "Movie Name", year: 2019, ext: ".mkv"},
+		{label: "Show Name - 01x02.mkv", title: "Show Name", season: 1, episode: 2, ext: ".mkv"},
+		{label: "Show Name S03E04.mkv", title: "Show Name", season: 3, episode: 4, ext: ".mkv"},
+		{label: "Movie.Name.2019.1080p.WEB-DL.mkv", title: "Movie Name", year: 2019, ext: ".mkv"},
+		// Single-word labels with a dot or underscore clean to themselves.
+		{label: "Avatar.mkv", title: "Avatar", ext: ".mkv"},
+		{label: "Avatar_.mkv", title: "Avatar", ext: ".mkv"},
+		{label: "Avatar.", title: "Avatar"},
+		{label: "1917.mkv", title: "1917", ext: ".mkv"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			g := library.ParseLabel(tt.label)
+			if g.Title != tt.title || g.Year != tt.year || g.Season != tt.season || g.Episode != tt.episode || g.Ext != tt.ext {
+				t.Errorf("ParseLabel(%q) = title %q year %d S%dE%d ext %q, want %q %d S%dE%d %q",
+					tt.label, g.Title, g.Year, g.Season, g.Episode, g.Ext, tt.title, tt.year, tt.season, tt.episode, tt.ext)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package library
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gaby/EDRmount/internal/config"
+)
+
+// Quality tiers used for folder buckets. "4K" keeps the existing library-auto folder name;
+// the raw NZB layout maps it to "2160" (see RawQualityFolder).
+const (
+	Quality4K   = "4K"
+	Quality1080 = "1080"
+	Quality720  = "720"
+	QualitySD   = "SD"
+)
+
+var reQualityToken = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(2160[pi]?|4k|uhd|1080[pi]?|720p|576[pi]?|480[pi]?|dvdrip|dvd)(?:$|[^a-z0-9])`)
+
+// isDynamicRange reports whether a lower-case word is an HDR/Dolby Vision tag. These
+// describe the dynamic range, not the resolution, so they never pick a tier
+// ("Movie.1080p.HDR" is 1080); configured quality_buckets can still match them.
+func isDynamicRange(w string) bool {
+	switch w {
+	case "hdr", "hdr10", "hdr10+", "dv", "dovi":
+		return true
+	}
+	return false
+}
+
+var qualityRank = map[string]int{QualitySD: 1, Quality720: 2, Quality1080: 3, Quality4K: 4}
+
+// qualityBucket is a compiled config.QualityBucket: any rule selects it, and a rule
+// matches when all of its token patterns do.
+type qualityBucket struct {
+	name  string
+	rules [][]*regexp.Regexp
+}
+
+// compiledBuckets memoizes the last compileBuckets result: callers pass
+// library.quality_buckets on every lookup and it rarely changes.
+var compiledBuckets atomic.Pointer[bucketsMemo]
+
+type bucketsMemo struct {
+	key     string
+	buckets []qualityBucket
+}
+
+// compileBuckets compiles library.quality_buckets; nil (the built-in tiers) when empty.
+func compileBuckets(bs []config.QualityBucket) []qualityBucket {
+	if len(bs) == 0 {
+		return nil
+	}
+	key := fmt.Sprintf("%q", bs)
+	if m := compiledBuckets.Load(); m != nil && m.key == key {
+		return m.buckets
+	}
+	out := make([]qualityBucket, 0, len(bs))
+	for _, b := range bs {
+		qb := qualityBucket{name: strings.TrimSpace(b.Name)}
+		for _, m := range b.Match {
+			rule := make([]*regexp.Regexp, 0, 2)
+			for _, tok := range strings.Split(m, "+") {
+				if tok = strings.TrimSpace(tok); tok != "" {
+					rule = append(rule, regexp.MustCompile(`(?i)(?:^|[^a-z0-9])`+regexp.QuoteMeta(tok)+`(?:$|[^a-z0-9])`))
+				}
+			}
+			if len(rule) > 0 {
+				qb.rules = append(qb.rules, rule)
+			}
+		}
+		if qb.name != "" {
+			out = append(out, qb)
+		}
+	}
+	compiledBuckets.Store(&bucketsMemo{key: key, buckets: out})
+	return out
+}
+
+// bucketFor returns the first configured bucket with a rule matching name.
+func bucketFor(buckets []qualityBucket, name string) string {
+	for _, b := range buckets {
+		for _, rule := range b.rules {
+			ok := true
+			for _, re := range rule {
+				if !re.MatchString(name) {
+					ok = false
+					break
+				}
+			}
+			if ok {
+				return b.name
+			}
+		}
+	}
+	return ""
+}
+
+// DetectQuality returns the best quality tier found in a filename (the first matching
+// bucket when library.quality_buckets is given), or "" if none.
+func DetectQuality(name string, qualityBuckets []config.QualityBucket) string {
+	if buckets := compileBuckets(qualityBuckets); buckets != nil {
+		return bucketFor(buckets, name)
+	}
+	best := ""
+	// Tokens can share separators ("DVDRip.720p"), so scan with overlapping matches.
+	s := name
+	for {
+		loc := reQualityToken.FindStringSubmatchIndex(s)
+		if loc == nil {
+			break
+		}
+		q := NormalizeQuality(s[loc[2]:loc[3]], nil)
+		if qualityRank[q] > qualityRank[best] {
+			best = q
+		}
+		s = s[loc[3]:]
+	}
+	return best
+}
+
+// NormalizeQuality maps a quality token or configured value to a tier ("4K", "1080", "720", "SD"),
+// or to a bucket name when library.quality_buckets is given. Unknown values return "".
+func NormalizeQuality(q string, qualityBuckets []config.QualityBucket) string {
+	if buckets := compileBuckets(qualityBuckets); buckets != nil {
+		q = strings.TrimSpace(q)
+		for _, b := range buckets {
+			if strings.EqualFold(b.name, q) {
+				return b.name
+			}
+		}
+		if q == "" {
+			return ""
+		}
+		return bucketFor(buckets, q)
+	}
+	q = strings.ToLower(strings.TrimSpace(q))
+	switch {
+	case q == "":
+		return ""
+	case strings.HasPrefix(q, "2160"), q == "4k", q == "uhd":
+		return Quality4K
+	case strings.HasPrefix(q, "1080"):
+		return Quality1080
+	case strings.HasPrefix(q, "720"):
+		return Quality720
+	case strings.HasPrefix(q, "576"), strings.HasPrefix(q, "480"), q == "sd", q == "dvd", q == "dvdrip":
+		return QualitySD
+	}
+	return ""
+}
+
+// QualityOr returns the normalized quality, falling back to def and then to "1080"
+// (the last configured bucket with library.quality_buckets).
+func QualityOr(q, def string, qualityBuckets []config.QualityBucket) string {
+	if n := NormalizeQuality(q, qualityBuckets); n != "" {
+		return n
+	}
+	if n := NormalizeQuality(def, qualityBuckets); n != "" {
+		return n
+	}
+	if buckets := compileBuckets(qualityBuckets); len(buckets) > 0 {
+		return buckets[len(buckets)-1].name
+	}
+	return Quality1080
+}
+
+// RawQualityFolder returns the folder name used by the raw NZB layout for a tier.
+// Configured buckets are used as-is.
+func RawQualityFolder(q string, qualityBuckets []config.QualityBucket) string {
+	if len(qualityBuckets) > 0 {
+		return q
+	}
+	if q == Quality4K {
+		return "2160"
+	}
+	return q
+}
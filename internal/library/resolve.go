@@ -13,13 +13,16 @@ import (
 )
 
 type Resolver struct {
-	cfg config.Config
-	c   *tmdb.Client
+	cfg   config.Config
+	c     *tmdb.Client
+	langs []string // primary language first, then fallbacks
 
 	mu         sync.Mutex
 	movieCache map[string]tmdb.MovieSearchResult
 	tvCache    map[string]tmdb.TVDetails
 	epCache    map[string]string // tvID|season|episode -> name
+
+	unavailable bool // a TMDB call failed for reasons other than "not found"
 }
 
 func NewResolver(cfg config.Config) *Resolver {
@@ -32,17 +35,86 @@ func NewResolver(cfg config.Config) *Resolver {
 		r.c = tmdb.New(cfg.Metadata.TMDB.APIKey)
 		r.c.Language = cfg.Metadata.TMDB.Language
 	}
+	seen := map[string]bool{}
+	for _, l := range append([]string{cfg.Metadata.TMDB.Language}, cfg.Metadata.TMDB.LanguageFallbacks...) {
+		l = strings.TrimSpace(l)
+		if l == "" || seen[strings.ToLower(l)] {
+			continue
+		}
+		seen[strings.ToLower(l)] = true
+		r.langs = append(r.langs, l)
+	}
 	return r
 }
 
+// langKey identifies the language chain in cache keys so results resolved with
+// different languages never mix.
+func (r *Resolver) langKey() string {
+	return strings.ToLower(strings.Join(r.langs, ","))
+}
+
+// fallbackLangs returns the configured fallback languages (primary excluded).
+func (r *Resolver) fallbackLangs() []string {
+	if len(r.langs) <= 1 {
+		return nil
+	}
+	return r.langs[1:]
+}
+
+var reGenericEpisodeName = regexp.MustCompile(`(?i)^(episode|episodio|épisode|episódio|folge|aflevering)\s*\d+$`)
+
+// usableEpisodeTitle rejects empty names and TMDB's untranslated placeholders ("Episodio 3").
+func usableEpisodeTitle(name string) bool {
+	name = strings.TrimSpace(name)
+	return name != "" && !reGenericEpisodeName.MatchString(name)
+}
+
+// pickTitle chooses the title stored for a TMDB match per metadata.tmdb.original_title:
+// localized is the primary-language title, fallback looks it up in language_fallbacks
+// and original is original_title/original_name. The result is "" when nothing applies
+// (enrichment then keeps the filename guess). The original title stays on the result
+// either way.
+func (r *Resolver) pickTitle(localized, original string, fallback func() string) string {
+	mode := r.cfg.Metadata.TMDB.OriginalTitleMode()
+	if mode == config.OriginalTitlePrefer && strings.TrimSpace(original) != "" {
+		return original
+	}
+	if strings.TrimSpace(localized) != "" {
+		return localized
+	}
+	if t := fallback(); strings.TrimSpace(t) != "" {
+		return t
+	}
+	if mode == config.OriginalTitleNever {
+		return ""
+	}
+	return original
+}
+
 func (r *Resolver) Enabled() bool { return r != nil && r.c != nil }
 
+// Unavailable reports whether any lookup hit a TMDB outage (network error, 429, 5xx),
+// meaning a "not found" result may just be a failed request worth retrying later.
+func (r *Resolver) Unavailable() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.unavailable
+}
+
+func (r *Resolver) noteErr(err error) {
+	if tmdb.IsUnavailable(err) {
+		r.mu.Lock()
+		r.unavailable = true
+		r.mu.Unlock()
+	}
+}
+
 func (r *Resolver) ResolveMovie(ctx context.Context, title string, year int) (tmdb.MovieSearchResult, bool) {
 	if !r.Enabled() {
 		return tmdb.MovieSearchResult{}, false
 	}
 	baseTitle := strings.TrimSpace(title)
-	key := fmt.Sprintf("m:%s:%d", strings.ToLower(baseTitle), year)
+	key := fmt.Sprintf("m:%s:%s:%d", r.langKey(), strings.ToLower(baseTitle), year)
 	r.mu.Lock()
 	if v, ok := r.movieCache[key]; ok {
 		r.mu.Unlock()
@@ -61,6 +133,7 @@ func (r *Resolver) ResolveMovie(ctx context.Context, title string, year int) (tm
 	var res []tmdb.MovieSearchResult
 	for _, q := range searchTitles {
 		out, err := r.c.SearchMovie(cctx, q, year)
+		r.noteErr(err)
 		if err == nil && len(out) > 0 {
 			res = out
 			break
@@ -79,6 +152,14 @@ func (r *Resolver) ResolveMovie(ctx context.Context, title string, year int) (tm
 			}
 		}
 	}
+	best.Title = r.pickTitle(best.Title, best.OriginalTitle, func() string {
+		for _, lang := range r.fallbackLangs() {
+			if mv, err := r.c.WithLanguage(lang).GetMovie(cctx, best.ID); err == nil && strings.TrimSpace(mv.Title) != "" {
+				return mv.Title
+			}
+		}
+		return ""
+	})
 
 	r.mu.Lock()
 	r.movieCache[key] = best
@@ -91,7 +172,7 @@ func (r *Resolver) ResolveTV(ctx context.Context, title string, year int) (tmdb.
 		return tmdb.TVDetails{}, false
 	}
 	baseTitle := strings.TrimSpace(title)
-	key := fmt.Sprintf("t:%s:%d", strings.ToLower(baseTitle), year)
+	key := fmt.Sprintf("t:%s:%s:%d", r.langKey(), strings.ToLower(baseTitle), year)
 	r.mu.Lock()
 	if v, ok := r.tvCache[key]; ok {
 		r.mu.Unlock()
@@ -110,6 +191,7 @@ func (r *Resolver) ResolveTV(ctx context.Context, title string, year int) (tmdb.
 	var res []tmdb.TVSearchResult
 	for _, q := range searchTitles {
 		out, err := r.c.SearchTV(cctx, q, year)
+		r.noteErr(err)
 		if err == nil && len(out) > 0 {
 			res = out
 			break
@@ -118,6 +200,7 @@ func (r *Resolver) ResolveTV(ctx context.Context, title string, year int) (tmdb.
 	if len(res) == 0 {
 		for _, q := range fallbackTVQueries(baseTitle) {
 			out, err := r.c.SearchTV(cctx, q, year)
+			r.noteErr(err)
 			if err == nil && len(out) > 0 {
 				res = out
 				break
@@ -140,8 +223,17 @@ func (r *Resolver) ResolveTV(ctx context.Context, title string, year int) (tmdb.
 
 	details, err := r.c.GetTV(cctx, best.ID)
 	if err != nil {
+		r.noteErr(err)
 		return tmdb.TVDetails{}, false
 	}
+	details.Name = r.pickTitle(details.Name, details.OriginalName, func() string {
+		for _, lang := range r.fallbackLangs() {
+			if tv, err := r.c.WithLanguage(lang).GetTV(cctx, best.ID); err == nil && strings.TrimSpace(tv.Name) != "" {
+				return tv.Name
+			}
+		}
+		return ""
+	})
 
 	r.mu.Lock()
 	r.tvCache[key] = details
@@ -153,7 +245,7 @@ func (r *Resolver) ResolveEpisodeTitle(ctx context.Context, tvID, season, episod
 	if !r.Enabled() {
 		return "", false
 	}
-	key := fmt.Sprintf("e:%d:%d:%d", tvID, season, episode)
+	key := fmt.Sprintf("e:%s:%d:%d:%d", r.langKey(), tvID, season, episode)
 	r.mu.Lock()
 	if v, ok := r.epCache[key]; ok {
 		r.mu.Unlock()
@@ -164,6 +256,15 @@ func (r *Resolver) ResolveEpisodeTitle(ctx context.Context, tvID, season, episod
 	cctx, cancel := context.WithTimeout(ctx, 12*time.Second)
 	defer cancel()
 	name, err := r.c.GetTVEpisodeName(cctx, tvID, season, episode)
+	r.noteErr(err)
+	if err != nil || !usableEpisodeTitle(name) {
+		for _, lang := range r.fallbackLangs() {
+			if n, ferr := r.c.WithLanguage(lang).GetTVEpisodeName(cctx, tvID, season, episode); ferr == nil && usableEpisodeTitle(n) {
+				name, err = n, nil
+				break
+			}
+		}
+	}
 	if err != nil || strings.TrimSpace(name) == "" {
 		return "", false
 	}
@@ -0,0 +1,90 @@
+package library
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Title sanitization policies (library.title_sanitize).
+const (
+	SanitizeSafe    = "safe"
+	SanitizeMinimal = "minimal"
+)
+
+// titleVars are the template variables that carry metadata text (TMDB, overrides,
+// filenames) rather than configured folder names.
+var titleVars = []string{"title", "series", "episode_title"}
+
+// maxTitleBytes caps a sanitized title. Path elements are limited to 255 bytes on
+// most filesystems; the rest is left for the template's year, tags and extension.
+const maxTitleBytes = 200
+
+// windowsReserved are the device names Windows/SMB refuse as a file or folder name,
+// with or without an extension.
+var windowsReserved = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// SanitizeTitle makes a title usable as one path element. Every policy turns path
+// separators into "-" (so "Part 1/2" cannot open a folder), drops control characters
+// refuses "." / ".." and cuts the result to maxTitleBytes on a UTF-8 boundary. "safe"
+// (default) also rewrites what Windows/SMB shares reject: ":" becomes " -", `"` becomes
+// "'", ?*<>| are dropped, trailing dots go and device names (CON, NUL...) get a "_".
+func SanitizeTitle(policy, s string) string {
+	safe := !strings.EqualFold(strings.TrimSpace(policy), SanitizeMinimal)
+	if safe {
+		s = strings.ReplaceAll(s, ": ", " - ")
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		switch {
+		case r == '/' || r == '\\':
+			b.WriteRune('-')
+		case unicode.IsControl(r):
+			b.WriteRune(' ')
+		case !safe:
+			b.WriteRune(r)
+		case r == ':':
+			b.WriteRune('-')
+		case r == '"':
+			b.WriteRune('\'')
+		case strings.ContainsRune("?*<>|", r):
+			// dropped
+		default:
+			b.WriteRune(r)
+		}
+	}
+	out := strings.Join(strings.Fields(b.String()), " ")
+	if len(out) > maxTitleBytes {
+		cut := maxTitleBytes
+		for cut > 0 && !utf8.RuneStart(out[cut]) {
+			cut--
+		}
+		out = strings.TrimRight(out[:cut], " ")
+	}
+	if safe {
+		out = strings.TrimRight(out, ". ")
+		stem, _, _ := strings.Cut(out, ".")
+		if windowsReserved[strings.ToUpper(strings.TrimSpace(stem))] {
+			out = stem + "_" + strings.TrimPrefix(out, stem)
+		}
+	}
+	if out == "." || out == ".." {
+		return ""
+	}
+	return out
+}
+
+// SanitizeVars applies SanitizeTitle to the metadata variables of a template vars map
+// (title, series, episode_title), in place.
+func SanitizeVars(policy string, vars map[string]string) {
+	for _, k := range titleVars {
+		if v, ok := vars[k]; ok {
+			vars[k] = SanitizeTitle(policy, v)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package library_test
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/gaby/EDRmount/internal/library"
+)
+
+func TestSanitizeTitle(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		in     string
+		want   string
+	}{
+		{name: "plain", in: "The Matrix", want: "The Matrix"},
+		{name: "slash", in: "Part 1/2", want: "Part 1-2"},
+		{name: "backslash", in: `Part 1\2`, want: "Part 1-2"},
+		{name: "parent dir", in: "../../etc", want: "..-..-etc"},
+		{name: "dot", in: ".", want: ""},
+		{name: "dot dot", in: "..", want: ""},
+		{name: "dot dot minimal", policy: library.SanitizeMinimal, in: "..", want: ""},
+		{name: "NUL byte", in: "Bad\x00Title", want: "Bad Title"},
+		{name: "control chars", in: "Line\none\ttab\x1b", want: "Line one tab"},
+		{name: "colon", in: "Mission: Impossible", want: "Mission - Impossible"},
+		{name: "colon minimal", policy: library.SanitizeMinimal, in: "Mission: Impossible", want: "Mission: Impossible"},
+		{name: "windows chars", in: `What? "Now" <is> *it*|`, want: "What 'Now' is it"},
+		{name: "trailing dots and spaces", in: "Mr. Robot. . ", want: "Mr. Robot"},
+		{name: "trailing dots minimal", policy: library.SanitizeMinimal, in: "Mr. Robot...", want: "Mr. Robot..."},
+		{name: "reserved name", in: "CON", want: "CON_"},
+		{name: "reserved name lower case", in: "nul", want: "nul_"},
+		{name: "reserved name with extension", in: "com1.txt", want: "com1_.txt"},
+		{name: "reserved name minimal", policy: library.SanitizeMinimal, in: "CON", want: "CON"},
+		{name: "reserved prefix only", in: "Con Air", want: "Con Air"},
+		{name: "unicode", in: "Amélie", want: "Amélie"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := library.SanitizeTitle(tt.policy, tt.in); got != tt.want {
+				t.Errorf("SanitizeTitle(%q, %q) = %q, want %q", tt.policy, tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeTitleLong(t *testing.T) {
+	for _, in := range []string{strings.Repeat("a", 400), strings.Repeat("é", 300), strings.Repeat("日本", 100)} {
+		got := library.SanitizeTitle("", in)
+		if len(got) > 255 {
+			t.Errorf("SanitizeTitle(%d bytes) = %d bytes, want <= 255", len(in), len(got))
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("SanitizeTitle(%d bytes) cut inside a rune: %q", len(in), got)
+		}
+		if got == "" || !strings.HasPrefix(in, got) {
+			t.Errorf("SanitizeTitle(%d bytes) = %q, want a prefix of the input", len(in), got)
+		}
+	}
+}
+
+func TestSanitizeVars(t *testing.T) {
+	vars := map[string]string{
+		"title":         "Part 1/2",
+		"series":        "../Show",
+		"episode_title": "Pilot: Part\x00One.",
+		"quality":       "1080p/WEB", // configured values are left alone
+	}
+	library.SanitizeVars("", vars)
+	want := map[string]string{
+		"title":         "Part 1-2",
+		"series":        "..-Show",
+		"episode_title": "Pilot - Part One",
+		"quality":       "1080p/WEB",
+	}
+	for k, v := range want {
+		if vars[k] != v {
+			t.Errorf("vars[%q] = %q, want %q", k, vars[k], v)
+		}
+	}
+	if _, ok := vars["year"]; ok {
+		t.Error("SanitizeVars added a missing key")
+	}
+}
@@ -0,0 +1,61 @@
+package library
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gaby/EDRmount/internal/config"
+)
+
+// reEpisodeOnly is an episode number without a season: "E05" (no separator after a bare
+// E, so "E 5" or a title word followed by a number never matches), "Ep05", "Ep 5",
+// "Episode 5".
+var reEpisodeOnly = regexp.MustCompile(`(?i)\b(?:(?:EP|Episode)[ ._-]?(\d{1,4})|E(\d{1,4}))\b`)
+
+// GuessSpecial returns the season 0 (specials) guess of a name that has an episode number
+// but no season ("Show.Name.E05.1080p.mkv"), and false for anything else. GuessFromFilename
+// keeps such names as movies: callers only try this for series categories and take the
+// guess once TMDB confirms the title is a series.
+func GuessSpecial(name string) (Guess, bool) {
+	g := GuessFromFilename(name)
+	if g.IsSeries {
+		return g, false
+	}
+	base := filepath.Base(name)
+	stem := strings.TrimSuffix(base, filepath.Ext(base))
+	loc := reEpisodeOnly.FindStringSubmatchIndex(stem)
+	if loc == nil {
+		return g, false
+	}
+	num := loc[2:4]
+	if num[0] < 0 {
+		num = loc[4:6]
+	}
+	ep, _ := strconv.Atoi(stem[num[0]:num[1]])
+	head := strings.TrimSpace(stem[:loc[0]])
+	title := cleanTitle(head)
+	// "Episode 2019" is a year, not the 2019th special.
+	if ep <= 0 || (ep >= 1900 && ep <= 2099) || title == "" {
+		return g, false
+	}
+	g.IsSeries = true
+	g.Season = 0
+	g.Episode = ep
+	g.Title = title
+	g.Year = 0
+	if ym := reYear.FindStringSubmatch(head); len(ym) == 2 {
+		g.Year, _ = strconv.Atoi(ym[1])
+	}
+	return g, true
+}
+
+// SeasonFolderTemplate is the season folder template of library-auto for season:
+// library.specials_folder_template for season 0 when set, else season_folder_template.
+func SeasonFolderTemplate(l config.Library, season int) string {
+	if season == 0 && strings.TrimSpace(l.SpecialsFolderTemplate) != "" {
+		return l.SpecialsFolderTemplate
+	}
+	return l.SeasonFolderTemplate
+}
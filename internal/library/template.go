@@ -1,6 +1,7 @@
 package library
 
 import (
+	"fmt"
 	"path/filepath"
 	"regexp"
 	"strconv"
@@ -24,19 +25,19 @@ type Guess struct {
 	Season   int
 	Episode  int
 	Ext      string
-	Quality  string // 1080 or 4K
+	Quality  string // built-in tier (4K, 1080, 720, SD) or "" if unknown; see DetectQuality for buckets
 }
 
-func GuessFromFilename(name string) Guess {
+func GuessFromFilename(name string) Guess { return guessFromFilename(name, true) }
+
+// guessFromFilename parses name; loose enables the "1x02" episode form, which can
+// misfire on movie titles.
+func guessFromFilename(name string, loose bool) Guess {
 	base := filepath.Base(name)
 	ext := filepath.Ext(base)
 	stem := strings.TrimSuffix(base, ext)
 
-	g := Guess{Title: stem, Ext: ext, Quality: "1080"}
-	low := strings.ToLower(stem)
-	if strings.Contains(low, "2160") || strings.Contains(low, "4k") {
-		g.Quality = "4K"
-	}
+	g := Guess{Title: stem, Ext: ext, Quality: DetectQuality(stem, nil)}
 
 	if loc := reSxxExx.FindStringSubmatchIndex(stem); len(loc) >= 6 {
 		g.IsSeries = true
@@ -44,7 +45,7 @@ func GuessFromFilename(name string) Guess {
 		g.Episode, _ = strconv.Atoi(stem[loc[4]:loc[5]])
 		stem = strings.TrimSpace(stem[:loc[0]])
 	}
-	if !g.IsSeries {
+	if !g.IsSeries && loose {
 		if loc := reNxxXxx.FindStringSubmatchIndex(stem); len(loc) >= 6 {
 			g.IsSeries = true
 			g.Season, _ = strconv.Atoi(stem[loc[2]:loc[3]])
@@ -57,12 +58,15 @@ func GuessFromFilename(name string) Guess {
 		g.Year, _ = strconv.Atoi(ym[1])
 	}
 
-	// crude title cleanup: normalize separators
+	g.Title = cleanTitle(stem)
+	return g
+}
+
+// cleanTitle is the crude title cleanup of a name stem: separators become spaces.
+func cleanTitle(stem string) string {
 	clean := strings.NewReplacer(".", " ", "_", " ", "-", " ").Replace(stem)
 	clean = strings.Join(strings.Fields(clean), " ")
-	clean = strings.TrimSpace(clean)
-	g.Title = clean
-	return g
+	return strings.TrimSpace(clean)
 }
 
 func InitialFolder(title string) string {
@@ -83,6 +87,28 @@ func InitialFolder(title string) string {
 	return "#"
 }
 
+// Bucket schemes for the {initial} folder.
+const (
+	BucketAlpha  = "alpha"
+	BucketNone   = "none"
+	BucketDecade = "decade"
+)
+
+// BucketFolder returns the {initial} folder for title according to scheme.
+// "none" yields "" so the level collapses; "decade" uses the release year
+// (e.g. "1990s") and falls back to the alphabetic bucket when year is unknown.
+func BucketFolder(scheme, title string, year int) string {
+	switch strings.ToLower(strings.TrimSpace(scheme)) {
+	case BucketNone:
+		return ""
+	case BucketDecade:
+		if year > 0 {
+			return fmt.Sprintf("%ds", year/10*10)
+		}
+	}
+	return InitialFolder(title)
+}
+
 func Normalize(s string) string {
 	// remove accents using NFD and drop marks
 	ss := norm.NFD.String(s)
@@ -105,9 +131,10 @@ func utf8FirstRune(s string) (rune, int) {
 		return 0, 0
 	}
 	return r, size
-	return 0, 0
 }
 
+// ApplyUppercaseFolders uppercases every folder of p (the all-caps mode of
+// UppercaseFolders).
 func ApplyUppercaseFolders(p string) string {
 	parts := strings.Split(p, string(filepath.Separator))
 	for i := range parts {
@@ -0,0 +1,64 @@
+package library
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gaby/EDRmount/internal/config"
+)
+
+// UppercaseFolders applies library.uppercase_folders to p: every folder, or only the
+// ones picked by library.uppercase_segments. l should have its defaults filled.
+func UppercaseFolders(l config.Library, p string) string {
+	if !l.UppercaseFolders {
+		return p
+	}
+	if len(l.UppercaseSegments) == 0 {
+		return ApplyUppercaseFolders(p)
+	}
+	depths := map[int]bool{}
+	names := map[string]bool{}
+	for _, seg := range l.UppercaseSegments {
+		seg = strings.ToLower(strings.TrimSpace(seg))
+		if n, err := strconv.Atoi(seg); err == nil {
+			depths[n] = true
+			continue
+		}
+		var vals []string
+		switch seg {
+		case "movies_root":
+			vals = []string{l.MoviesRoot}
+		case "series_root":
+			vals = []string{l.SeriesRoot}
+		case "series_status":
+			vals = []string{l.EmisionFolder, l.FinalizadasFolder}
+		case "emision_folder":
+			vals = []string{l.EmisionFolder}
+		case "finalizadas_folder":
+			vals = []string{l.FinalizadasFolder}
+		}
+		for _, v := range vals {
+			if v = strings.TrimSpace(v); v != "" {
+				names[strings.ToLower(v)] = true
+			}
+		}
+	}
+	parts := strings.Split(p, string(filepath.Separator))
+	depth := 0
+	for i := range parts {
+		if parts[i] == "" {
+			continue
+		}
+		if i == len(parts)-1 && strings.Contains(parts[i], ".") {
+			continue
+		}
+		depth++
+		// Root variables are matched by value, so stored paths and raw NZB paths
+		// (which are not rendered from templates) get the same treatment.
+		if depths[depth] || names[strings.ToLower(parts[i])] {
+			parts[i] = strings.ToUpper(parts[i])
+		}
+	}
+	return strings.Join(parts, string(filepath.Separator))
+}
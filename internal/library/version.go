@@ -0,0 +1,81 @@
+package library
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/gaby/EDRmount/internal/config"
+)
+
+// DetectVersion returns the version tags (language cut, edition) of a release name,
+// e.g. "LATINO" or "DUAL EXTENDED", in the order of tags; "" when none match. Tags
+// match whole words, case-insensitively, and only after the year or SxxExx of the
+// name, so a title like "DC League of Super-Pets" is not a "DC" cut. Multi-word tags
+// ("DIRECTORS CUT") match consecutive words.
+func DetectVersion(name string, tags []string) string {
+	stem := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	if loc := reSxxExx.FindStringIndex(stem); loc != nil {
+		stem = stem[loc[1]:]
+	} else if loc := reYear.FindStringIndex(stem); loc != nil {
+		stem = stem[loc[1]:]
+	}
+	words := versionWords(stem)
+	found := make([]string, 0, 2)
+	for _, tag := range tags {
+		want := versionWords(tag)
+		if len(want) == 0 {
+			continue
+		}
+		for i := 0; i+len(want) <= len(words); i++ {
+			if equalWords(words[i:i+len(want)], want) {
+				found = append(found, strings.Join(want, " "))
+				break
+			}
+		}
+	}
+	return strings.Join(found, " ")
+}
+
+// WithVersion appends " [version]" to the leaf name of p, before the extension, unless
+// the template already put it there.
+func WithVersion(p, version string) string {
+	if version == "" {
+		return p
+	}
+	tag := "[" + version + "]"
+	dir, name := filepath.Split(p)
+	if strings.Contains(strings.ToUpper(name), tag) {
+		return p
+	}
+	ext := filepath.Ext(name)
+	return dir + strings.TrimSuffix(name, ext) + " " + tag + ext
+}
+
+// LibraryVersion is the version tag of a file for the library-auto leaf name, or "" with
+// library.split_versions off.
+func LibraryVersion(l config.Library, filename string) string {
+	if !l.SplitVersions {
+		return ""
+	}
+	tags := l.VersionTags
+	if len(tags) == 0 {
+		tags = config.DefaultVersionTags
+	}
+	return DetectVersion(filename, tags)
+}
+
+func versionWords(s string) []string {
+	return strings.FieldsFunc(strings.ToUpper(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func equalWords(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -38,6 +38,41 @@ func New(apiKey string) *Client {
 	}
 }
 
+// WithLanguage returns a copy of the client that requests the given language.
+// The copy shares the HTTP client, so it is cheap to create per call.
+func (c *Client) WithLanguage(lang string) *Client {
+	if c == nil {
+		return nil
+	}
+	cp := *c
+	cp.Language = strings.TrimSpace(lang)
+	return &cp
+}
+
+// ErrEpisodeNotFound means the season exists but has no such episode.
+var ErrEpisodeNotFound = errors.New("episode not found")
+
+// HTTPError is a non-2xx TMDB response.
+type HTTPError struct {
+	Status int
+	Body   string
+}
+
+func (e *HTTPError) Error() string { return fmt.Sprintf("tmdb http %d: %s", e.Status, e.Body) }
+
+// IsUnavailable reports whether err means TMDB could not be reached or is failing
+// (network error, timeout, 429, 5xx), as opposed to a definitive answer like 404.
+func IsUnavailable(err error) bool {
+	if err == nil || errors.Is(err, ErrEpisodeNotFound) {
+		return false
+	}
+	var he *HTTPError
+	if errors.As(err, &he) {
+		return he.Status == http.StatusTooManyRequests || he.Status >= 500
+	}
+	return true
+}
+
 func (c *Client) validate() error {
 	if c == nil {
 		return errors.New("tmdb client is nil")
@@ -132,7 +167,7 @@ func (c *Client) GetTVEpisodeName(ctx context.Context, tvID int, seasonNumber in
 			return ep.Name, nil
 		}
 	}
-	return "", fmt.Errorf("episode not found: tv=%d season=%d episode=%d", tvID, seasonNumber, episodeNumber)
+	return "", fmt.Errorf("%w: tv=%d season=%d episode=%d", ErrEpisodeNotFound, tvID, seasonNumber, episodeNumber)
 }
 
 func (c *Client) getJSON(ctx context.Context, path string, q url.Values, dst any) error {
@@ -177,7 +212,7 @@ func (c *Client) getJSON(ctx context.Context, path string, q url.Values, dst any
 	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		// Do not include full URL (contains api_key). Keep a safe error.
-		return fmt.Errorf("tmdb http %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+		return &HTTPError{Status: resp.StatusCode, Body: strings.TrimSpace(string(b))}
 	}
 	if err := json.Unmarshal(b, dst); err != nil {
 		return err
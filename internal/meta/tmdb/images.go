@@ -0,0 +1,96 @@
+package tmdb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultImageBaseURL = "https://image.tmdb.org/t/p/"
+
+// imageBaseTTL is how long the /configuration image base URL is reused. TMDB asks
+// clients to cache it and it practically never changes.
+const imageBaseTTL = 24 * time.Hour
+
+// imageBases caches the image base URL per API base URL.
+var imageBases struct {
+	mu sync.Mutex
+	m  map[string]imageBase
+}
+
+type imageBase struct {
+	url     string
+	fetched time.Time
+}
+
+// Configuration is the subset of /configuration needed to build image URLs.
+type Configuration struct {
+	Images struct {
+		SecureBaseURL string   `json:"secure_base_url"`
+		PosterSizes   []string `json:"poster_sizes"`
+	} `json:"images"`
+}
+
+func (c *Client) GetConfiguration(ctx context.Context) (Configuration, error) {
+	if err := c.validate(); err != nil {
+		return Configuration{}, err
+	}
+	var out Configuration
+	if err := c.getJSON(ctx, "/configuration", nil, &out); err != nil {
+		return Configuration{}, err
+	}
+	return out, nil
+}
+
+// imageBaseURL returns the image base URL from /configuration, cached for imageBaseTTL.
+// When /configuration fails the default is used and the lookup retried next time.
+func (c *Client) imageBaseURL(ctx context.Context) string {
+	imageBases.mu.Lock()
+	b, ok := imageBases.m[c.BaseURL]
+	imageBases.mu.Unlock()
+	if ok && time.Since(b.fetched) < imageBaseTTL {
+		return b.url
+	}
+	conf, err := c.GetConfiguration(ctx)
+	if err != nil || strings.TrimSpace(conf.Images.SecureBaseURL) == "" {
+		return defaultImageBaseURL
+	}
+	imageBases.mu.Lock()
+	if imageBases.m == nil {
+		imageBases.m = map[string]imageBase{}
+	}
+	imageBases.m[c.BaseURL] = imageBase{url: conf.Images.SecureBaseURL, fetched: time.Now()}
+	imageBases.mu.Unlock()
+	return conf.Images.SecureBaseURL
+}
+
+// GetImage downloads an image by its TMDB file path (e.g. a poster_path) at the given
+// size ("w500", "original"...). The base URL comes from /configuration (cached).
+func (c *Client) GetImage(ctx context.Context, filePath, size string) ([]byte, error) {
+	if strings.TrimSpace(filePath) == "" {
+		return nil, fmt.Errorf("tmdb image path empty")
+	}
+	base := c.imageBaseURL(ctx)
+	if size == "" {
+		size = "original"
+	}
+	u := strings.TrimRight(base, "/") + "/" + size + "/" + strings.TrimLeft(filePath, "/")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("tmdb image http %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 10<<20)) // 10MB max
+}
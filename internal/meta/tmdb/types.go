@@ -37,6 +37,8 @@ type MovieDetails struct {
 	ReleaseDate   string `json:"release_date"`
 	Status        string `json:"status"`
 	IMDBID        string `json:"imdb_id"`
+	Overview      string `json:"overview"`
+	PosterPath    string `json:"poster_path"`
 }
 
 func (m MovieDetails) ReleaseYear() int {
@@ -74,6 +76,8 @@ type TVDetails struct {
 	NumberOfSeasons  int           `json:"number_of_seasons"`
 	NumberOfEpisodes int           `json:"number_of_episodes"`
 	Seasons          []TVSeasonRef `json:"seasons"`
+	Overview         string        `json:"overview"`
+	PosterPath       string        `json:"poster_path"`
 }
 
 func (t TVDetails) FirstAirYear() int {
@@ -0,0 +1,115 @@
+package nntp
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrProviderDown is returned by Dial while the circuit breaker for a provider is open
+// (too many consecutive dial failures). Reads fail fast instead of each one timing out.
+var ErrProviderDown = errors.New("provider down (circuit open)")
+
+// Breakers are shared per host:port; pools and streamers are short-lived.
+var breakers sync.Map // host:port -> *breaker
+
+type breaker struct {
+	mu        sync.Mutex
+	failures  int       // consecutive dial failures
+	openUntil time.Time // zero = closed
+	probing   bool      // half-open: one dial in flight after the cooldown
+	trips     int64
+	lastErr   string
+	lastFail  time.Time
+}
+
+// BreakerState is a snapshot of a provider's circuit breaker.
+type BreakerState struct {
+	Addr      string    `json:"addr"`
+	State     string    `json:"state"` // closed|open|half-open
+	Failures  int       `json:"failures"`
+	Trips     int64     `json:"trips"`
+	OpenUntil time.Time `json:"open_until,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+	LastFail  time.Time `json:"last_fail,omitempty"`
+}
+
+func breakerKey(host string, port int) string { return fmt.Sprintf("%s:%d", host, port) }
+
+func breakerFor(cfg Config) *breaker {
+	b, _ := breakers.LoadOrStore(breakerKey(cfg.Host, cfg.Port), &breaker{})
+	return b.(*breaker)
+}
+
+// allow reports whether a dial may proceed. After the cooldown a single probe is let
+// through; its outcome closes or re-opens the breaker.
+func (b *breaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openUntil.IsZero() {
+		return true
+	}
+	if now.Before(b.openUntil) || b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	b.failures = 0
+	b.openUntil = time.Time{}
+	b.probing = false
+	b.mu.Unlock()
+}
+
+func (b *breaker) failure(err error, threshold int, cooldown time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	b.failures++
+	b.lastErr = err.Error()
+	b.lastFail = now
+	if b.probing || b.failures >= threshold {
+		if b.openUntil.IsZero() {
+			b.trips++
+		}
+		b.openUntil = now.Add(cooldown)
+		b.probing = false
+	}
+}
+
+func (b *breaker) state(addr string) BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st := BreakerState{Addr: addr, State: "closed", Failures: b.failures, Trips: b.trips, OpenUntil: b.openUntil, LastError: b.lastErr, LastFail: b.lastFail}
+	switch {
+	case b.openUntil.IsZero():
+	case b.probing || !time.Now().Before(b.openUntil):
+		st.State = "half-open"
+	default:
+		st.State = "open"
+	}
+	return st
+}
+
+// BreakerStates returns the breaker state of every provider dialed with a breaker.
+func BreakerStates() []BreakerState {
+	out := make([]BreakerState, 0)
+	breakers.Range(func(k, v any) bool {
+		out = append(out, v.(*breaker).state(k.(string)))
+		return true
+	})
+	sort.Slice(out, func(i, j int) bool { return out[i].Addr < out[j].Addr })
+	return out
+}
+
+// ResetBreaker closes the breaker for host:port (e.g. after a successful manual probe).
+func ResetBreaker(host string, port int) {
+	if b, ok := breakers.Load(breakerKey(host, port)); ok {
+		b.(*breaker).success()
+	}
+}
@@ -3,51 +3,121 @@ package nntp
 import (
 	"bufio"
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net"
 	"strings"
+	"sync"
 	"time"
 )
 
+// ErrArticleNotFound is returned (wrapped) when the server reports the article doesn't
+// exist (430/423). Unlike I/O errors, retrying won't help.
+var ErrArticleNotFound = errors.New("article not found")
+
 type Config struct {
-	Host    string
-	Port    int
-	SSL     bool
-	User    string
-	Pass    string
-	Timeout time.Duration
+	Host string
+	Port int
+	SSL  bool
+	User string
+	Pass string
+
+	// DialTimeout bounds connecting (TCP + TLS handshake). IOTimeout is the per-command
+	// read/write deadline; keep it generous so big BODY fetches on slow links don't abort.
+	DialTimeout time.Duration
+	IOTimeout   time.Duration
+
+	// BreakerFailures enables the per-provider circuit breaker: after this many
+	// consecutive dial failures Dial returns ErrProviderDown for BreakerCooldown.
+	// 0 = no breaker.
+	BreakerFailures int
+	BreakerCooldown time.Duration
+
+	// Limiter, when set, holds one slot per open connection (see GlobalLimiter).
+	Limiter *Limiter
+
+	// TLS settings (with SSL). TLSPinSHA256 lists the accepted leaf certificate
+	// fingerprints (lowercase hex): when set they replace CA verification. Zero values
+	// keep Go's defaults.
+	TLSPinSHA256    []string
+	TLSMinVersion   uint16
+	TLSCipherSuites []uint16
 }
 
 type Client struct {
 	cfg  Config
 	conn net.Conn
 	r    *bufio.Reader
+
+	closeOnce sync.Once
 }
 
 func (c *Client) setDeadline() {
-	_ = c.conn.SetDeadline(time.Now().Add(c.cfg.Timeout))
+	_ = c.conn.SetDeadline(time.Now().Add(c.cfg.IOTimeout))
 }
 
 func Dial(ctx context.Context, cfg Config) (*Client, error) {
 	if cfg.Port == 0 {
 		cfg.Port = 119
 	}
-	if cfg.Timeout == 0 {
-		cfg.Timeout = 10 * time.Second
+	if cfg.DialTimeout == 0 {
+		cfg.DialTimeout = 10 * time.Second
+	}
+	if cfg.IOTimeout == 0 {
+		cfg.IOTimeout = 60 * time.Second
+	}
+
+	var br *breaker
+	if cfg.BreakerFailures > 0 {
+		br = breakerFor(cfg)
+		if !br.allow(time.Now()) {
+			return nil, ErrProviderDown
+		}
+	}
+
+	if cfg.Limiter != nil {
+		if err := cfg.Limiter.acquire(ctx); err != nil {
+			return nil, err
+		}
 	}
+	cl, err := dial(ctx, cfg, br)
+	if err != nil && cfg.Limiter != nil {
+		cfg.Limiter.release()
+	}
+	return cl, err
+}
 
+func dial(ctx context.Context, cfg Config, br *breaker) (*Client, error) {
 	addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
-	d := &net.Dialer{Timeout: cfg.Timeout}
+	d := &net.Dialer{Timeout: cfg.DialTimeout}
 	var c net.Conn
 	var err error
 	if cfg.SSL {
-		tlsCfg := &tls.Config{ServerName: cfg.Host}
-		td := &tls.Dialer{NetDialer: d, Config: tlsCfg}
+		td := &tls.Dialer{NetDialer: d, Config: tlsConfig(cfg)}
 		c, err = td.DialContext(ctx, "tcp", addr)
 	} else {
 		c, err = d.DialContext(ctx, "tcp", addr)
 	}
+	if br != nil {
+		switch {
+		case err == nil:
+			br.success()
+		case ctx.Err() != nil:
+			// Caller gave up; says nothing about the provider. Free a half-open probe slot.
+			br.mu.Lock()
+			br.probing = false
+			br.mu.Unlock()
+		default:
+			cooldown := cfg.BreakerCooldown
+			if cooldown <= 0 {
+				cooldown = 30 * time.Second
+			}
+			br.failure(err, cfg.BreakerFailures, cooldown)
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -66,9 +136,41 @@ func Dial(ctx context.Context, cfg Config) (*Client, error) {
 	return cl, nil
 }
 
+// ErrTLSPinMismatch is returned (wrapped) when the provider presents a certificate that
+// matches none of Config.TLSPinSHA256.
+var ErrTLSPinMismatch = errors.New("tls certificate does not match the pinned sha256")
+
+func tlsConfig(cfg Config) *tls.Config {
+	tc := &tls.Config{ServerName: cfg.Host, MinVersion: cfg.TLSMinVersion, CipherSuites: cfg.TLSCipherSuites}
+	if len(cfg.TLSPinSHA256) == 0 {
+		return tc
+	}
+	// The pin is the trust anchor: self-signed and private-CA certificates are fine, and
+	// VerifyConnection still runs on every handshake.
+	tc.InsecureSkipVerify = true
+	tc.VerifyConnection = func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return fmt.Errorf("%w: %s presented no certificate", ErrTLSPinMismatch, cfg.Host)
+		}
+		sum := sha256.Sum256(cs.PeerCertificates[0].Raw)
+		got := hex.EncodeToString(sum[:])
+		for _, pin := range cfg.TLSPinSHA256 {
+			if pin == got {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s presented %s", ErrTLSPinMismatch, cfg.Host, got)
+	}
+	return tc
+}
+
 func (c *Client) Close() error {
 	_ = c.send("QUIT")
-	return c.conn.Close()
+	err := c.conn.Close()
+	if c.cfg.Limiter != nil {
+		c.closeOnce.Do(c.cfg.Limiter.release)
+	}
+	return err
 }
 
 func (c *Client) readLine() (string, error) {
@@ -161,6 +263,9 @@ func (c *Client) StatByMessageID(messageID string) error {
 	if strings.HasPrefix(line, "223") {
 		return nil
 	}
+	if isNotFoundReply(line) {
+		return fmt.Errorf("STAT failed: %s: %w", line, ErrArticleNotFound)
+	}
 	return fmt.Errorf("STAT failed: %s", line)
 }
 
@@ -175,6 +280,9 @@ func (c *Client) BodyByMessageID(messageID string) ([]string, error) {
 		return nil, err
 	}
 	if !strings.HasPrefix(line, "222") {
+		if isNotFoundReply(line) {
+			return nil, fmt.Errorf("BODY failed: %s: %w", line, ErrArticleNotFound)
+		}
 		return nil, fmt.Errorf("BODY failed: %s", line)
 	}
 	out := make([]string, 0, 1024)
@@ -193,3 +301,7 @@ func (c *Client) BodyByMessageID(messageID string) ([]string, error) {
 	}
 	return out, nil
 }
+
+func isNotFoundReply(line string) bool {
+	return strings.HasPrefix(line, "430") || strings.HasPrefix(line, "423")
+}
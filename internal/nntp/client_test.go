@@ -0,0 +1,113 @@
+package nntp_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gaby/EDRmount/internal/nntp"
+)
+
+// tlsServer starts an NNTP greeting server with a fresh self-signed certificate and
+// returns its port and the certificate's sha256 pin.
+func tlsServer(t *testing.T) (int, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "news.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				_, _ = c.Write([]byte("200 news.test ready\r\n"))
+				buf := make([]byte, 512)
+				for {
+					n, err := c.Read(buf)
+					if err != nil {
+						return
+					}
+					if strings.HasPrefix(string(buf[:n]), "QUIT") {
+						_, _ = c.Write([]byte("205 bye\r\n"))
+						return
+					}
+				}
+			}()
+		}
+	}()
+	sum := sha256.Sum256(der)
+	return ln.Addr().(*net.TCPAddr).Port, hex.EncodeToString(sum[:])
+}
+
+func TestDialTLSPin(t *testing.T) {
+	port, pin := tlsServer(t)
+	cfg := nntp.Config{Host: "127.0.0.1", Port: port, SSL: true, DialTimeout: 5 * time.Second, IOTimeout: 5 * time.Second}
+
+	t.Run("matching pin", func(t *testing.T) {
+		c := cfg
+		c.TLSPinSHA256 = []string{strings.Repeat("0", 64), pin}
+		cl, err := nntp.Dial(context.Background(), c)
+		if err != nil {
+			t.Fatalf("Dial: %v", err)
+		}
+		_ = cl.Close()
+	})
+
+	t.Run("wrong pin", func(t *testing.T) {
+		c := cfg
+		c.TLSPinSHA256 = []string{strings.Repeat("0", 64)}
+		cl, err := nntp.Dial(context.Background(), c)
+		if err == nil {
+			_ = cl.Close()
+			t.Fatal("Dial succeeded with a wrong pin")
+		}
+		if !errors.Is(err, nntp.ErrTLSPinMismatch) {
+			t.Fatalf("Dial error = %v, want ErrTLSPinMismatch", err)
+		}
+	})
+
+	t.Run("no pin", func(t *testing.T) {
+		// Without a pin the self-signed certificate fails normal CA verification.
+		cl, err := nntp.Dial(context.Background(), cfg)
+		if err == nil {
+			_ = cl.Close()
+			t.Fatal("Dial succeeded against an untrusted certificate")
+		}
+		if errors.Is(err, nntp.ErrTLSPinMismatch) {
+			t.Fatalf("Dial error = %v, want a verification error", err)
+		}
+	})
+}
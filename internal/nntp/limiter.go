@@ -0,0 +1,120 @@
+package nntp
+
+import (
+	"context"
+	"sync"
+)
+
+// Limiter caps the number of open NNTP connections across every pool and direct Dial
+// that shares it (Config.Limiter). A slot is held from Dial until Client.Close, so idle
+// pooled connections count too; when the limit is reached, waiters ask the registered
+// pools to close an idle connection before blocking.
+type Limiter struct {
+	mu      sync.Mutex
+	limit   int // <= 0 = unlimited
+	inUse   int
+	waiting int
+	wake    chan struct{} // closed (and replaced) on every release
+	pools   map[*Pool]struct{}
+}
+
+// LimiterStats is a snapshot of a Limiter.
+type LimiterStats struct {
+	Limit   int `json:"limit"`
+	InUse   int `json:"in_use"`
+	Waiting int `json:"waiting"`
+}
+
+var globalLimiter = &Limiter{}
+
+// GlobalLimiter is the process-wide limiter for the download provider, sized from
+// download.connections at startup and on config changes.
+func GlobalLimiter() *Limiter { return globalLimiter }
+
+// SetLimit changes the cap. Raising it wakes waiters; lowering it never closes
+// connections, new dials just wait until usage drops below the new limit.
+func (l *Limiter) SetLimit(n int) {
+	l.mu.Lock()
+	l.limit = n
+	l.broadcastLocked()
+	l.mu.Unlock()
+}
+
+func (l *Limiter) Stats() LimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return LimiterStats{Limit: l.limit, InUse: l.inUse, Waiting: l.waiting}
+}
+
+func (l *Limiter) broadcastLocked() {
+	if l.wake != nil {
+		close(l.wake)
+		l.wake = nil
+	}
+}
+
+// acquire takes a slot, blocking until one is free or ctx is done.
+func (l *Limiter) acquire(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if l.limit <= 0 || l.inUse < l.limit {
+			l.inUse++
+			l.mu.Unlock()
+			return nil
+		}
+		if l.wake == nil {
+			l.wake = make(chan struct{})
+		}
+		wake := l.wake
+		pools := make([]*Pool, 0, len(l.pools))
+		for p := range l.pools {
+			pools = append(pools, p)
+		}
+		l.waiting++
+		l.mu.Unlock()
+
+		// Reclaim an idle connection from any pool; closing it releases a slot.
+		for _, p := range pools {
+			if p.dropIdle() {
+				break
+			}
+		}
+
+		var err error
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		l.mu.Lock()
+		l.waiting--
+		l.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (l *Limiter) release() {
+	l.mu.Lock()
+	if l.inUse > 0 {
+		l.inUse--
+	}
+	l.broadcastLocked()
+	l.mu.Unlock()
+}
+
+func (l *Limiter) register(p *Pool) {
+	l.mu.Lock()
+	if l.pools == nil {
+		l.pools = map[*Pool]struct{}{}
+	}
+	l.pools[p] = struct{}{}
+	l.mu.Unlock()
+}
+
+func (l *Limiter) unregister(p *Pool) {
+	l.mu.Lock()
+	delete(l.pools, p)
+	l.mu.Unlock()
+}
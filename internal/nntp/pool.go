@@ -12,6 +12,7 @@ type Pool struct {
 
 	mu      sync.Mutex
 	created int
+	closed  bool
 	idle    chan *Client
 }
 
@@ -19,10 +20,45 @@ func NewPool(cfg Config, max int) *Pool {
 	if max <= 0 {
 		max = 1
 	}
-	if cfg.Timeout == 0 {
-		cfg.Timeout = 15 * time.Second
+	if cfg.DialTimeout == 0 {
+		cfg.DialTimeout = 10 * time.Second
+	}
+	if cfg.IOTimeout == 0 {
+		cfg.IOTimeout = 60 * time.Second
+	}
+	p := &Pool{cfg: cfg, max: max, idle: make(chan *Client, max)}
+	if cfg.Limiter != nil {
+		cfg.Limiter.register(p)
+	}
+	return p
+}
+
+// dropIdle closes one idle client, if any, so a shared Limiter can hand its slot to
+// another pool.
+func (p *Pool) dropIdle() bool {
+	select {
+	case c := <-p.idle:
+		_ = c.Close()
+		p.mu.Lock()
+		p.created--
+		p.mu.Unlock()
+		return true
+	default:
+		return false
+	}
+}
+
+// Close closes the idle clients and detaches the pool from its Limiter. Clients still
+// checked out are closed when released.
+func (p *Pool) Close() {
+	if p.cfg.Limiter != nil {
+		p.cfg.Limiter.unregister(p)
+	}
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+	for p.dropIdle() {
 	}
-	return &Pool{cfg: cfg, max: max, idle: make(chan *Client, max)}
 }
 
 func (p *Pool) dialAuthed(ctx context.Context) (*Client, error) {
@@ -96,7 +132,17 @@ func (p *Pool) Release(c *Client) {
 	if c == nil {
 		return
 	}
-	// If connection is dead, drop it
+	p.mu.Lock()
+	closed := p.closed
+	p.mu.Unlock()
+	// If the pool is closed or the connection is dead, drop it
+	if closed {
+		_ = c.Close()
+		p.mu.Lock()
+		p.created--
+		p.mu.Unlock()
+		return
+	}
 	if err := c.Noop(); err != nil {
 		_ = c.Close()
 		p.mu.Lock()
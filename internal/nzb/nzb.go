@@ -2,16 +2,38 @@ package nzb
 
 import (
 	"encoding/xml"
+	"fmt"
 	"io"
+	"strconv"
+	"strings"
 )
 
 // Minimal NZB parser.
 // We only need file subjects and segment sizes/ids for now.
 
 type NZB struct {
+	Head  []Meta `xml:"head>meta"`
 	Files []File `xml:"file"`
 }
 
+// Meta is a <head><meta type="...">value</meta> entry (category, password, name, ...).
+type Meta struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// Meta returns the first non-empty head value of the given type (case-insensitive).
+func (n *NZB) Meta(typ string) string {
+	for _, m := range n.Head {
+		if strings.EqualFold(strings.TrimSpace(m.Type), typ) {
+			if v := strings.TrimSpace(m.Value); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
 type File struct {
 	Poster   string    `xml:"poster,attr"`
 	Subject  string    `xml:"subject,attr"`
@@ -34,3 +56,136 @@ func Parse(r io.Reader) (*NZB, error) {
 	}
 	return &doc, nil
 }
+
+// Limits bounds what ParseLimited accepts from an untrusted NZB. Zero means unlimited.
+type Limits struct {
+	MaxFiles           int
+	MaxSegmentsPerFile int
+}
+
+// ParseLimited is Parse with Limits enforced while decoding, so an oversized NZB
+// fails before it is fully loaded in memory.
+func ParseLimited(r io.Reader, lim Limits) (*NZB, error) {
+	if lim.MaxFiles <= 0 && lim.MaxSegmentsPerFile <= 0 {
+		return Parse(r)
+	}
+	var doc NZB
+	head, err := Walk(r, lim, func(f File) error {
+		doc.Files = append(doc.Files, f)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	doc.Head = head
+	return &doc, nil
+}
+
+// Walk decodes r one <file> at a time and calls fn with each as soon as it is read, so
+// only one file's segments are held in memory (see import.stream_batch_segments). It
+// returns the <head> metadata. Limits are enforced as in ParseLimited; an error from fn
+// stops the walk and is returned as is.
+func Walk(r io.Reader, lim Limits, fn func(File) error) ([]Meta, error) {
+	dec := xml.NewDecoder(r)
+	var head []Meta
+	files := 0
+	depth := 0     // elements open around the current token
+	headDepth := 0 // depth of the open <head>, 0 outside it
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ee, ok := tok.(xml.EndElement); ok {
+			if ee.Name.Local == "head" && depth == headDepth {
+				headDepth = 0
+			}
+			depth--
+			continue
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		depth++
+		switch {
+		case se.Name.Local == "head" && headDepth == 0:
+			headDepth = depth
+			continue
+		case se.Name.Local == "meta" && headDepth > 0 && depth == headDepth+1:
+			// Only <head><meta>, as in Parse; a <meta> anywhere else is not head metadata.
+			var m Meta
+			if err := dec.DecodeElement(&m, &se); err != nil {
+				return nil, err
+			}
+			depth--
+			head = append(head, m)
+			continue
+		case se.Name.Local != "file":
+			continue
+		}
+		depth--
+		if lim.MaxFiles > 0 && files >= lim.MaxFiles {
+			return nil, fmt.Errorf("nzb has more than %d files (import.max_files_per_nzb)", lim.MaxFiles)
+		}
+		f, err := decodeFileLimited(dec, se, lim.MaxSegmentsPerFile)
+		if err != nil {
+			return nil, err
+		}
+		files++
+		if err := fn(f); err != nil {
+			return nil, err
+		}
+	}
+	return head, nil
+}
+
+func decodeFileLimited(dec *xml.Decoder, start xml.StartElement, maxSegs int) (File, error) {
+	var f File
+	for _, a := range start.Attr {
+		switch a.Name.Local {
+		case "poster":
+			f.Poster = a.Value
+		case "subject":
+			f.Subject = a.Value
+		case "date":
+			f.Date, _ = strconv.ParseInt(a.Value, 10, 64)
+		}
+	}
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return f, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "group":
+				var g string
+				if err := dec.DecodeElement(&g, &t); err != nil {
+					return f, err
+				}
+				f.Groups = append(f.Groups, g)
+			case "segment":
+				if maxSegs > 0 && len(f.Segments) >= maxSegs {
+					return f, fmt.Errorf("nzb file %q has more than %d segments (import.max_segments_per_file)", f.Subject, maxSegs)
+				}
+				var s Segment
+				if err := dec.DecodeElement(&s, &t); err != nil {
+					return f, err
+				}
+				f.Segments = append(f.Segments, s)
+			}
+		case xml.EndElement:
+			if t.Name.Local == start.Name.Local {
+				return f, nil
+			}
+		}
+	}
+}
@@ -2,10 +2,13 @@ package plex
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -73,6 +76,95 @@ func (c *Client) RefreshPath(ctx context.Context, plexPath string, fallbackFile
 	return fmt.Errorf("plex refresh failed")
 }
 
+// Section is a Plex library section as returned by /library/sections.
+type Section struct {
+	ID        int      `json:"id"`
+	Title     string   `json:"title"`
+	Type      string   `json:"type"` // movie|show|artist|photo
+	Locations []string `json:"locations"`
+}
+
+// Sections lists the Plex library sections with their root folders.
+func (c *Client) Sections(ctx context.Context) ([]Section, error) {
+	if !c.Enabled() {
+		return nil, fmt.Errorf("plex not configured")
+	}
+	u, err := url.Parse(c.BaseURL + "/library/sections")
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("X-Plex-Token", c.Token)
+	u.RawQuery = q.Encode()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("plex sections status=%d", resp.StatusCode)
+	}
+	var body struct {
+		MediaContainer struct {
+			Directory []struct {
+				Key      string `json:"key"`
+				Title    string `json:"title"`
+				Type     string `json:"type"`
+				Location []struct {
+					Path string `json:"path"`
+				} `json:"Location"`
+			} `json:"Directory"`
+		} `json:"MediaContainer"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(&body); err != nil {
+		return nil, err
+	}
+	out := make([]Section, 0, len(body.MediaContainer.Directory))
+	for _, d := range body.MediaContainer.Directory {
+		id, err := strconv.Atoi(d.Key)
+		if err != nil {
+			continue
+		}
+		sec := Section{ID: id, Title: d.Title, Type: d.Type}
+		for _, l := range d.Location {
+			sec.Locations = append(sec.Locations, l.Path)
+		}
+		out = append(out, sec)
+	}
+	return out, nil
+}
+
+// RefreshSection asks Plex to scan a whole library section.
+func (c *Client) RefreshSection(ctx context.Context, sectionID int) error {
+	if !c.Enabled() {
+		return fmt.Errorf("plex not configured")
+	}
+	if sectionID <= 0 {
+		return fmt.Errorf("invalid plex section id")
+	}
+	u, err := url.Parse(fmt.Sprintf("%s/library/sections/%d/refresh", c.BaseURL, sectionID))
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	q.Set("X-Plex-Token", c.Token)
+	u.RawQuery = q.Encode()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("plex section refresh status=%d", resp.StatusCode)
+	}
+	return nil
+}
+
 func (c *Client) refreshOnce(ctx context.Context, plexPath string) error {
 	u, err := url.Parse(c.BaseURL + "/library/sections/all/refresh")
 	if err != nil {
@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gaby/EDRmount/internal/cache"
+	"github.com/gaby/EDRmount/internal/config"
+	"github.com/gaby/EDRmount/internal/jobs"
+	"github.com/gaby/EDRmount/internal/streamer"
+)
+
+// runDecodedSizes records the decoded size of every segment of an import
+// (decoded_sizes_import, queued after the import when import.precompute_decoded_sizes)
+// so its streams get exact offsets. Segments missing on the server are left unknown.
+func (r *Runner) runDecodedSizes(ctx context.Context, j *jobs.Job) {
+	var p struct {
+		ImportID string `json:"import_id"`
+	}
+	if err := json.Unmarshal(j.Payload, &p); err != nil || p.ImportID == "" {
+		_ = r.jobs.SetFailed(ctx, j.ID, "invalid payload")
+		return
+	}
+	cfg := config.Default()
+	if r.GetConfig != nil {
+		cfg = r.GetConfig()
+	}
+	if !cfg.Download.Enabled {
+		msg := "decoded sizes: download provider disabled"
+		_ = r.jobs.AppendLog(ctx, j.ID, "ERROR: "+msg)
+		_ = r.jobs.SetFailed(ctx, j.ID, msg)
+		return
+	}
+
+	st := streamer.New(cfg.Download, r.jobs, cfg.Paths.CacheDir, cfg.Paths.CacheMaxBytes)
+	st.Eviction = cache.NewEviction(cfg.Paths.CacheEviction, cfg.Paths.CachePinnedImports)
+	start := time.Now()
+	lastPct := -1
+	measured, missing, err := st.MeasureDecodedSizes(ctx, p.ImportID, func(done, total int) {
+		if pct := done * 100 / total; pct != lastPct {
+			lastPct = pct
+			_ = r.jobs.AppendLog(ctx, j.ID, fmt.Sprintf("PROGRESS: %d", pct))
+		}
+	})
+	if err != nil {
+		msg := fmt.Sprintf("decoded sizes: %v (%d segment(s) recorded, rerun to finish)", err, measured)
+		_ = r.jobs.AppendLog(ctx, j.ID, "ERROR: "+msg)
+		_ = r.jobs.SetFailed(ctx, j.ID, msg)
+		return
+	}
+	_ = r.jobs.AppendLog(ctx, j.ID, fmt.Sprintf("decoded sizes: %d segment(s) recorded, %d missing on server (%s)", measured, missing, time.Since(start).Round(time.Second)))
+	_ = r.jobs.SetDone(ctx, j.ID)
+}
@@ -23,7 +23,7 @@ func maybeNormalizeWithFileBot(ctx context.Context, cfg config.Config, inputPath
 	}
 	bin := strings.TrimSpace(rn.FileBot.Binary)
 	if bin == "" {
-		bin = "/usr/local/bin/filebot"
+		bin = config.DefaultFileBotBinary
 	}
 	if _, err := os.Stat(bin); err != nil {
 		return inputPath, false, fmt.Errorf("binary not found: %s", bin)
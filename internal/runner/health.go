@@ -11,11 +11,14 @@ import (
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gaby/EDRmount/internal/config"
 	"github.com/gaby/EDRmount/internal/importer"
+	"github.com/gaby/EDRmount/internal/jobs"
 	"github.com/gaby/EDRmount/internal/nntp"
 	"github.com/gaby/EDRmount/internal/nzb"
 	"github.com/gaby/EDRmount/internal/yenc"
@@ -25,6 +28,27 @@ type healthRepairPayload struct {
 	Path string `json:"path"`
 }
 
+// errHealthMissingParity marks a repair that cannot run because no PAR2 set exists, local
+// or in the NZB.
+// It is recorded as status "missing-parity" so the UI can tell it apart from a failed repair.
+var errHealthMissingParity = errors.New("health repair: no PAR2 found for this NZB (B2 requires keep-local par2 or parity uploaded with the release)")
+
+// par2ShortfallError is a "par2 r" that found too few recovery blocks. It is recorded as
+// status "unrepairable" (with the shortfall in last_error): retrying won't help unless
+// the missing segments turn up on another provider (health.repair_providers).
+type par2ShortfallError struct {
+	Need      int // more recovery blocks par2 asked for
+	Available int // data blocks present (-1 = not reported)
+	Total     int // data blocks in the set (-1 = not reported)
+}
+
+func (e *par2ShortfallError) Error() string {
+	if e.Total >= 0 {
+		return fmt.Sprintf("health: par2 repair not possible: need %d more recovery block(s) (%d/%d data blocks available)", e.Need, e.Available, e.Total)
+	}
+	return fmt.Sprintf("health: par2 repair not possible: need %d more recovery block(s)", e.Need)
+}
+
 func (r *Runner) runHealthRepair(ctx context.Context, jobID string, cfg config.Config, payload healthRepairPayload) (retErr error) {
 	if !cfg.Health.Enabled {
 		return errors.New("health repair: disabled by config (health.enabled=false)")
@@ -36,6 +60,15 @@ func (r *Runner) runHealthRepair(ctx context.Context, jobID string, cfg config.C
 	}
 	_ = r.upsertHealthState(ctx, nzbPath, "repairing", time.Now().Unix(), 0, "", jobID)
 	defer func() {
+		if errors.Is(retErr, errHealthMissingParity) {
+			_ = r.upsertHealthState(ctx, nzbPath, "missing-parity", 0, 0, retErr.Error(), jobID)
+			return
+		}
+		var shortfall *par2ShortfallError
+		if errors.As(retErr, &shortfall) {
+			_ = r.upsertHealthState(ctx, nzbPath, "unrepairable", 0, 0, retErr.Error(), jobID)
+			return
+		}
 		if retErr != nil {
 			_ = r.upsertHealthState(ctx, nzbPath, "error", 0, 0, retErr.Error(), jobID)
 			return
@@ -77,7 +110,7 @@ func (r *Runner) runHealthRepair(ctx context.Context, jobID string, cfg config.C
 		return fmt.Errorf("read nzb: %w", err)
 	}
 
-	// Parse NZB (we only handle a single MKV for now)
+	// Parse NZB; every MKV in it is checked and repaired (season packs carry several).
 	f, err := os.Open(workNZB)
 	if err != nil {
 		return err
@@ -87,55 +120,17 @@ func (r *Runner) runHealthRepair(ctx context.Context, jobID string, cfg config.C
 	if err != nil {
 		return fmt.Errorf("parse nzb: %w", err)
 	}
-
-	// Pick first file that looks like an MKV.
-	fileIdx := -1
-	for i := range doc.Files {
-		subj := strings.ToLower(doc.Files[i].Subject)
-		if strings.Contains(subj, ".mkv") {
-			fileIdx = i
-			break
-		}
-	}
-	if fileIdx < 0 {
+	targets := healthRepairTargets(doc)
+	if len(targets) == 0 {
 		return errors.New("health repair: no MKV file found in NZB")
 	}
-	file := doc.Files[fileIdx]
+	_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("health: mkv file(s)=%d", len(targets)))
 
-	// Extract original filename from subject.
-	// Common forms include: "\"name.mkv\" yEnc". Fall back to a safe name.
-	mkvName := "recovered.mkv"
-	if m := regexp.MustCompile(`"([^"]+\.mkv)"`).FindStringSubmatch(file.Subject); len(m) == 2 {
-		mkvName = m[1]
-	} else if m := regexp.MustCompile(`([^\s]+\.mkv)`).FindStringSubmatch(file.Subject); len(m) == 2 {
-		mkvName = filepath.Base(m[1])
-	}
-
-	// Link/copy PAR2 set into workdir (keep-local). This is mandatory for B2.
+	// Link/copy PAR2 sets into workdir (keep-local). This is mandatory for B2.
+	// A release has either one set named after the NZB or one set per file.
 	parRoot := filepath.Join("/host", "inbox", "par2")
 	stem := strings.TrimSuffix(baseName, filepath.Ext(baseName))
 
-	norm := func(s string) string {
-		s = strings.ToLower(s)
-		b := make([]byte, 0, len(s))
-		dash := false
-		for i := 0; i < len(s); i++ {
-			c := s[i]
-			ok := (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9')
-			if ok {
-				b = append(b, c)
-				dash = false
-				continue
-			}
-			if !dash {
-				b = append(b, '-')
-				dash = true
-			}
-		}
-		out := strings.Trim(string(b), "-")
-		return out
-	}
-
 	// Allow test suffixes like ".FORCE" to still match existing PAR2 filenames.
 	stemMatch := stem
 	low := strings.ToLower(stemMatch)
@@ -143,7 +138,13 @@ func (r *Runner) runHealthRepair(ctx context.Context, jobID string, cfg config.C
 		stemMatch = stemMatch[:len(stemMatch)-len(".force")]
 	}
 
-	want := norm(stemMatch)
+	want := healthNorm(stemMatch)
+	wants := []string{want}
+	for _, t := range targets {
+		if w := healthNorm(strings.TrimSuffix(t.Name, filepath.Ext(t.Name))); w != "" {
+			wants = append(wants, w)
+		}
+	}
 	parCount := 0
 	_ = filepath.WalkDir(parRoot, func(p string, d os.DirEntry, err error) error {
 		if err != nil {
@@ -156,8 +157,15 @@ func (r *Runner) runHealthRepair(ctx context.Context, jobID string, cfg config.C
 		if !strings.HasSuffix(n, ".par2") {
 			return nil
 		}
-		base := strings.TrimSuffix(d.Name(), filepath.Ext(d.Name()))
-		if !strings.HasPrefix(norm(base), want) {
+		base := healthNorm(strings.TrimSuffix(d.Name(), filepath.Ext(d.Name())))
+		matched := false
+		for _, w := range wants {
+			if strings.HasPrefix(base, w) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
 			return nil
 		}
 		dst := filepath.Join(workDir, d.Name())
@@ -176,137 +184,141 @@ func (r *Runner) runHealthRepair(ctx context.Context, jobID string, cfg config.C
 	})
 	_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("health: linked par2 file(s)=%d", parCount))
 	if parCount == 0 {
-		return errors.New("health repair: no local PAR2 found for this NZB (B2 requires keep-local par2)")
+		// No local set: use the parity posted with the release (upload.par.upload_parity), if any.
+		n, err := r.healthFetchNZBPar2(ctx, jobID, cfg, doc, workDir)
+		if err != nil {
+			_ = r.jobs.AppendLog(ctx, jobID, "health: nzb par2 download WARN: "+err.Error())
+		}
+		parCount = n
+	}
+	if parCount == 0 {
+		// Without parity we can't repair; report exactly which segments are gone instead.
+		gone, total := 0, 0
+		for _, t := range targets {
+			missing, err := healthMissingSegments(ctx, cfg, t.File.Segments)
+			if err != nil {
+				_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("health: segment check failed: %v", err))
+				return errHealthMissingParity
+			}
+			_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("health: %s: missing segments=%d/%d: %s", t.Name, len(missing), len(t.File.Segments), formatSegmentNumbers(missing, 50)))
+			gone += len(missing)
+			total += len(t.File.Segments)
+		}
+		return fmt.Errorf("%w: %d/%d segments missing", errHealthMissingParity, gone, total)
 	}
 
-	// Download segments (or zero-fill missing) into a local file so par2 can repair it.
+	// Download segments (or zero-fill missing) into local files so par2 can repair them.
 	// This is intentionally simple: sequential download, one NNTP client.
-	pool := nntp.NewPool(nntp.Config{Host: cfg.Download.Host, Port: cfg.Download.Port, SSL: cfg.Download.SSL, User: cfg.Download.User, Pass: cfg.Download.Pass, Timeout: 30 * time.Second}, cfg.Download.Connections)
+	pool := nntp.NewPool(cfg.Download.NNTPConfig(), cfg.Download.Connections)
 	cl, err := pool.Acquire(ctx)
 	if err != nil {
+		pool.Close()
 		return fmt.Errorf("health: nntp acquire: %w", err)
 	}
-	defer pool.Release(cl)
-
-	// Sort segments by number
-	segs := make([]nzb.Segment, 0, len(file.Segments))
-	segs = append(segs, file.Segments...)
-	sort.Slice(segs, func(i, j int) bool { return segs[i].Number < segs[j].Number })
-
-	outFile := filepath.Join(workDir, mkvName)
-	_ = os.Remove(outFile)
-	wf, err := os.OpenFile(outFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
-	if err != nil {
-		return err
+	// The connection goes back before repairing: the repair provider retry takes its own
+	// global limiter slot, and with download.connections=1 it would wait on this one.
+	var poolDone sync.Once
+	closePool := func() {
+		poolDone.Do(func() {
+			pool.Close()
+			pool.Release(cl)
+		})
 	}
-	defer func() { _ = wf.Close() }()
+	defer closePool()
 
-	missing := 0
-	for i, s := range segs {
-		id := strings.TrimSpace(s.ID)
-		if i%200 == 0 {
-			_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("health: downloading segments... %d/%d (missing=%d)", i, len(segs), missing))
+	damaged := 0
+	for _, t := range targets {
+		t.Path = filepath.Join(workDir, t.Name)
+		if err := r.healthDownloadFile(ctx, jobID, cl, t); err != nil {
+			return err
 		}
-		lines, err := cl.BodyByMessageID(id)
-		if err != nil {
-			missing++
-			// zero-fill
-			_, _ = wf.Write(make([]byte, int(s.Bytes)))
-			continue
+		_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("health: %s: missing segments=%d/%d", t.Name, t.Missing, len(t.File.Segments)))
+		if t.Missing > 0 {
+			damaged++
 		}
-		data, _, _, _, err := yenc.DecodePart(lines)
-		if err != nil {
-			missing++
-			_, _ = wf.Write(make([]byte, int(s.Bytes)))
-			continue
-		}
-		_, _ = wf.Write(data)
 	}
-	_ = wf.Sync()
-	_ = wf.Close()
+	closePool()
 
-	if missing == 0 {
+	if damaged == 0 {
 		_ = r.jobs.AppendLog(ctx, jobID, "health: no missing segments detected; leaving NZB unchanged")
 		return nil
 	}
-	_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("health: missing segments=%d (attempting PAR2 repair)", missing))
+	_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("health: damaged file(s)=%d/%d (attempting PAR2 repair)", damaged, len(targets)))
 
-	// Find the main .par2 file (prefer one that is not a volume file)
-	parMain := ""
-	entries, _ := os.ReadDir(workDir)
-	for _, e := range entries {
-		n := strings.ToLower(e.Name())
-		if strings.HasSuffix(n, ".par2") && !strings.Contains(n, ".vol") {
-			parMain = filepath.Join(workDir, e.Name())
-			break
-		}
-	}
-	if parMain == "" {
-		// fallback: any par2
-		for _, e := range entries {
-			if strings.HasSuffix(strings.ToLower(e.Name()), ".par2") {
-				parMain = filepath.Join(workDir, e.Name())
-				break
-			}
-		}
-	}
-	if parMain == "" {
+	mains := healthPar2Mains(workDir)
+	if len(mains) == 0 {
 		return errors.New("health: PAR2 files were linked but main .par2 not found")
 	}
+	assignPar2Sets(mains, targets, want)
 
-	// par2 may expect the original relative target path embedded in PAR2 (e.g. host/inbox/media/...).
-	// Mirror that target in workdir pointing to our reconstructed outFile to avoid "Target ... missing".
-	expectedRel := filepath.Join("host", "inbox", "media", filepath.Base(outFile))
-	expectedAbs := filepath.Join(workDir, expectedRel)
-	_ = os.MkdirAll(filepath.Dir(expectedAbs), 0o755)
-	_ = os.Remove(expectedAbs)
-	if err := os.Symlink(outFile, expectedAbs); err != nil {
-		_ = copyFilePerm(outFile, expectedAbs, 0o644)
+	// par2 expects the original relative target paths embedded in the set (e.g. host/inbox/media/...).
+	// Mirror those targets in workdir pointing to our reconstructed files to avoid "Target ... missing".
+	for _, t := range targets {
+		expectedRel := healthMapPar2Target(workDir, t)
+		_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("health: par2 target mapped: %s -> %s (set %s)", expectedRel, t.Name, filepath.Base(t.Par)))
 	}
-	_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("health: par2 target mapped: %s -> %s", expectedRel, filepath.Base(outFile)))
 
-	// par2 repair in-place
-	_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("health: par2 repair: %s r %s", "/usr/bin/par2", filepath.Base(parMain)))
-	// IMPORTANT: do not pass an alternate target filename here; let PAR2 use its own indexed target paths.
-	cmd := exec.CommandContext(ctx, "par2", "r", parMain)
-	cmd.Dir = workDir
-	stdout, _ := cmd.StdoutPipe()
-	stderr, _ := cmd.StderrPipe()
-	if err := cmd.Start(); err != nil {
-		return err
-	}
-	scanPipe := func(prefix string, rc io.ReadCloser) {
-		defer func() { _ = rc.Close() }()
-		s := bufio.NewScanner(rc)
-		for s.Scan() {
-			_ = r.jobs.AppendLog(ctx, jobID, prefix+s.Text())
+	// Repair each set that covers a damaged file once; a shared set repairs all its files in one run.
+	setErr := map[string]error{}
+	for _, t := range targets {
+		if t.Missing == 0 {
+			continue
+		}
+		if _, done := setErr[t.Par]; done {
+			continue
+		}
+		err := r.healthPar2Repair(ctx, jobID, workDir, t.Par)
+		var shortfall *par2ShortfallError
+		if errors.As(err, &shortfall) && len(cfg.Health.RepairProviders) > 0 {
+			err = r.healthRetryFromProviders(ctx, jobID, cfg, workDir, targets, t.Par, err)
+		}
+		if err == nil && cfg.Health.VerifyAfterRepair {
+			err = r.healthVerifyPAR2(ctx, jobID, workDir, t.Par)
+		}
+		setErr[t.Par] = err
+	}
+	var failed []error
+	for _, t := range targets {
+		switch {
+		case t.Missing == 0:
+			_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("health: file %s: intact", t.Name))
+		case setErr[t.Par] != nil:
+			_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("health: file %s: repair FAILED (%d segments missing): %v", t.Name, t.Missing, setErr[t.Par]))
+			failed = append(failed, setErr[t.Par])
+		default:
+			_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("health: file %s: repaired (%d segments)", t.Name, t.Missing))
 		}
 	}
-	if stdout != nil {
-		go scanPipe("", stdout)
+	// Publishing needs every file good: the new NZB replaces the whole release.
+	if len(failed) == 1 {
+		return failed[0]
 	}
-	if stderr != nil {
-		go scanPipe("ERR: ", stderr)
-	}
-	if err := cmd.Wait(); err != nil {
-		return fmt.Errorf("health: par2 repair failed: %w", err)
+	if len(failed) > 1 {
+		return fmt.Errorf("health: %d/%d damaged file(s) could not be repaired (workdir kept: %s): %w", len(failed), damaged, workDir, failed[0])
 	}
 
-	// Now re-upload the repaired MKV and generate a CLEAN NZB (no PAR2 included).
-	repairedNZBTmp := filepath.Join(workDir, stem+".repaired.nzb")
-	_ = os.Remove(repairedNZBTmp)
-	if err := r.healthUploadCleanNZB(ctx, jobID, cfg, outFile, repairedNZBTmp); err != nil {
-		return err
+	// A single file is published as-is; several go out as one folder, like the original pack upload.
+	outFile := targets[0].Path
+	if len(targets) > 1 {
+		outFile = filepath.Join(workDir, "release", stem)
+		if err := os.MkdirAll(outFile, 0o755); err != nil {
+			return err
+		}
+		for _, t := range targets {
+			if err := os.Rename(t.Path, filepath.Join(outFile, t.Name)); err != nil {
+				return fmt.Errorf("health: collect repaired files: %w", err)
+			}
+		}
 	}
 
-	// Replace original NZB (backup original first)
+	// Backup location for the original NZB
 	bakRoot := strings.TrimSpace(cfg.Health.BackupDir)
 	if bakRoot == "" {
 		bakRoot = "/cache/health-bak"
 	}
-	outRoot := strings.TrimSpace(cfg.NgPost.OutputDir)
-	if outRoot == "" {
-		outRoot = "/host/inbox/nzb"
+	outRoot, ok := cfg.NZBRootFor(nzbPath)
+	if !ok {
+		outRoot = cfg.NZBOutputDir(false)
 	}
 	rel, _ := filepath.Rel(outRoot, nzbPath)
 	if strings.HasPrefix(rel, "..") {
@@ -317,6 +329,30 @@ func (r *Runner) runHealthRepair(ctx context.Context, jobID string, cfg config.C
 		return err
 	}
 
+	// Both ways out re-upload the repaired media; without the upload role keep the
+	// original NZB (and the repaired files) instead.
+	if !cfg.Runner.HasRole(config.RoleUpload) {
+		return fmt.Errorf("health: repaired media not re-uploaded, upload role disabled (runner.roles); original NZB kept, workdir kept: %s", workDir)
+	}
+	if !cfg.Health.ReuploadAfterRepair {
+		if err := r.healthHandoffToMediaInbox(ctx, cfg, jobID, nzbPath, outFile, bakPath); err != nil {
+			return err
+		}
+		if err := os.RemoveAll(workDir); err == nil {
+			_ = r.jobs.AppendLog(ctx, jobID, "health: cleaned workdir")
+		}
+		_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("health: repaired OK, re-upload via media watcher (backup=%s)", bakPath))
+		return nil
+	}
+
+	// Re-upload the repaired MKV and generate a CLEAN NZB (no PAR2 included).
+	repairedNZBTmp := filepath.Join(workDir, stem+".repaired.nzb")
+	_ = os.Remove(repairedNZBTmp)
+	if err := r.healthUploadCleanNZB(ctx, jobID, cfg, outFile, repairedNZBTmp); err != nil {
+		return err
+	}
+
+	// Replace original NZB (backup original first)
 	destTmp := nzbPath + ".health.tmp"
 	if err := copyFilePerm(repairedNZBTmp, destTmp, 0o644); err != nil {
 		return fmt.Errorf("copy repaired nzb: %w", err)
@@ -351,6 +387,238 @@ func (r *Runner) runHealthRepair(ctx context.Context, jobID string, cfg config.C
 	return nil
 }
 
+// healthTarget is one MKV of the NZB being repaired.
+type healthTarget struct {
+	Name    string   // file name (from the NZB subject)
+	File    nzb.File // segments sorted by number
+	Path    string   // reconstructed copy in the workdir
+	Missing int      // segments that could not be downloaded (zero-filled)
+	Parts   []int64  // bytes written per segment; negative = zero-filled
+	Par     string   // main .par2 of the set covering it
+	ParRel  string   // path of the file inside that set, when the set lists it
+}
+
+var (
+	reHealthQuotedMKV  = regexp.MustCompile(`"([^"]+\.mkv)"`)
+	reHealthBareMKV    = regexp.MustCompile(`([^\s]+\.mkv)`)
+	reHealthQuotedPar2 = regexp.MustCompile(`(?i)"([^"]+\.par2)"`)
+	reHealthBarePar2   = regexp.MustCompile(`(?i)([^\s"]+\.par2)`)
+)
+
+// healthRepairTargets returns every MKV of doc, in NZB order, with unique file names.
+func healthRepairTargets(doc *nzb.NZB) []*healthTarget {
+	out := make([]*healthTarget, 0)
+	seen := map[string]int{}
+	for _, file := range doc.Files {
+		if !strings.Contains(strings.ToLower(file.Subject), ".mkv") {
+			continue
+		}
+		// Extract original filename from subject.
+		// Common forms include: "\"name.mkv\" yEnc". Fall back to a safe name.
+		name := "recovered.mkv"
+		if m := reHealthQuotedMKV.FindStringSubmatch(file.Subject); len(m) == 2 {
+			name = filepath.Base(m[1])
+		} else if m := reHealthBareMKV.FindStringSubmatch(file.Subject); len(m) == 2 {
+			name = filepath.Base(m[1])
+		}
+		seen[name]++
+		if n := seen[name]; n > 1 {
+			ext := filepath.Ext(name)
+			name = fmt.Sprintf("%s.%d%s", strings.TrimSuffix(name, ext), n, ext)
+		}
+		segs := append([]nzb.Segment(nil), file.Segments...)
+		sort.Slice(segs, func(i, j int) bool { return segs[i].Number < segs[j].Number })
+		file.Segments = segs
+		out = append(out, &healthTarget{Name: name, File: file})
+	}
+	return out
+}
+
+// healthDownloadFile writes t's segments to t.Path, zero-filling the ones that cannot be
+// fetched or decoded, and counts them in t.Missing.
+func (r *Runner) healthDownloadFile(ctx context.Context, jobID string, cl *nntp.Client, t *healthTarget) error {
+	_ = os.Remove(t.Path)
+	wf, err := os.OpenFile(t.Path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = wf.Close() }()
+
+	segs := t.File.Segments
+	t.Missing = 0
+	t.Parts = make([]int64, 0, len(segs))
+	for i, s := range segs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		id := strings.TrimSpace(s.ID)
+		if i%200 == 0 {
+			_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("health: %s: downloading segments... %d/%d (missing=%d)", t.Name, i, len(segs), t.Missing))
+		}
+		lines, err := cl.BodyByMessageID(id)
+		if err != nil {
+			t.Missing++
+			// zero-fill
+			_, _ = wf.Write(make([]byte, int(s.Bytes)))
+			t.Parts = append(t.Parts, -s.Bytes)
+			continue
+		}
+		data, _, _, _, err := yenc.DecodePart(lines)
+		if err != nil {
+			t.Missing++
+			_, _ = wf.Write(make([]byte, int(s.Bytes)))
+			t.Parts = append(t.Parts, -s.Bytes)
+			continue
+		}
+		_, _ = wf.Write(data)
+		t.Parts = append(t.Parts, int64(len(data)))
+	}
+	_ = wf.Sync()
+	return wf.Close()
+}
+
+// healthFetchNZBPar2 downloads the .par2 files listed in doc (posted with the release by
+// upload.par.upload_parity) into workDir and returns how many came back at least in part;
+// par2 reads the blocks of a damaged volume that are still intact.
+func (r *Runner) healthFetchNZBPar2(ctx context.Context, jobID string, cfg config.Config, doc *nzb.NZB, workDir string) (int, error) {
+	var pars []*healthTarget
+	for _, file := range doc.Files {
+		name := ""
+		if m := reHealthQuotedPar2.FindStringSubmatch(file.Subject); len(m) == 2 {
+			name = filepath.Base(m[1])
+		} else if m := reHealthBarePar2.FindStringSubmatch(file.Subject); len(m) == 2 {
+			name = filepath.Base(m[1])
+		}
+		if name == "" {
+			continue
+		}
+		segs := append([]nzb.Segment(nil), file.Segments...)
+		sort.Slice(segs, func(i, j int) bool { return segs[i].Number < segs[j].Number })
+		file.Segments = segs
+		pars = append(pars, &healthTarget{Name: name, File: file, Path: filepath.Join(workDir, name)})
+	}
+	if len(pars) == 0 {
+		return 0, nil
+	}
+	_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("health: no local par2, downloading %d par2 file(s) from the NZB", len(pars)))
+
+	cl, err := nntp.Dial(ctx, cfg.Download.NNTPConfig())
+	if err != nil {
+		return 0, err
+	}
+	defer cl.Close()
+	if err := cl.Auth(); err != nil {
+		return 0, err
+	}
+	got := 0
+	for _, t := range pars {
+		if err := r.healthDownloadFile(ctx, jobID, cl, t); err != nil {
+			return got, err
+		}
+		if t.Missing == len(t.File.Segments) {
+			_ = os.Remove(t.Path)
+			continue
+		}
+		got++
+	}
+	_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("health: downloaded par2 file(s)=%d/%d", got, len(pars)))
+	return got, nil
+}
+
+// healthPar2Repair runs "par2 r" for one set in workDir, streaming its output to the job log.
+func (r *Runner) healthPar2Repair(ctx context.Context, jobID, workDir, parMain string) error {
+	_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("health: par2 repair: %s r %s", "/usr/bin/par2", filepath.Base(parMain)))
+	// IMPORTANT: do not pass an alternate target filename here; let PAR2 use its own indexed target paths.
+	cmd := exec.CommandContext(ctx, "par2", "r", parMain)
+	cmd.Dir = workDir
+	stdout, _ := cmd.StdoutPipe()
+	stderr, _ := cmd.StderrPipe()
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	var (
+		mu  sync.Mutex
+		out strings.Builder
+		wg  sync.WaitGroup
+	)
+	scanPipe := func(prefix string, rc io.ReadCloser) {
+		defer wg.Done()
+		s := bufio.NewScanner(rc)
+		for s.Scan() {
+			_ = r.jobs.AppendLog(ctx, jobID, prefix+s.Text())
+			mu.Lock()
+			out.WriteString(s.Text() + "\n")
+			mu.Unlock()
+		}
+	}
+	if stdout != nil {
+		wg.Add(1)
+		go scanPipe("", stdout)
+	}
+	if stderr != nil {
+		wg.Add(1)
+		go scanPipe("ERR: ", stderr)
+	}
+	wg.Wait()
+	if err := cmd.Wait(); err != nil {
+		res := parsePar2Verify(out.String(), err)
+		if m := rePar2Need.FindStringSubmatch(out.String()); len(m) == 2 {
+			need, _ := strconv.Atoi(m[1])
+			return &par2ShortfallError{Need: need, Available: res.Available, Total: res.Total}
+		}
+		return fmt.Errorf("health: par2 repair failed: %w", err)
+	}
+	return nil
+}
+
+// healthMissingSegments STATs every segment and returns the numbers missing on the server.
+func healthMissingSegments(ctx context.Context, cfg config.Config, segs []nzb.Segment) ([]int, error) {
+	cl, err := nntp.Dial(ctx, cfg.Download.NNTPConfig())
+	if err != nil {
+		return nil, err
+	}
+	defer cl.Close()
+	if err := cl.Auth(); err != nil {
+		return nil, err
+	}
+
+	missing := make([]int, 0)
+	for _, s := range segs {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		err := cl.StatByMessageID(strings.TrimSpace(s.ID))
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, nntp.ErrArticleNotFound) {
+			return nil, err
+		}
+		missing = append(missing, s.Number)
+	}
+	sort.Ints(missing)
+	return missing, nil
+}
+
+// formatSegmentNumbers renders up to max segment numbers, e.g. "3,4,9 (+12 more)".
+func formatSegmentNumbers(nums []int, max int) string {
+	if len(nums) == 0 {
+		return "none"
+	}
+	parts := make([]string, 0, max)
+	for i, n := range nums {
+		if i >= max {
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%d", n))
+	}
+	out := strings.Join(parts, ",")
+	if len(nums) > max {
+		out += fmt.Sprintf(" (+%d more)", len(nums)-max)
+	}
+	return out
+}
+
 func (r *Runner) upsertHealthState(ctx context.Context, path, status string, lastCheckedAt, lastRepairedAt int64, lastError, repairJobID string) error {
 	if r.jobs == nil || r.jobs.DB() == nil || r.jobs.DB().SQL == nil {
 		return errors.New("jobs db not configured")
@@ -368,44 +636,176 @@ func (r *Runner) upsertHealthState(ctx context.Context, path, status string, las
 }
 
 func (r *Runner) healthRefreshImportDB(ctx context.Context, cfg config.Config, jobID, nzbPath string) error {
+	// The re-import keeps the import id, the user notes and the collection membership
+	// of the release it replaces.
+	var oldImportID, notes string
+	type collectionItem struct {
+		collectionID string
+		fileIdx      int
+		addedAt      int64
+	}
+	var collected []collectionItem
+	if r.jobs != nil {
+		_ = r.jobs.DB().SQL.QueryRowContext(ctx, `SELECT id, notes FROM nzb_imports WHERE path=? ORDER BY imported_at DESC LIMIT 1`, nzbPath).Scan(&oldImportID, &notes)
+	}
+	if oldImportID != "" {
+		rows, err := r.jobs.DB().SQL.QueryContext(ctx, `SELECT collection_id, file_idx, added_at FROM collection_items WHERE import_id=?`, oldImportID)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var c collectionItem
+			if err := rows.Scan(&c.collectionID, &c.fileIdx, &c.addedAt); err == nil {
+				collected = append(collected, c)
+			}
+		}
+		_ = rows.Close()
+	}
+	if err := r.healthDropImportDB(ctx, jobID, nzbPath); err != nil {
+		return err
+	}
+
+	imp := importer.New(r.jobs)
+	imp.SubjectPatterns = cfg.Subject.Patterns
+	imp.NZBRoots = append([]string{cfg.Watch.NZB.Dir}, cfg.NZBOutputRoots()...)
+	imp.Limits = nzb.Limits{MaxFiles: cfg.Import.MaxFilesPerNZB, MaxSegmentsPerFile: cfg.Import.MaxSegmentsPerFile}
+	imp.StreamBatchSegments = cfg.Import.StreamBatchSegments
+	importID := jobID
+	if oldImportID != "" {
+		importID = oldImportID
+		imp.ReuseImportID = oldImportID
+	}
+	if _, _, err := imp.ImportNZB(ctx, jobID, nzbPath); err != nil {
+		return err
+	}
+	if notes != "" {
+		_, _ = r.jobs.DB().SQL.ExecContext(ctx, `UPDATE nzb_imports SET notes=? WHERE id=?`, notes, importID)
+	}
+	// Files the repaired NZB no longer has drop out of their collections.
+	for _, c := range collected {
+		_, _ = r.jobs.DB().SQL.ExecContext(ctx, `
+			INSERT OR IGNORE INTO collection_items(collection_id,import_id,file_idx,added_at)
+			SELECT ?,?,?,? WHERE EXISTS (SELECT 1 FROM nzb_files WHERE import_id=? AND idx=?)
+		`, c.collectionID, importID, c.fileIdx, c.addedAt, importID, c.fileIdx)
+	}
+	if err := imp.EnrichLibraryResolved(ctx, cfg, importID); err != nil {
+		if !errors.Is(err, importer.ErrTMDBUnavailable) {
+			return err
+		}
+		_ = r.jobs.AppendLog(ctx, jobID, "health: library_resolved: WARN: "+err.Error()+" (queued for retry)")
+		_ = r.jobs.MarkResolvePending(ctx, importID, err.Error())
+	}
+	_ = r.jobs.AppendLog(ctx, jobID, "health: db reimport+resolved refreshed")
+	return nil
+}
+
+// healthDropImportDB removes the catalog rows of the latest import for nzbPath (if any).
+func (r *Runner) healthDropImportDB(ctx context.Context, jobID, nzbPath string) error {
 	if r.jobs == nil || r.jobs.DB() == nil {
 		return errors.New("jobs db not configured")
 	}
 	db := r.jobs.DB().SQL
 	var importID string
-	if err := db.QueryRowContext(ctx, `SELECT id FROM nzb_imports WHERE path=? ORDER BY imported_at DESC LIMIT 1`, nzbPath).Scan(&importID); err == nil {
-		tx, err := db.BeginTx(ctx, nil)
-		if err == nil {
-			stmts := []string{
-				`DELETE FROM nzb_segments WHERE import_id=?`,
-				`DELETE FROM nzb_files WHERE import_id=?`,
-				`DELETE FROM library_overrides WHERE import_id=?`,
-				`DELETE FROM library_review_dismissed WHERE import_id=?`,
-				`DELETE FROM library_resolved WHERE import_id=?`,
-				`DELETE FROM manual_items WHERE import_id=?`,
-				`DELETE FROM nzb_imports WHERE id=?`,
-			}
-			for _, s := range stmts {
-				if _, e := tx.ExecContext(ctx, s, importID); e != nil {
-					_ = tx.Rollback()
-					return e
-				}
-			}
-			if e := tx.Commit(); e != nil {
-				return e
-			}
-			_ = r.jobs.AppendLog(ctx, jobID, "health: db old import removed: "+importID)
+	if err := db.QueryRowContext(ctx, `SELECT id FROM nzb_imports WHERE path=? ORDER BY imported_at DESC LIMIT 1`, nzbPath).Scan(&importID); err != nil {
+		return nil
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil
+	}
+	stmts := []string{
+		`DELETE FROM nzb_segments WHERE import_id=?`,
+		`DELETE FROM nzb_files WHERE import_id=?`,
+		`DELETE FROM library_overrides WHERE import_id=?`,
+		`DELETE FROM library_review_dismissed WHERE import_id=?`,
+		`DELETE FROM library_resolved WHERE import_id=?`,
+		`DELETE FROM manual_items WHERE import_id=?`,
+		`DELETE FROM collection_items WHERE import_id=?`,
+		`DELETE FROM library_resolve_pending WHERE import_id=?`,
+		`DELETE FROM nzb_imports WHERE id=?`,
+	}
+	for _, s := range stmts {
+		if _, e := tx.ExecContext(ctx, s, importID); e != nil {
+			_ = tx.Rollback()
+			return e
 		}
 	}
+	if e := tx.Commit(); e != nil {
+		return e
+	}
+	r.jobs.TouchLibrary(importID)
+	_ = r.jobs.AppendLog(ctx, jobID, "health: db old import removed: "+importID)
+	return nil
+}
 
-	imp := importer.New(r.jobs)
-	if _, _, err := imp.ImportNZB(ctx, jobID, nzbPath); err != nil {
+// healthHandoffToMediaInbox moves the repaired media into the media inbox so the media watcher
+// re-uploads it through the normal upload pipeline. The original NZB is backed up and removed
+// (with its catalog rows) so the new upload isn't skipped as a duplicate.
+func (r *Runner) healthHandoffToMediaInbox(ctx context.Context, cfg config.Config, jobID, nzbPath, mediaPath, bakPath string) error {
+	inbox := strings.TrimSpace(cfg.Watch.Media.Dir)
+	if inbox == "" {
+		inbox = strings.TrimSpace(cfg.Paths.MediaInbox)
+	}
+	if inbox == "" {
+		inbox = "/host/inbox/media"
+	}
+	if err := os.MkdirAll(inbox, 0o755); err != nil {
 		return err
 	}
-	if err := imp.EnrichLibraryResolved(ctx, cfg, jobID); err != nil {
-		return err
+	dst := filepath.Join(inbox, filepath.Base(mediaPath))
+	if _, err := os.Stat(dst); err == nil {
+		ext := filepath.Ext(dst)
+		dst = fmt.Sprintf("%s.%s%s", strings.TrimSuffix(dst, ext), jobID[:min(8, len(jobID))], ext)
+	}
+	// A multi-file release is handed over as a folder and queued as one upload below; keep
+	// the watcher from also picking up its files one by one.
+	isDir := false
+	if st, err := os.Stat(mediaPath); err == nil && st.IsDir() {
+		isDir = true
+		entries, _ := os.ReadDir(mediaPath)
+		for _, e := range entries {
+			_ = r.jobs.Suppress(ctx, filepath.Join(dst, e.Name()), "media", "health repair folder upload", time.Now().Add(24*time.Hour))
+		}
+	}
+	if err := os.Rename(mediaPath, dst); err != nil {
+		if isDir {
+			err = copyDirFiles(mediaPath, dst)
+		} else {
+			err = copyFilePerm(mediaPath, dst, 0o644)
+		}
+		if err != nil {
+			return fmt.Errorf("handoff media: %w", err)
+		}
+		_ = os.RemoveAll(mediaPath)
+	}
+	_ = r.jobs.AppendLog(ctx, jobID, "health: repaired media handed to media inbox: "+dst)
+
+	// The re-upload writes its NZB back to nzbPath and the re-import keeps the import id.
+	var oldImportID string
+	_ = r.jobs.DB().SQL.QueryRowContext(ctx, `SELECT id FROM nzb_imports WHERE path=? ORDER BY imported_at DESC LIMIT 1`, nzbPath).Scan(&oldImportID)
+	if oldImportID != "" {
+		if err := r.jobs.SetHealthReplacement(ctx, filepath.Base(dst), nzbPath, oldImportID); err != nil {
+			_ = r.jobs.AppendLog(ctx, jobID, "health: replacement record WARN: "+err.Error())
+		}
+	}
+
+	_ = os.Remove(bakPath)
+	if err := copyFilePerm(nzbPath, bakPath, 0o644); err != nil {
+		return fmt.Errorf("backup original: %w", err)
+	}
+	if err := os.Remove(nzbPath); err != nil {
+		return fmt.Errorf("remove original after backup: %w", err)
+	}
+	if err := r.healthDropImportDB(ctx, jobID, nzbPath); err != nil {
+		_ = r.jobs.AppendLog(ctx, jobID, "health: db cleanup WARN: "+err.Error())
+	}
+	if isDir {
+		if uj, err := r.jobs.Enqueue(ctx, jobs.TypeUpload, map[string]string{"path": dst}); err == nil {
+			_ = r.jobs.AppendLog(ctx, jobID, "health: queued folder re-upload job "+uj.ID)
+		} else {
+			_ = r.jobs.AppendLog(ctx, jobID, "health: queue folder re-upload WARN: "+err.Error())
+		}
 	}
-	_ = r.jobs.AppendLog(ctx, jobID, "health: db reimport+resolved refreshed")
 	return nil
 }
 
@@ -417,33 +817,13 @@ func (r *Runner) healthRegeneratePAR2(ctx context.Context, cfg config.Config, jo
 	if parRoot == "" {
 		parRoot = "/host/inbox/par2"
 	}
-	outRoot := strings.TrimSpace(cfg.NgPost.OutputDir)
-	if outRoot == "" {
-		outRoot = "/host/inbox/nzb"
-	}
-
-	norm := func(s string) string {
-		s = strings.ToLower(s)
-		b := make([]byte, 0, len(s))
-		dash := false
-		for i := 0; i < len(s); i++ {
-			c := s[i]
-			ok := (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9')
-			if ok {
-				b = append(b, c)
-				dash = false
-				continue
-			}
-			if !dash {
-				b = append(b, '-')
-				dash = true
-			}
-		}
-		return strings.Trim(string(b), "-")
+	outRoot, ok := cfg.NZBRootFor(nzbPath)
+	if !ok {
+		outRoot = cfg.NZBOutputDir(false)
 	}
 
 	stem := strings.TrimSuffix(filepath.Base(nzbPath), filepath.Ext(nzbPath))
-	want := norm(stem)
+	want := healthNorm(stem)
 	relDir, _ := filepath.Rel(outRoot, filepath.Dir(nzbPath))
 	if strings.HasPrefix(relDir, "..") {
 		relDir = ""
@@ -457,14 +837,35 @@ func (r *Runner) healthRegeneratePAR2(ctx context.Context, cfg config.Config, jo
 		return err
 	}
 	parBase := filepath.Join(stagingDir, stem+".par2")
-	args := []string{"c", fmt.Sprintf("-r%d", cfg.Upload.Par.RedundancyPercent), "-B/", parBase, mediaPath}
+	args := []string{"c", fmt.Sprintf("-r%d", cfg.Upload.Par.RedundancyPercent), "-B/", parBase}
+	// A multi-file release is a folder; par2 needs its files listed.
+	if st, err := os.Stat(mediaPath); err == nil && st.IsDir() {
+		entries, _ := os.ReadDir(mediaPath)
+		for _, e := range entries {
+			if !e.IsDir() {
+				args = append(args, filepath.Join(mediaPath, e.Name()))
+			}
+		}
+	} else {
+		args = append(args, mediaPath)
+	}
+	releasePar, err := par2Create.acquire(ctx, cfg.Upload.Par.MaxConcurrent)
+	if err != nil {
+		return err
+	}
+	name, argv, note := par2CreateCommand(cfg.Upload.Par, args)
+	if note != "" {
+		_ = r.jobs.AppendLog(ctx, jobID, "health: WARN: "+note)
+	}
 	_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("health: par2 regenerate: par2 %s", strings.Join(args, " ")))
-	if err := runCommand(ctx, func(line string) {
+	err = runCommand(ctx, func(line string) {
 		clean := strings.TrimSpace(line)
 		if clean != "" {
 			_ = r.jobs.AppendLog(ctx, jobID, clean)
 		}
-	}, "par2", args...); err != nil {
+	}, name, argv...)
+	releasePar()
+	if err != nil {
 		return err
 	}
 
@@ -476,7 +877,7 @@ func (r *Runner) healthRegeneratePAR2(ctx context.Context, cfg config.Config, jo
 			continue
 		}
 		base := strings.TrimSuffix(e.Name(), filepath.Ext(e.Name()))
-		if !strings.HasPrefix(norm(base), want) {
+		if !strings.HasPrefix(healthNorm(base), want) {
 			continue
 		}
 		if err := os.Remove(filepath.Join(keepDir, e.Name())); err == nil {
@@ -507,6 +908,26 @@ func (r *Runner) healthRegeneratePAR2(ctx context.Context, cfg config.Config, jo
 	return nil
 }
 
+// copyDirFiles copies the regular files of src (not recursive) into dst.
+func copyDirFiles(src, dst string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if err := copyFilePerm(filepath.Join(src, e.Name()), filepath.Join(dst, e.Name()), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func copyFilePerm(src, dst string, perm os.FileMode) error {
 	in, err := os.Open(src)
 	if err != nil {
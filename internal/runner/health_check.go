@@ -0,0 +1,60 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gaby/EDRmount/internal/config"
+	"github.com/gaby/EDRmount/internal/jobs"
+	"github.com/gaby/EDRmount/internal/nntp"
+)
+
+// runHealthCheck re-verifies a single NZB (health_check_nzb) and records the result in
+// health_nzb_state. Unlike the scan it never enqueues a repair.
+func (r *Runner) runHealthCheck(ctx context.Context, j *jobs.Job) {
+	var p struct {
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal(j.Payload, &p); err != nil || p.Path == "" {
+		_ = r.jobs.SetFailed(ctx, j.ID, "invalid payload")
+		return
+	}
+	cfg := config.Default()
+	if r.GetConfig != nil {
+		cfg = r.GetConfig()
+	}
+	_ = r.jobs.AppendLog(ctx, j.ID, "health check: "+p.Path)
+
+	workers := healthScanWorkers(cfg.Download.Connections)
+	pool := nntp.NewPool(cfg.Download.NNTPConfig(), workers)
+	defer pool.Close()
+
+	start := time.Now()
+	status, err := healthCheckNZB(ctx, pool, workers, p.Path)
+	db := r.jobs.DB().SQL
+	now := time.Now().Unix()
+	if err != nil {
+		_, _ = db.ExecContext(ctx, `INSERT INTO health_nzb_state(path,status,last_checked_at,last_error) VALUES(?,?,?,?)
+			ON CONFLICT(path) DO UPDATE SET status=excluded.status,last_checked_at=excluded.last_checked_at,last_error=excluded.last_error`, p.Path, "error", now, err.Error())
+		msg := "health check: " + err.Error()
+		_ = r.jobs.AppendLog(ctx, j.ID, "ERROR: "+msg)
+		_ = r.jobs.SetFailed(ctx, j.ID, msg)
+		return
+	}
+	if status == "broken" {
+		if young, left := r.healthStillPropagating(ctx, cfg, p.Path); young {
+			msg := fmt.Sprintf("missing articles on an NZB newer than health.scan.min_age_hours; recheck in %s", left.Round(time.Minute))
+			_, _ = db.ExecContext(ctx, `INSERT INTO health_nzb_state(path,status,last_checked_at,last_error) VALUES(?,?,?,?)
+				ON CONFLICT(path) DO UPDATE SET status=excluded.status,last_checked_at=excluded.last_checked_at,last_error=excluded.last_error`, p.Path, "pending-propagation", now, msg)
+			_ = r.jobs.AppendLog(ctx, j.ID, "health check: status=pending-propagation ("+msg+")")
+			_ = r.jobs.SetDone(ctx, j.ID)
+			return
+		}
+	}
+	_, _ = db.ExecContext(ctx, `INSERT INTO health_nzb_state(path,status,last_checked_at,last_error) VALUES(?,?,?,NULL)
+		ON CONFLICT(path) DO UPDATE SET status=excluded.status,last_checked_at=excluded.last_checked_at,last_error=NULL`, p.Path, status, now)
+	_ = r.jobs.AppendLog(ctx, j.ID, fmt.Sprintf("health check: status=%s (%s)", status, time.Since(start).Round(time.Millisecond)))
+	_ = r.jobs.SetDone(ctx, j.ID)
+}
@@ -0,0 +1,145 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	par2Magic        = []byte("PAR2\x00PKT")
+	par2FileDescType = []byte("PAR 2.0\x00FileDesc")
+)
+
+// par2TargetNames lists the files a PAR2 set protects, read from its FileDesc packets.
+// Names are as stored by par2 create: relative to the -B base path ("/" for our
+// uploads, so host/inbox/media/...).
+func par2TargetNames(path string) ([]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// Packet header: magic(8) length(8) hash(16) set id(16) type(16).
+	// FileDesc body: file id(16) md5(16) md5-16k(16) length(8) name (zero padded).
+	const header, descFixed = 64, 56
+	seen := map[string]bool{}
+	out := make([]string, 0)
+	for i := 0; i+header <= len(b); {
+		if !bytes.Equal(b[i:i+8], par2Magic) {
+			j := bytes.Index(b[i+1:], par2Magic)
+			if j < 0 {
+				break
+			}
+			i += 1 + j
+			continue
+		}
+		n := binary.LittleEndian.Uint64(b[i+8 : i+16])
+		if n < header || n > uint64(len(b)-i) {
+			break
+		}
+		end := i + int(n)
+		if bytes.Equal(b[i+48:i+64], par2FileDescType) && end >= i+header+descFixed {
+			name := strings.TrimRight(string(b[i+header+descFixed:end]), "\x00")
+			name = filepath.Clean(filepath.FromSlash(strings.ReplaceAll(name, `\`, "/")))
+			if name != "." && !filepath.IsAbs(name) && name != ".." && !strings.HasPrefix(name, ".."+string(filepath.Separator)) && !seen[name] {
+				seen[name] = true
+				out = append(out, name)
+			}
+		}
+		i = end
+	}
+	return out, nil
+}
+
+// healthPar2Mains returns the main .par2 files (not .volNN+NN) in dir, or every .par2
+// file when there is no main one.
+func healthPar2Mains(dir string) []string {
+	entries, _ := os.ReadDir(dir)
+	mains, all := make([]string, 0), make([]string, 0)
+	for _, e := range entries {
+		n := strings.ToLower(e.Name())
+		if e.IsDir() || !strings.HasSuffix(n, ".par2") {
+			continue
+		}
+		p := filepath.Join(dir, e.Name())
+		all = append(all, p)
+		if !strings.Contains(n, ".vol") {
+			mains = append(mains, p)
+		}
+	}
+	if len(mains) == 0 {
+		mains = all
+	}
+	sort.Strings(mains)
+	return mains
+}
+
+// healthNorm lowercases s and collapses every run of non-alphanumerics to "-", so
+// PAR2 and NZB names can be compared regardless of punctuation.
+func healthNorm(s string) string {
+	s = strings.ToLower(s)
+	b := make([]byte, 0, len(s))
+	dash := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		ok := (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9')
+		if ok {
+			b = append(b, c)
+			dash = false
+			continue
+		}
+		if !dash {
+			b = append(b, '-')
+			dash = true
+		}
+	}
+	return strings.Trim(string(b), "-")
+}
+
+// assignPar2Sets picks the PAR2 set of every target: the set whose file list names it,
+// else a set named after the file itself (per-file parity), else the set named after
+// the NZB (one set for the whole release), else the first set found.
+func assignPar2Sets(mains []string, targets []*healthTarget, nzbWant string) {
+	names := make(map[string][]string, len(mains))
+	for _, m := range mains {
+		names[m], _ = par2TargetNames(m)
+	}
+	byPrefix := func(want string) string {
+		if want == "" {
+			return ""
+		}
+		for _, m := range mains {
+			base := strings.TrimSuffix(filepath.Base(m), filepath.Ext(m))
+			if strings.HasPrefix(healthNorm(base), want) {
+				return m
+			}
+		}
+		return ""
+	}
+	for _, t := range targets {
+		t.Par, t.ParRel = "", ""
+		for _, m := range mains {
+			for _, n := range names[m] {
+				if filepath.Base(n) == t.Name {
+					t.Par, t.ParRel = m, n
+					break
+				}
+			}
+			if t.Par != "" {
+				break
+			}
+		}
+		if t.Par == "" {
+			t.Par = byPrefix(healthNorm(strings.TrimSuffix(t.Name, filepath.Ext(t.Name))))
+		}
+		if t.Par == "" {
+			t.Par = byPrefix(nzbWant)
+		}
+		if t.Par == "" && len(mains) > 0 {
+			t.Par = mains[0]
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gaby/EDRmount/internal/config"
+	"github.com/gaby/EDRmount/internal/nntp"
+	"github.com/gaby/EDRmount/internal/yenc"
+)
+
+// healthMapPar2Target mirrors t at the relative path its PAR2 set expects inside workDir
+// (e.g. host/inbox/media/...) and returns that path.
+func healthMapPar2Target(workDir string, t *healthTarget) string {
+	expectedRel := t.ParRel
+	if expectedRel == "" {
+		expectedRel = filepath.Join("host", "inbox", "media", t.Name)
+	}
+	expectedAbs := filepath.Join(workDir, expectedRel)
+	_ = os.MkdirAll(filepath.Dir(expectedAbs), 0o755)
+	_ = os.Remove(expectedAbs)
+	if err := os.Symlink(t.Path, expectedAbs); err != nil {
+		_ = copyFilePerm(t.Path, expectedAbs, 0o644)
+	}
+	return expectedRel
+}
+
+// healthRetryFromProviders handles a par2 shortfall on set parMain: the segments still
+// missing from its files are fetched from each of health.repair_providers in turn and
+// par2 runs again whenever some came back. It returns nil once the set repairs, else
+// the last par2 error (the shortfall left after every provider).
+func (r *Runner) healthRetryFromProviders(ctx context.Context, jobID string, cfg config.Config, workDir string, targets []*healthTarget, parMain string, parErr error) error {
+	for i, p := range cfg.Health.RepairProviders {
+		if !p.Enabled {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("health: %v; retrying missing segments from repair provider #%d (%s)", parErr, i+1, p.Host))
+		cl, err := nntp.Dial(ctx, p.NNTPConfig())
+		if err == nil {
+			if err = cl.Auth(); err != nil {
+				_ = cl.Close()
+			}
+		}
+		if err != nil {
+			_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("health: repair provider #%d: %v", i+1, err))
+			continue
+		}
+		recovered := 0
+		for _, t := range targets {
+			if t.Par != parMain || t.Missing == 0 {
+				continue
+			}
+			n, err := r.healthRefetchMissing(ctx, cl, t)
+			if err != nil {
+				_ = cl.Close()
+				return fmt.Errorf("health: %s: refetch: %w", t.Name, err)
+			}
+			if n > 0 {
+				healthMapPar2Target(workDir, t)
+			}
+			_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("health: %s: repair provider #%d recovered %d segment(s), %d still missing", t.Name, i+1, n, t.Missing))
+			recovered += n
+		}
+		_ = cl.Close()
+		if recovered == 0 {
+			continue
+		}
+		parErr = r.healthPar2Repair(ctx, jobID, workDir, parMain)
+		var shortfall *par2ShortfallError
+		if parErr == nil || !errors.As(parErr, &shortfall) {
+			return parErr
+		}
+	}
+	return parErr
+}
+
+// healthRefetchMissing rewrites t.Path with the zero-filled segments fetched from cl
+// where available, and returns how many were recovered.
+func (r *Runner) healthRefetchMissing(ctx context.Context, cl *nntp.Client, t *healthTarget) (int, error) {
+	old, err := os.Open(t.Path)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = old.Close() }()
+	tmp := t.Path + ".retry"
+	wf, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = wf.Close(); _ = os.Remove(tmp) }()
+
+	recovered := 0
+	for i, n := range t.Parts {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		if n >= 0 {
+			if _, err := io.CopyN(wf, old, n); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if _, err := io.CopyN(io.Discard, old, -n); err != nil {
+			return 0, err
+		}
+		var data []byte
+		if lines, err := cl.BodyByMessageID(strings.TrimSpace(t.File.Segments[i].ID)); err == nil {
+			data, _, _, _, err = yenc.DecodePart(lines)
+			if err != nil {
+				data = nil
+			}
+		}
+		if data == nil {
+			_, _ = wf.Write(make([]byte, int(-n)))
+			continue
+		}
+		if _, err := wf.Write(data); err != nil {
+			return 0, err
+		}
+		t.Parts[i] = int64(len(data))
+		t.Missing--
+		recovered++
+	}
+	if recovered == 0 {
+		return 0, nil
+	}
+	if err := wf.Sync(); err != nil {
+		return 0, err
+	}
+	if err := wf.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp, t.Path); err != nil {
+		return 0, err
+	}
+	return recovered, nil
+}
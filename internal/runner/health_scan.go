@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gaby/EDRmount/internal/config"
@@ -36,11 +37,6 @@ func (r *Runner) runHealthScan(ctx context.Context, j *jobs.Job) {
 	}
 	deadline := time.Now().Add(budget)
 
-	outRoot := strings.TrimSpace(cfg.NgPost.OutputDir)
-	if outRoot == "" {
-		outRoot = "/host/inbox/nzb"
-	}
-
 	db := r.jobs.DB().SQL
 
 	// Load scan cursor
@@ -56,18 +52,22 @@ func (r *Runner) runHealthScan(ctx context.Context, j *jobs.Job) {
 
 	// List all NZBs (deterministic order)
 	paths := make([]string, 0, 1024)
-	_ = filepath.WalkDir(outRoot, func(p string, d os.DirEntry, err error) error {
-		if err != nil {
-			return nil
-		}
-		if d.IsDir() {
+	seen := map[string]bool{}
+	for _, outRoot := range cfg.NZBOutputRoots() {
+		_ = filepath.WalkDir(outRoot, func(p string, d os.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if strings.HasSuffix(strings.ToLower(d.Name()), ".nzb") && !seen[p] {
+				seen[p] = true
+				paths = append(paths, p)
+			}
 			return nil
-		}
-		if strings.HasSuffix(strings.ToLower(d.Name()), ".nzb") {
-			paths = append(paths, p)
-		}
-		return nil
-	})
+		})
+	}
 	sort.Strings(paths)
 	_ = r.jobs.AppendLog(ctx, j.ID, fmt.Sprintf("health scan: found %d nzb(s)", len(paths)))
 
@@ -82,8 +82,11 @@ func (r *Runner) runHealthScan(ctx context.Context, j *jobs.Job) {
 		}
 	}
 
-	// NNTP client for STAT checks
-	pool := nntp.NewPool(nntp.Config{Host: cfg.Download.Host, Port: cfg.Download.Port, SSL: cfg.Download.SSL, User: cfg.Download.User, Pass: cfg.Download.Pass, Timeout: 30 * time.Second}, cfg.Download.Connections)
+	// NNTP pool for parallel STAT checks
+	workers := healthScanWorkers(cfg.Download.Connections)
+	pool := nntp.NewPool(cfg.Download.NNTPConfig(), workers)
+	defer pool.Close()
+	// Fail fast if the provider is unreachable.
 	cl, err := pool.Acquire(ctx)
 	if err != nil {
 		msg := "health scan: nntp acquire failed: " + err.Error()
@@ -91,10 +94,28 @@ func (r *Runner) runHealthScan(ctx context.Context, j *jobs.Job) {
 		_ = r.jobs.SetFailed(ctx, j.ID, msg)
 		return
 	}
-	defer pool.Release(cl)
+	pool.Release(cl)
+	_ = r.jobs.AppendLog(ctx, j.ID, fmt.Sprintf("health scan: stat workers=%d", workers))
+
+	// NZBs repaired within health.scan.repaired_cooldown_hours are skipped.
+	cooling := map[string]bool{}
+	if h := cfg.Health.Scan.RepairedCooldownHours; h > 0 {
+		since := time.Now().Add(-time.Duration(h) * time.Hour).Unix()
+		if rows, err := db.QueryContext(ctx, `SELECT path FROM health_nzb_state WHERE last_repaired_at>=?`, since); err == nil {
+			for rows.Next() {
+				var p string
+				if rows.Scan(&p) == nil {
+					cooling[p] = true
+				}
+			}
+			_ = rows.Close()
+		}
+	}
 
 	checked := 0
 	broken := 0
+	pending := 0
+	skipped := 0
 	lastProcessed := ""
 	for idx := startIdx; idx < len(paths); idx++ {
 		if time.Now().After(deadline) {
@@ -108,12 +129,16 @@ func (r *Runner) runHealthScan(ctx context.Context, j *jobs.Job) {
 
 		p := paths[idx]
 		lastProcessed = p
+		if cooling[p] {
+			skipped++
+			continue
+		}
 		checked++
 		if checked%20 == 0 {
 			_ = r.jobs.AppendLog(ctx, j.ID, fmt.Sprintf("health scan: progress %d/%d (broken=%d)", idx+1, len(paths), broken))
 		}
 
-		status, err := healthCheckNZB(ctx, cl, p)
+		status, err := healthCheckNZB(ctx, pool, workers, p)
 		now := time.Now().Unix()
 		if err != nil {
 			_, _ = db.ExecContext(ctx, `INSERT INTO health_nzb_state(path,status,last_checked_at,last_error) VALUES(?,?,?,?)
@@ -122,6 +147,14 @@ func (r *Runner) runHealthScan(ctx context.Context, j *jobs.Job) {
 		}
 
 		if status == "broken" {
+			if young, left := r.healthStillPropagating(ctx, cfg, p); young {
+				pending++
+				msg := fmt.Sprintf("missing articles on an NZB newer than health.scan.min_age_hours; recheck in %s", left.Round(time.Minute))
+				_, _ = db.ExecContext(ctx, `INSERT INTO health_nzb_state(path,status,last_checked_at,last_error) VALUES(?,?,?,?)
+					ON CONFLICT(path) DO UPDATE SET status=excluded.status,last_checked_at=excluded.last_checked_at,last_error=excluded.last_error`, p, "pending-propagation", now, msg)
+				_, _ = db.ExecContext(ctx, `UPDATE health_scan_state SET cursor_path=?, last_chunk_finished_at=? WHERE id=1`, p, now)
+				continue
+			}
 			broken++
 			_, _ = db.ExecContext(ctx, `INSERT INTO health_nzb_state(path,status,last_checked_at,last_error) VALUES(?,?,?,NULL)
 				ON CONFLICT(path) DO UPDATE SET status=excluded.status,last_checked_at=excluded.last_checked_at,last_error=NULL`, p, "broken", now)
@@ -144,12 +177,26 @@ func (r *Runner) runHealthScan(ctx context.Context, j *jobs.Job) {
 	}
 
 	// Completed full run
-	_ = r.jobs.AppendLog(ctx, j.ID, fmt.Sprintf("health scan: completed (checked=%d broken=%d)", checked, broken))
+	_ = r.jobs.AppendLog(ctx, j.ID, fmt.Sprintf("health scan: completed (checked=%d broken=%d pending-propagation=%d skipped-recently-repaired=%d)", checked, broken, pending, skipped))
 	_, _ = db.ExecContext(ctx, `UPDATE health_scan_state SET cursor_path=NULL, last_run_completed_at=?, last_chunk_finished_at=? WHERE id=1`, time.Now().Unix(), time.Now().Unix())
 	_ = r.jobs.SetDone(ctx, j.ID)
 }
 
-func healthCheckNZB(ctx context.Context, cl *nntp.Client, nzbPath string) (string, error) {
+// healthScanWorkers bounds STAT parallelism by the configured download connections.
+func healthScanWorkers(connections int) int {
+	n := connections
+	if n <= 0 {
+		n = 4
+	}
+	if n > 16 {
+		n = 16
+	}
+	return n
+}
+
+// healthCheckNZB STATs every MKV segment of the NZB using up to `workers` pooled connections.
+// Any missing message-id or failed STAT makes it "broken"; the first failure cancels the rest.
+func healthCheckNZB(ctx context.Context, pool *nntp.Pool, workers int, nzbPath string) (string, error) {
 	f, err := os.Open(nzbPath)
 	if err != nil {
 		return "error", err
@@ -159,6 +206,7 @@ func healthCheckNZB(ctx context.Context, cl *nntp.Client, nzbPath string) (strin
 	if err != nil {
 		return "error", err
 	}
+	ids := make([]string, 0, 1024)
 	for _, file := range doc.Files {
 		// Only check MKV segments
 		if !strings.Contains(strings.ToLower(file.Subject), ".mkv") {
@@ -172,10 +220,103 @@ func healthCheckNZB(ctx context.Context, cl *nntp.Client, nzbPath string) (strin
 			if id == "" {
 				return "broken", nil
 			}
-			if err := cl.StatByMessageID(id); err != nil {
-				return "broken", nil
-			}
+			ids = append(ids, id)
 		}
 	}
+	if len(ids) == 0 {
+		return "ok", nil
+	}
+	if workers > len(ids) {
+		workers = len(ids)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// Pre-filled so a worker that can't get a connection never blocks the feeder.
+	feed := make(chan string, len(ids))
+	for _, id := range ids {
+		feed <- id
+	}
+	close(feed)
+	var (
+		mu         sync.Mutex
+		broken     bool
+		acquired   int
+		acquireErr error
+		wg         sync.WaitGroup
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cl, err := pool.Acquire(cctx)
+			mu.Lock()
+			if err != nil {
+				acquireErr = err
+				mu.Unlock()
+				return
+			}
+			acquired++
+			mu.Unlock()
+			defer pool.Release(cl)
+			for id := range feed {
+				if cctx.Err() != nil {
+					return
+				}
+				if err := cl.StatByMessageID(id); err != nil {
+					mu.Lock()
+					broken = true
+					mu.Unlock()
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if broken {
+		return "broken", nil
+	}
+	if acquired == 0 && acquireErr != nil {
+		return "error", acquireErr
+	}
+	if err := ctx.Err(); err != nil {
+		return "error", err
+	}
 	return "ok", nil
 }
+
+// healthStillPropagating reports whether a "broken" NZB is younger than
+// health.scan.min_age_hours: fresh uploads often miss articles for a few hours while
+// they propagate between servers. The age counts from the later of the NZB mtime and
+// its import time. It also returns how long is left.
+func (r *Runner) healthStillPropagating(ctx context.Context, cfg config.Config, nzbPath string) (bool, time.Duration) {
+	minAge := time.Duration(cfg.Health.Scan.MinAgeHours) * time.Hour
+	if minAge <= 0 {
+		return false, 0
+	}
+	var newest time.Time
+	if st, err := os.Stat(nzbPath); err == nil {
+		newest = st.ModTime()
+	}
+	var importedAt sql.NullInt64
+	_ = r.jobs.DB().SQL.QueryRowContext(ctx, `SELECT MAX(imported_at) FROM nzb_imports WHERE path=?`, nzbPath).Scan(&importedAt)
+	if importedAt.Valid {
+		if t := time.Unix(importedAt.Int64, 0); t.After(newest) {
+			newest = t
+		}
+	}
+	if newest.IsZero() {
+		return false, 0
+	}
+	left := minAge - time.Since(newest)
+	if left <= 0 {
+		return false, 0
+	}
+	return true, left
+}
@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	rePar2Available = regexp.MustCompile(`You have (\d+) out of (\d+) data blocks available`)
+	rePar2Need      = regexp.MustCompile(`You need (\d+) more recovery blocks`)
+)
+
+// par2VerifyResult summarizes "par2 v" output.
+type par2VerifyResult struct {
+	OK        bool
+	Available int // data blocks present
+	Total     int // data blocks in the set
+	Missing   int
+}
+
+func parsePar2Verify(out string, exitErr error) par2VerifyResult {
+	res := par2VerifyResult{Available: -1, Total: -1}
+	if m := rePar2Available.FindStringSubmatch(out); len(m) == 3 {
+		res.Available, _ = strconv.Atoi(m[1])
+		res.Total, _ = strconv.Atoi(m[2])
+		res.Missing = res.Total - res.Available
+	}
+	if m := rePar2Need.FindStringSubmatch(out); len(m) == 2 && res.Missing == 0 {
+		res.Missing, _ = strconv.Atoi(m[1])
+	}
+	allOK := strings.Contains(out, "All files are correct") || strings.Contains(out, "repair is not required")
+	res.OK = exitErr == nil && allOK && res.Missing == 0
+	if res.OK && res.Total >= 0 {
+		res.Available = res.Total
+	}
+	return res
+}
+
+// healthVerifyPAR2 runs "par2 v" against the repaired set and fails unless every block
+// verifies, so a still-damaged file is never re-published.
+func (r *Runner) healthVerifyPAR2(ctx context.Context, jobID, workDir, parMain string) error {
+	_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("health: par2 verify: par2 v %s", filepath.Base(parMain)))
+	cmd := exec.CommandContext(ctx, "par2", "v", parMain)
+	cmd.Dir = workDir
+	b, err := cmd.CombinedOutput()
+	out := string(b)
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			_ = r.jobs.AppendLog(ctx, jobID, "verify: "+line)
+		}
+	}
+	res := parsePar2Verify(out, err)
+	if res.Total >= 0 {
+		_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("health: par2 verify blocks=%d/%d missing=%d", res.Available, res.Total, res.Missing))
+	}
+	if !res.OK {
+		if err != nil {
+			return fmt.Errorf("health: par2 verify failed after repair (missing=%d): %w (workdir kept: %s)", res.Missing, err, workDir)
+		}
+		return fmt.Errorf("health: par2 verify failed after repair (missing=%d, workdir kept: %s)", res.Missing, workDir)
+	}
+	_ = r.jobs.AppendLog(ctx, jobID, "health: par2 verify OK")
+	return nil
+}
@@ -0,0 +1,282 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/gaby/EDRmount/internal/config"
+	"github.com/gaby/EDRmount/internal/library"
+)
+
+// hookMaxOutput bounds what a hook may print (stdout and stderr each).
+const hookMaxOutput = 1 << 20
+
+// importHookFile is one file of the hooks.on_import input: the guess of library_resolved.
+type importHookFile struct {
+	Idx      int    `json:"idx"`
+	Filename string `json:"filename"`
+	Bytes    int64  `json:"bytes"`
+	Kind     string `json:"kind"`
+	Title    string `json:"title"`
+	Year     int    `json:"year"`
+	Quality  string `json:"quality"`
+	TMDBID   int64  `json:"tmdb_id"`
+	Season   int    `json:"season"`
+	Episode  int    `json:"episode"`
+}
+
+type importHookInput struct {
+	ImportID string           `json:"import_id"`
+	Path     string           `json:"path"`
+	Category string           `json:"nzb_category"`
+	Files    []importHookFile `json:"files"`
+}
+
+// importHookOverride is what a hook may print; zero fields keep the guess. The top level
+// applies to every file, Files entries (matched by idx) to one. Category picks the kind
+// like an NZB category would ("anime" -> series) unless Kind is set; at the top level it
+// also replaces the import's nzb_category.
+type importHookOverride struct {
+	Category string `json:"category"`
+	Kind     string `json:"kind"`
+	Title    string `json:"title"`
+	Year     int    `json:"year"`
+	Quality  string `json:"quality"`
+	TMDBID   int64  `json:"tmdb_id"`
+	Season   int    `json:"season"`
+	Episode  int    `json:"episode"`
+}
+
+type importHookOutput struct {
+	importHookOverride
+	Files []struct {
+		Idx int `json:"idx"`
+		importHookOverride
+	} `json:"files"`
+}
+
+func (o importHookOverride) apply(f *importHookFile) {
+	if k := library.CategoryKind(o.Category); k != "" {
+		f.Kind = k
+	}
+	if k := strings.ToLower(strings.TrimSpace(o.Kind)); k != "" {
+		f.Kind = k
+	}
+	if t := strings.TrimSpace(o.Title); t != "" {
+		f.Title = t
+	}
+	if o.Year > 0 {
+		f.Year = o.Year
+	}
+	if q := strings.TrimSpace(o.Quality); q != "" {
+		f.Quality = q
+	}
+	if o.TMDBID > 0 {
+		f.TMDBID = o.TMDBID
+	}
+	if o.Season > 0 {
+		f.Season = o.Season
+	}
+	if o.Episode > 0 {
+		f.Episode = o.Episode
+	}
+}
+
+func (o importHookOverride) validate() error {
+	switch strings.ToLower(strings.TrimSpace(o.Kind)) {
+	case "", "movie", "series":
+	default:
+		return fmt.Errorf("kind must be movie|series, got %q", o.Kind)
+	}
+	if o.Year < 0 || o.Season < 0 || o.Episode < 0 {
+		return errors.New("year, season and episode must not be negative")
+	}
+	return nil
+}
+
+// runImportHook runs hooks.on_import for a finished import and stores what it returns as
+// library_overrides. Files that already have an override (set by hand, or by an earlier
+// run on a reused import id) are left alone. Errors are for the job log only: the import
+// itself already succeeded.
+func (r *Runner) runImportHook(ctx context.Context, jobID string, cfg config.Config, importID string) error {
+	hook := strings.TrimSpace(cfg.Hooks.OnImport)
+	db := r.jobs.DB().SQL
+
+	in := importHookInput{ImportID: importID, Files: []importHookFile{}}
+	if err := db.QueryRowContext(ctx, `SELECT path,nzb_category FROM nzb_imports WHERE id=?`, importID).Scan(&in.Path, &in.Category); err != nil {
+		return err
+	}
+	rows, err := db.QueryContext(ctx, `
+		SELECT f.idx, COALESCE(f.filename,''), f.total_bytes, r.kind, r.title, r.year, r.quality, r.tmdb_id, r.season, r.episode
+		FROM nzb_files f
+		JOIN library_resolved r ON r.import_id=f.import_id AND r.file_idx=f.idx
+		WHERE f.import_id=?
+		ORDER BY f.idx ASC`, importID)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var f importHookFile
+		if err := rows.Scan(&f.Idx, &f.Filename, &f.Bytes, &f.Kind, &f.Title, &f.Year, &f.Quality, &f.TMDBID, &f.Season, &f.Episode); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		in.Files = append(in.Files, f)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(in.Files) == 0 {
+		_ = r.jobs.AppendLog(ctx, jobID, "hook: no resolved library files, skipped")
+		return nil
+	}
+
+	stdin, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	stdout, err := execHook(ctx, hook, time.Duration(cfg.Hooks.Defaults().TimeoutSeconds)*time.Second, stdin, importID, func(line string) {
+		_ = r.jobs.AppendLog(ctx, jobID, "hook: "+line)
+	})
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(stdout)) == 0 {
+		_ = r.jobs.AppendLog(ctx, jobID, "hook: no overrides")
+		return nil
+	}
+	var out importHookOutput
+	if err := json.Unmarshal(stdout, &out); err != nil {
+		return fmt.Errorf("hook output: %w", err)
+	}
+	if err := out.validate(); err != nil {
+		return fmt.Errorf("hook output: %w", err)
+	}
+	perFile := map[int]importHookOverride{}
+	for _, f := range out.Files {
+		if err := f.validate(); err != nil {
+			return fmt.Errorf("hook output: file %d: %w", f.Idx, err)
+		}
+		perFile[f.Idx] = f.importHookOverride
+	}
+
+	// Check every file before writing any, so a bad answer leaves the import untouched.
+	merged := make([]importHookFile, 0, len(in.Files))
+	for _, guess := range in.Files {
+		f := guess
+		out.apply(&f)
+		if o, ok := perFile[f.Idx]; ok {
+			o.apply(&f)
+		}
+		if f == guess {
+			continue
+		}
+		if f.Kind == "series" && f.Episode <= 0 {
+			return fmt.Errorf("hook output: file %d: kind series needs season and episode", f.Idx)
+		}
+		merged = append(merged, f)
+	}
+
+	if c := strings.TrimSpace(out.Category); c != "" && c != in.Category {
+		if _, err := db.ExecContext(ctx, `UPDATE nzb_imports SET nzb_category=? WHERE id=?`, c, importID); err != nil {
+			return err
+		}
+		// The category is a movie/series hint for library-auto paths.
+		r.jobs.TouchLibrary(importID)
+		_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("hook: category %q -> %q", in.Category, c))
+	}
+
+	now := time.Now().Unix()
+	applied := 0
+	for _, f := range merged {
+		res, err := db.ExecContext(ctx, `
+			INSERT INTO library_overrides(import_id,file_idx,kind,title,year,quality,tmdb_id,season,episode,updated_at)
+			VALUES(?,?,?,?,?,?,?,?,?,?)
+			ON CONFLICT(import_id,file_idx) DO NOTHING
+		`, importID, f.Idx, f.Kind, f.Title, f.Year, f.Quality, f.TMDBID, f.Season, f.Episode, now)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			applied++
+		}
+	}
+	if applied > 0 {
+		r.jobs.TouchLibrary(importID)
+	}
+	_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("hook: %d override(s) applied", applied))
+	return nil
+}
+
+// execHook runs a hook with stdin, a timeout, a scratch working directory and a minimal
+// environment (no inherited secrets), and returns its stdout. Stderr goes to onLine.
+func execHook(ctx context.Context, hook string, timeout time.Duration, stdin []byte, importID string, onLine func(string)) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	work, err := os.MkdirTemp("", "edrmount-hook-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(work)
+
+	cmd := exec.CommandContext(ctx, hook)
+	cmd.Dir = work
+	cmd.Env = []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + work,
+		"TMPDIR=" + work,
+		"LANG=C.UTF-8",
+		"LC_ALL=C.UTF-8",
+		"EDRMOUNT_HOOK=on_import",
+		"EDRMOUNT_IMPORT_ID=" + importID,
+	}
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stdout, stderr limitedBuffer
+	stdout.max, stderr.max = hookMaxOutput, hookMaxOutput
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	// A hook that forks and leaves children holding the pipes must not hang the import.
+	cmd.WaitDelay = 5 * time.Second
+
+	err = cmd.Run()
+	for _, line := range strings.Split(strings.TrimSpace(stderr.buf.String()), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			onLine(line)
+		}
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("timed out after %s", timeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stdout.truncated {
+		return nil, fmt.Errorf("output over %d bytes", hookMaxOutput)
+	}
+	return stdout.buf.Bytes(), nil
+}
+
+// limitedBuffer keeps the first max bytes written to it and drops the rest.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
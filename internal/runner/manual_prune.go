@@ -0,0 +1,36 @@
+package runner
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gaby/EDRmount/internal/config"
+)
+
+const manualPruneEvery = 1 * time.Hour
+
+// runManualPruneSweeper drops empty auto-seeded library-manual folders at startup and
+// then periodically (library.prune_manual_dirs).
+func (r *Runner) runManualPruneSweeper(ctx context.Context) {
+	t := time.NewTicker(manualPruneEvery)
+	defer t.Stop()
+	for {
+		cfg := config.Default()
+		if r.GetConfig != nil {
+			cfg = r.GetConfig()
+		}
+		if cfg.Library.PruneManualDirs {
+			if n, err := r.jobs.PruneEmptyManualDirs(ctx); err != nil {
+				log.Printf("manual prune: %v", err)
+			} else if n > 0 {
+				log.Printf("manual prune: removed %d empty folder(s)", n)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gaby/EDRmount/internal/config"
+)
+
+// cleanupUploadedMedia applies watch.media.delete_after_upload to the source of a
+// finished upload: it is moved to watch.media.done_dir, or deleted when that is unset.
+// Only called once the NZB is in place; sources outside the media inbox (manual uploads
+// from elsewhere) are never touched.
+func (r *Runner) cleanupUploadedMedia(ctx context.Context, jobID string, cfg config.Config, src string) {
+	m := cfg.Watch.Media
+	if !m.DeleteAfterUpload {
+		return
+	}
+	inbox := strings.TrimSpace(m.Dir)
+	if inbox == "" {
+		return
+	}
+	inbox = filepath.Clean(inbox)
+	rel, err := filepath.Rel(inbox, filepath.Clean(src))
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		_ = r.jobs.AppendLog(ctx, jobID, "inbox cleanup: source not under watch.media.dir, kept: "+src)
+		return
+	}
+
+	if done := strings.TrimSpace(m.DoneDir); done != "" {
+		dst := filepath.Join(done, rel)
+		if _, err := os.Stat(dst); err == nil {
+			ext := filepath.Ext(dst)
+			dst = fmt.Sprintf("%s.%s%s", strings.TrimSuffix(dst, ext), jobID[:min(8, len(jobID))], ext)
+		}
+		if err := moveMedia(src, dst); err != nil {
+			_ = r.jobs.AppendLog(ctx, jobID, "inbox cleanup: WARN: move failed, source kept: "+err.Error())
+			return
+		}
+		_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("inbox cleanup: moved %s -> %s", src, dst))
+	} else {
+		if err := os.RemoveAll(src); err != nil {
+			_ = r.jobs.AppendLog(ctx, jobID, "inbox cleanup: WARN: delete failed: "+err.Error())
+			return
+		}
+		_ = r.jobs.AppendLog(ctx, jobID, "inbox cleanup: deleted "+src)
+	}
+
+	// Season packs and nested drops leave their folders behind; remove them once empty.
+	for dir := filepath.Dir(filepath.Clean(src)); dir != inbox && strings.HasPrefix(dir, inbox+string(filepath.Separator)); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+}
+
+// moveMedia renames src (a file or a folder tree) to dst. Across filesystems the tree is
+// copied and every file checked against its source size before src is removed; on any
+// error the source is kept and the partial copy is removed.
+func moveMedia(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := copyMediaTree(src, dst); err != nil {
+		_ = os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// copyMediaTree copies the regular files and folders under src to dst. Anything else
+// (symlinks, devices) is refused rather than silently dropped.
+func copyMediaTree(src, dst string) error {
+	return filepath.WalkDir(src, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, 0o755)
+		case !d.Type().IsRegular():
+			return fmt.Errorf("%s: not a regular file", p)
+		}
+		if err := copyFilePerm(p, target, 0o644); err != nil {
+			return err
+		}
+		in, err := os.Stat(p)
+		if err != nil {
+			return err
+		}
+		out, err := os.Stat(target)
+		if err != nil {
+			return err
+		}
+		if in.Size() != out.Size() {
+			return fmt.Errorf("%s: copied %d of %d bytes", p, out.Size(), in.Size())
+		}
+		return nil
+	})
+}
@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"sync"
+
+	"github.com/gaby/EDRmount/internal/config"
+)
+
+// par2Gate serializes "par2 c" across upload and health jobs: parity generation is
+// CPU-bound and a few at once starve streaming. The limit is read from the live config
+// on every acquire (upload.par.max_concurrent).
+type par2Gate struct {
+	mu      sync.Mutex
+	running int
+	wake    chan struct{}
+}
+
+var par2Create = &par2Gate{}
+
+// acquire takes a slot, blocking until one is free or ctx is done. The returned func
+// releases it.
+func (g *par2Gate) acquire(ctx context.Context, limit int) (func(), error) {
+	if limit <= 0 {
+		limit = 1
+	}
+	for {
+		g.mu.Lock()
+		if g.running < limit {
+			g.running++
+			g.mu.Unlock()
+			var once sync.Once
+			return func() { once.Do(g.release) }, nil
+		}
+		if g.wake == nil {
+			g.wake = make(chan struct{})
+		}
+		wake := g.wake
+		g.mu.Unlock()
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (g *par2Gate) release() {
+	g.mu.Lock()
+	g.running--
+	if g.wake != nil {
+		close(g.wake)
+		g.wake = nil
+	}
+	g.mu.Unlock()
+}
+
+// par2CreateCommand returns the command line for "par2 <args>" with the priority of
+// upload.par.nice / upload.par.ionice: nice and ionice wrap the call when installed,
+// otherwise par2 runs at normal priority (reported in note).
+func par2CreateCommand(par config.UploadPar, args []string) (name string, argv []string, note string) {
+	name, argv = config.DefaultPar2Binary, args
+	if par.IONice {
+		if p, err := exec.LookPath("ionice"); err == nil {
+			argv = append([]string{"-c3", name}, argv...)
+			name = p
+		} else {
+			note = "ionice not found, par2 keeps normal I/O priority"
+		}
+	}
+	if par.Nice > 0 {
+		if p, err := exec.LookPath("nice"); err == nil {
+			argv = append([]string{"-n", strconv.Itoa(par.Nice), name}, argv...)
+			name = p
+		} else {
+			note = fmt.Sprintf("nice not found, par2 keeps normal CPU priority (wanted %d)", par.Nice)
+		}
+	}
+	return name, argv, note
+}
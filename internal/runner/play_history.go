@@ -0,0 +1,36 @@
+package runner
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gaby/EDRmount/internal/config"
+)
+
+const playHistoryPruneEvery = 6 * time.Hour
+
+// runPlayHistoryPrune drops play_history rows older than server.play_history_days.
+func (r *Runner) runPlayHistoryPrune(ctx context.Context) {
+	t := time.NewTicker(playHistoryPruneEvery)
+	defer t.Stop()
+	for {
+		cfg := config.Default()
+		if r.GetConfig != nil {
+			cfg = r.GetConfig()
+		}
+		if days := cfg.Server.PlayHistoryDays; days > 0 {
+			cutoff := time.Now().Add(-time.Duration(days) * 24 * time.Hour)
+			if n, err := r.jobs.PrunePlayHistory(ctx, cutoff); err != nil {
+				log.Printf("play history prune: %v", err)
+			} else if n > 0 {
+				log.Printf("play history prune: removed %d row(s)", n)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package runner
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gaby/EDRmount/internal/config"
+	"github.com/gaby/EDRmount/internal/fusefs"
+	"github.com/gaby/EDRmount/internal/plex"
+)
+
+// plexBatchSectionAt is the batch size from which a debounced "path" refresh becomes a
+// section refresh: one scan of the section is cheaper for Plex than hundreds of paths.
+const plexBatchSectionAt = 20
+
+// plexPending coalesces library-auto paths of recent imports for one Plex refresh.
+type plexPending struct {
+	mu          sync.Mutex
+	items       map[string]fusefs.AutoVirtualItem // by path
+	first, last time.Time
+}
+
+// add queues items and returns how many distinct paths are pending.
+func (p *plexPending) add(items []fusefs.AutoVirtualItem) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.items == nil {
+		p.items = map[string]fusefs.AutoVirtualItem{}
+	}
+	now := time.Now()
+	if len(p.items) == 0 {
+		p.first = now
+	}
+	p.last = now
+	for _, it := range items {
+		p.items[it.Path] = it
+	}
+	return len(p.items)
+}
+
+// take returns the pending items once nothing was added for quiet, or once the oldest
+// has waited maxWait (a steady backfill still refreshes now and then).
+func (p *plexPending) take(quiet, maxWait time.Duration) []fusefs.AutoVirtualItem {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.items) == 0 {
+		return nil
+	}
+	if time.Since(p.last) < quiet && time.Since(p.first) < maxWait {
+		return nil
+	}
+	out := make([]fusefs.AutoVirtualItem, 0, len(p.items))
+	for _, it := range p.items {
+		out = append(out, it)
+	}
+	p.items = nil
+	return out
+}
+
+// runPlexDebounce flushes the paths queued by plexRefreshImport after
+// plex.refresh_debounce_seconds without new imports (at the latest after ten periods).
+func (r *Runner) runPlexDebounce(ctx context.Context) {
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		cfg := config.Default()
+		if r.GetConfig != nil {
+			cfg = r.GetConfig()
+		}
+		quiet := time.Duration(cfg.Plex.RefreshDebounceSeconds) * time.Second
+		items := r.plexQueue.take(quiet, 10*quiet)
+		if len(items) == 0 {
+			continue
+		}
+		pc := plex.New(cfg.Plex.BaseURL, cfg.Plex.Token)
+		if !cfg.Plex.Enabled || !pc.Enabled() {
+			continue
+		}
+		log.Printf("plex: debounced refresh of %d path(s)", len(items))
+		r.plexRefresh(ctx, cfg, pc, items, len(items) >= plexBatchSectionAt, func(msg string) { log.Print(msg) })
+	}
+}
@@ -0,0 +1,106 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/gaby/EDRmount/internal/config"
+	"github.com/gaby/EDRmount/internal/fusefs"
+	"github.com/gaby/EDRmount/internal/plex"
+)
+
+// plexRefreshImport refreshes the library-auto paths of a new import in Plex,
+// per path and/or per section according to plex.refresh_mode. Progress is logged on jobID.
+// With plex.refresh_debounce_seconds the paths are queued instead and refreshed together
+// once imports go quiet (see runPlexDebounce).
+func (r *Runner) plexRefreshImport(ctx context.Context, jobID, importID string, cfg config.Config) {
+	if !cfg.Plex.Enabled || !cfg.Plex.RefreshOnImport {
+		return
+	}
+	pc := plex.New(cfg.Plex.BaseURL, cfg.Plex.Token)
+	if !pc.Enabled() {
+		return
+	}
+	items, err := fusefs.AutoVirtualItemsForImport(ctx, cfg, r.jobs, importID)
+	if err != nil {
+		_ = r.jobs.AppendLog(ctx, jobID, "plex: cannot build auto paths: "+err.Error())
+		return
+	}
+	if cfg.Plex.RefreshDebounceSeconds > 0 {
+		n := r.plexQueue.add(items)
+		_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("plex: refresh queued (%d path(s) pending, debounce %ds)", n, cfg.Plex.RefreshDebounceSeconds))
+		return
+	}
+	r.plexRefresh(ctx, cfg, pc, items, false, func(msg string) { _ = r.jobs.AppendLog(ctx, jobID, msg) })
+}
+
+// plexRefresh refreshes items per path and/or per section according to
+// plex.refresh_mode. preferSection turns a "path" refresh into a section refresh when
+// every item maps to a section (a large debounced batch).
+func (r *Runner) plexRefresh(ctx context.Context, cfg config.Config, pc *plex.Client, items []fusefs.AutoVirtualItem, preferSection bool, logf func(string)) {
+	mode := cfg.Plex.RefreshMode
+	if mode == "" {
+		mode = "path"
+	}
+
+	sections := map[int]bool{}
+	unmapped := make([]string, 0)
+	for _, it := range items {
+		id := 0
+		if cat, ok := cfg.Plex.CategoryFor(it.Kind, it.Quality); ok {
+			id = cat.SectionID
+		}
+		if id <= 0 {
+			id = cfg.Plex.SectionFor(it.Path)
+		}
+		if id > 0 {
+			sections[id] = true
+		} else {
+			unmapped = append(unmapped, it.Path)
+		}
+	}
+	if mode == "path" && preferSection && len(sections) > 0 && len(unmapped) == 0 {
+		logf(fmt.Sprintf("plex: %d paths batched, refreshing %d section(s) instead", len(items), len(sections)))
+		mode = "section"
+	}
+
+	if mode == "path" || mode == "both" {
+		refreshed := 0
+		for _, it := range items {
+			root := cfg.Plex.PlexRoot
+			if cat, ok := cfg.Plex.CategoryFor(it.Kind, it.Quality); ok && cat.Root != "" {
+				root = cat.Root
+			}
+			plexPath := filepath.Join(root, it.Path)
+			// try directory first, then file path
+			if err := pc.RefreshPath(ctx, plexPath, true); err != nil {
+				logf("plex: refresh failed: " + err.Error())
+			} else {
+				refreshed++
+			}
+		}
+		if refreshed > 0 {
+			logf(fmt.Sprintf("plex: refresh ok via path (%d path(s))", refreshed))
+		}
+	}
+
+	if mode == "section" || mode == "both" {
+		for _, p := range unmapped {
+			logf("plex: no section mapped for " + p)
+		}
+		ids := make([]int, 0, len(sections))
+		for id := range sections {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+		for _, id := range ids {
+			if err := pc.RefreshSection(ctx, id); err != nil {
+				logf(fmt.Sprintf("plex: section %d refresh failed: %v", id, err))
+			} else {
+				logf(fmt.Sprintf("plex: refresh ok via section %d", id))
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package runner
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gaby/EDRmount/internal/config"
+	"github.com/gaby/EDRmount/internal/importer"
+)
+
+const (
+	resolveRetryEvery = 1 * time.Minute
+	resolveRetryBatch = 10
+	// resolveRetryGap spaces out retried imports so a recovering TMDB is not hammered.
+	resolveRetryGap = 2 * time.Second
+)
+
+// runResolveRetry re-runs TMDB enrichment for imports in library_resolve_pending
+// (enrichment hit a TMDB outage) until it succeeds. Backoff lives in the table.
+func (r *Runner) runResolveRetry(ctx context.Context) {
+	t := time.NewTicker(resolveRetryEvery)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		cfg := config.Default()
+		if r.GetConfig != nil {
+			cfg = r.GetConfig()
+		}
+		ids, err := r.jobs.DueResolvePending(ctx, resolveRetryBatch)
+		if err != nil {
+			continue
+		}
+		for _, id := range ids {
+			if ctx.Err() != nil {
+				return
+			}
+			var exists int
+			_ = r.jobs.DB().SQL.QueryRowContext(ctx, `SELECT COUNT(1) FROM nzb_imports WHERE id=?`, id).Scan(&exists)
+			if exists == 0 {
+				_ = r.jobs.ClearResolvePending(ctx, id)
+				continue
+			}
+			imp := importer.New(r.jobs)
+			ectx, cancel := context.WithTimeout(ctx, 120*time.Second)
+			err := imp.EnrichLibraryResolved(ectx, cfg, id)
+			cancel()
+			if err != nil {
+				_ = r.jobs.MarkResolvePending(ctx, id, err.Error())
+				log.Printf("library_resolved retry: import=%s still failing: %v", id, err)
+			} else {
+				_ = r.jobs.ClearResolvePending(ctx, id)
+				log.Printf("library_resolved retry: import=%s resolved", id)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(resolveRetryGap):
+			}
+		}
+	}
+}
@@ -3,21 +3,22 @@ package runner
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gaby/EDRmount/internal/config"
-	"github.com/gaby/EDRmount/internal/fusefs"
 	"github.com/gaby/EDRmount/internal/importer"
 	"github.com/gaby/EDRmount/internal/jobs"
 	"github.com/gaby/EDRmount/internal/library"
-	"github.com/gaby/EDRmount/internal/plex"
+	"github.com/gaby/EDRmount/internal/nzb"
 )
 
 var rePercent = regexp.MustCompile(`\b(\d{1,3})%\b`)
@@ -28,6 +29,7 @@ type Runner struct {
 	jobs *jobs.Store
 
 	UploadConcurrency int
+	ImportConcurrency int
 	PollInterval      time.Duration
 	Mode              string // "stub" or "exec" (dev)
 
@@ -35,14 +37,38 @@ type Runner struct {
 	NyuuPath   string // default: /usr/local/bin/nyuu
 
 	GetConfig func() config.Config // optional live config provider
+
+	plexQueue plexPending // debounced Plex refreshes (plex.refresh_debounce_seconds)
 }
 
 func New(j *jobs.Store) *Runner {
-	return &Runner{jobs: j, UploadConcurrency: 1, PollInterval: 1 * time.Second, Mode: "stub", NgPostPath: "/usr/local/bin/ngpost", NyuuPath: "/usr/local/bin/nyuu"}
+	return &Runner{jobs: j, UploadConcurrency: 1, ImportConcurrency: 2, PollInterval: 1 * time.Second, Mode: "stub", NgPostPath: config.DefaultNgPostBinary, NyuuPath: config.DefaultNyuuBinary}
 }
 
 func (r *Runner) Run(ctx context.Context) {
+	go r.runStagingSweeper(ctx)
+	go r.runResolveRetry(ctx)
+	go r.runManualPruneSweeper(ctx)
+	go r.runPlayHistoryPrune(ctx)
+	go r.runTrashPurge(ctx)
+	go r.runPlexDebounce(ctx)
+
 	semUpload := make(chan struct{}, r.UploadConcurrency)
+	importConcurrency := r.ImportConcurrency
+	if importConcurrency <= 0 {
+		importConcurrency = 2
+	}
+	semImport := make(chan struct{}, importConcurrency)
+	pools := []jobPool{
+		{sem: semUpload, types: []jobs.Type{jobs.TypeUpload}},
+		{sem: semImport, types: []jobs.Type{jobs.TypeImport}},
+		// Thumbnails run ffmpeg over a streamed head: one at a time, and never in an
+		// import slot, so a burst of them cannot hold up new NZBs.
+		{sem: make(chan struct{}, 1), types: []jobs.Type{jobs.TypeThumbnail}},
+		// Measuring decoded sizes downloads the whole release: one at a time, outside
+		// the import slots.
+		{sem: make(chan struct{}, 1), types: []jobs.Type{jobs.TypeDecodedSizes}},
+	}
 	t := time.NewTicker(r.PollInterval)
 	defer t.Stop()
 
@@ -51,32 +77,135 @@ func (r *Runner) Run(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-t.C:
-			job, err := r.jobs.ClaimNext(ctx)
+			// Paused: keep in-flight jobs running but don't claim new ones.
+			if paused, err := r.jobs.Paused(ctx); err == nil && paused {
+				continue
+			}
+			job, slot, err := r.claim(ctx, pools)
 			if err != nil {
-				if err == jobs.ErrNoQueuedJobs {
-					continue
-				}
 				continue
 			}
 
 			switch job.Type {
 			case jobs.TypeUpload:
-				semUpload <- struct{}{}
 				go func(j *jobs.Job) {
-					defer func() { <-semUpload }()
+					defer func() { <-slot }()
 					r.runUpload(ctx, j)
 				}(job)
 			case jobs.TypeHealthRepair:
 				go r.runHealth(ctx, job)
 			case jobs.TypeHealthScan:
 				go r.runHealthScan(ctx, job)
+			case jobs.TypeHealthCheck:
+				go r.runHealthCheck(ctx, job)
+			case jobs.TypeThumbnail:
+				go func(j *jobs.Job) {
+					defer func() { <-slot }()
+					r.runThumbnails(ctx, j)
+				}(job)
+			case jobs.TypeDecodedSizes:
+				go func(j *jobs.Job) {
+					defer func() { <-slot }()
+					r.runDecodedSizes(ctx, j)
+				}(job)
 			default:
-				go r.runImport(ctx, job)
+				go func(j *jobs.Job) {
+					defer func() { <-slot }()
+					r.runImport(ctx, j)
+				}(job)
 			}
 		}
 	}
 }
 
+// claimTypes maps runner.roles to the job types the runner may claim; nil (every type)
+// when all roles are enabled.
+func (r *Runner) claimTypes() []jobs.Type {
+	cfg := config.Default()
+	if r.GetConfig != nil {
+		cfg = r.GetConfig()
+	}
+	rc := cfg.Runner
+	if rc.HasRole(config.RoleImport) && rc.HasRole(config.RoleUpload) && rc.HasRole(config.RoleHealth) {
+		return nil
+	}
+	types := make([]jobs.Type, 0, 6)
+	if rc.HasRole(config.RoleImport) {
+		types = append(types, jobs.TypeImport, jobs.TypeThumbnail, jobs.TypeDecodedSizes)
+	}
+	if rc.HasRole(config.RoleUpload) {
+		types = append(types, jobs.TypeUpload)
+	}
+	if rc.HasRole(config.RoleHealth) {
+		types = append(types, jobs.TypeHealthRepair, jobs.TypeHealthScan, jobs.TypeHealthCheck)
+	}
+	return types
+}
+
+// allJobTypes is every job type the runner knows how to run.
+var allJobTypes = []jobs.Type{
+	jobs.TypeImport, jobs.TypeUpload,
+	jobs.TypeHealthRepair, jobs.TypeHealthScan, jobs.TypeHealthCheck,
+	jobs.TypeThumbnail, jobs.TypeDecodedSizes,
+}
+
+// jobPool bounds how many jobs of its types run at once.
+type jobPool struct {
+	sem   chan struct{}
+	types []jobs.Type
+}
+
+// claim claims the next job without ever blocking the dispatch loop: a slot is taken
+// in each pool up front, and the types of a full pool stay queued. The claimed job
+// keeps its pool's slot (nil for unbounded types); every other slot is given back.
+func (r *Runner) claim(ctx context.Context, pools []jobPool) (*jobs.Job, chan struct{}, error) {
+	allowed := r.claimTypes()
+	claimable := func(t jobs.Type) bool { return allowed == nil || slices.Contains(allowed, t) }
+
+	bounded := map[jobs.Type]chan struct{}{}
+	held := make([]chan struct{}, 0, len(pools))
+	types := make([]jobs.Type, 0, len(allJobTypes))
+	for _, p := range pools {
+		for _, t := range p.types {
+			bounded[t] = p.sem
+		}
+		if !slices.ContainsFunc(p.types, claimable) {
+			continue
+		}
+		select {
+		case p.sem <- struct{}{}:
+			held = append(held, p.sem)
+			for _, t := range p.types {
+				if claimable(t) {
+					types = append(types, t)
+				}
+			}
+		default:
+		}
+	}
+	for _, t := range allJobTypes {
+		if _, ok := bounded[t]; !ok && claimable(t) {
+			types = append(types, t)
+		}
+	}
+
+	var job *jobs.Job
+	err := jobs.ErrNoQueuedJobs
+	if len(types) > 0 {
+		job, err = r.jobs.ClaimNext(ctx, types...)
+	}
+	var slot chan struct{}
+	if err == nil {
+		slot = bounded[job.Type]
+	}
+	for _, sem := range held {
+		if sem != slot {
+			<-sem
+		}
+	}
+	return job, slot, err
+}
+
 func (r *Runner) runImport(ctx context.Context, j *jobs.Job) {
 	_ = r.jobs.AppendLog(ctx, j.ID, "starting import job")
 	var p struct {
@@ -89,6 +218,19 @@ func (r *Runner) runImport(ctx context.Context, j *jobs.Job) {
 		cfg = r.GetConfig()
 	}
 	imp := importer.New(r.jobs)
+	imp.SubjectPatterns = cfg.Subject.Patterns
+	imp.NZBRoots = append([]string{cfg.Watch.NZB.Dir}, cfg.NZBOutputRoots()...)
+	imp.Limits = nzb.Limits{MaxFiles: cfg.Import.MaxFilesPerNZB, MaxSegmentsPerFile: cfg.Import.MaxSegmentsPerFile}
+	imp.ContentDedupe = cfg.Import.ContentDedupe
+	imp.IncrementalCommit = cfg.Import.IncrementalCommit
+	imp.StreamBatchSegments = cfg.Import.StreamBatchSegments
+	// A health repair re-uploaded through the media inbox keeps its import id.
+	importID := j.ID
+	if id, ok := r.jobs.TakeHealthReplacement(ctx, p.Path); ok {
+		importID = id
+		imp.ReuseImportID = id
+		_ = r.jobs.AppendLog(ctx, j.ID, "health re-upload: reusing import id "+id)
+	}
 	files, bytes, err := imp.ImportNZB(ctx, j.ID, p.Path)
 	if err != nil {
 		msg := err.Error()
@@ -98,36 +240,40 @@ func (r *Runner) runImport(ctx context.Context, j *jobs.Job) {
 	}
 	_ = r.jobs.AppendLog(ctx, j.ID, fmt.Sprintf("imported NZB: files=%d total_bytes=%d", files, bytes))
 	enrichCtx, cancelEnrich := context.WithTimeout(ctx, 120*time.Second)
-	if err := imp.EnrichLibraryResolved(enrichCtx, cfg, j.ID); err != nil {
+	if err := imp.EnrichLibraryResolved(enrichCtx, cfg, importID); errors.Is(err, importer.ErrTMDBUnavailable) {
+		_ = r.jobs.AppendLog(ctx, j.ID, "library_resolved: WARN: "+err.Error()+" (queued for retry)")
+		_ = r.jobs.MarkResolvePending(ctx, importID, err.Error())
+	} else if err != nil {
 		_ = r.jobs.AppendLog(ctx, j.ID, "library_resolved: WARN: "+err.Error())
+	} else {
+		_ = r.jobs.ClearResolvePending(ctx, importID)
 	}
 	cancelEnrich()
 
+	if strings.TrimSpace(cfg.Hooks.OnImport) != "" {
+		if err := r.runImportHook(ctx, j.ID, cfg, importID); err != nil {
+			_ = r.jobs.AppendLog(ctx, j.ID, "hook: WARN: "+err.Error())
+		}
+	}
+
 	// Optional: ask Plex to refresh only the new item(s) in library-auto.
 	if r.GetConfig != nil {
-		cfg := r.GetConfig()
-		if cfg.Plex.Enabled && cfg.Plex.RefreshOnImport {
-			pc := plex.New(cfg.Plex.BaseURL, cfg.Plex.Token)
-			if pc.Enabled() {
-				paths, perr := fusefs.AutoVirtualPathsForImport(ctx, cfg, r.jobs, j.ID)
-				if perr != nil {
-					_ = r.jobs.AppendLog(ctx, j.ID, "plex: cannot build auto paths: "+perr.Error())
-				} else {
-					refreshed := 0
-					for _, pth := range paths {
-						plexPath := filepath.Join(cfg.Plex.PlexRoot, pth)
-						// try directory first, then file path
-						if err := pc.RefreshPath(ctx, plexPath, true); err != nil {
-							_ = r.jobs.AppendLog(ctx, j.ID, "plex: refresh failed: "+err.Error())
-						} else {
-							refreshed++
-						}
-					}
-					if refreshed > 0 {
-						_ = r.jobs.AppendLog(ctx, j.ID, fmt.Sprintf("plex: refresh ok (%d path(s))", refreshed))
-					}
-				}
-			}
+		r.plexRefreshImport(ctx, j.ID, importID, r.GetConfig())
+	}
+
+	if cfg.Thumbnails.Enabled {
+		if tj, err := r.jobs.Enqueue(ctx, jobs.TypeThumbnail, map[string]string{"import_id": importID}); err != nil {
+			_ = r.jobs.AppendLog(ctx, j.ID, "thumbnails: WARN: "+err.Error())
+		} else {
+			_ = r.jobs.AppendLog(ctx, j.ID, "thumbnails: queued job "+tj.ID)
+		}
+	}
+
+	if cfg.Import.PrecomputeDecodedSizes {
+		if dj, err := r.jobs.Enqueue(ctx, jobs.TypeDecodedSizes, map[string]string{"import_id": importID}); err != nil {
+			_ = r.jobs.AppendLog(ctx, j.ID, "decoded sizes: WARN: "+err.Error())
+		} else {
+			_ = r.jobs.AppendLog(ctx, j.ID, "decoded sizes: queued job "+dj.ID)
 		}
 	}
 
@@ -180,12 +326,19 @@ func (r *Runner) runUpload(ctx context.Context, j *jobs.Job) {
 				return
 			}
 		}
-
-		outDir := ng.OutputDir
-		if outDir == "" {
-			outDir = "/host/inbox/nzb"
+		// Providers reject oversized posts late (after most of the upload); fail up front instead.
+		if name, size, over := oversizedUploadFile(p.Path, cfg.Upload.MaxFileBytes); over {
+			msg := fmt.Sprintf("%s is %d bytes, over upload.max_file_bytes (%d); split it (e.g. mkvmerge --split size:...) and upload the parts", filepath.Base(name), size, cfg.Upload.MaxFileBytes)
+			_ = r.jobs.AppendLog(ctx, j.ID, "ERROR: "+msg)
+			_ = r.jobs.SetFailed(ctx, j.ID, msg)
+			return
 		}
+
 		sourceGuess := library.GuessFromFilename(filepath.Base(p.Path))
+		if g := cfg.Upload.GroupsFor(sourceGuess.IsSeries, ng.Groups); g != ng.Groups {
+			ng.Groups = g
+			_ = r.jobs.AppendLog(ctx, j.ID, "groups: category-specific -> "+g)
+		}
 		normalizedInputPath := p.Path
 		if np, changed, nerr := maybeNormalizeWithFileBot(ctx, cfg, p.Path, func(line string) {
 			_ = r.jobs.AppendLog(ctx, j.ID, line)
@@ -198,16 +351,20 @@ func (r *Runner) runUpload(ctx context.Context, j *jobs.Job) {
 		base := strings.TrimSuffix(filepath.Base(normalizedInputPath), filepath.Ext(normalizedInputPath))
 
 		// IMPORTANT: write NZB to staging first so the import watcher never sees an incomplete NZB.
-		cacheDir := cfg.Paths.CacheDir
-		if strings.TrimSpace(cacheDir) == "" {
-			cacheDir = "/cache"
-		}
-		stagingDir := filepath.Join(cacheDir, "nzb-staging")
+		stagingRoot := cfg.Paths.StagingRoot()
+		stagingDir := filepath.Join(stagingRoot, "nzb-staging")
 		_ = os.MkdirAll(stagingDir, 0o755)
 		stagingNZB := filepath.Join(stagingDir, fmt.Sprintf("%s-%s.nzb", base, j.ID))
 
-		finalNZB := buildRawNZBPath(cfg, normalizedInputPath, outDir, sourceGuess.Quality)
-		if st, err := os.Stat(finalNZB); err == nil && st.Size() > 0 {
+		finalNZB := buildRawNZBPath(cfg, normalizedInputPath, "", sourceGuess.Quality)
+		// Repaired media handed over by health keeps the original NZB path.
+		replacing := false
+		if orig, ok := r.jobs.HealthReplacementForMedia(ctx, filepath.Base(p.Path)); ok {
+			finalNZB = orig
+			replacing = true
+			_ = r.jobs.AppendLog(ctx, j.ID, "health re-upload: reusing original nzb path "+orig)
+		}
+		if st, err := os.Stat(finalNZB); err == nil && st.Size() > 0 && !replacing {
 			_ = r.jobs.AppendLog(ctx, j.ID, "nzb already exists at target path; skipping new upload to avoid duplicates: "+finalNZB)
 			_ = r.jobs.SetDone(ctx, j.ID)
 			return
@@ -236,11 +393,13 @@ func (r *Runner) runUpload(ctx context.Context, j *jobs.Job) {
 			lastPhase = p
 			_ = r.jobs.AppendLog(ctx, j.ID, "PHASE: "+p)
 		}
+		// Last uploader lines, to tell transient failures (retried) from permanent ones.
+		tail := &outputTail{max: 20}
 
 		// Optional PAR2 generation (staged in /cache, then optionally persisted under /host/inbox/par2)
 		parEnabled := cfg.Upload.Par.Enabled && cfg.Upload.Par.RedundancyPercent > 0
 		parKeep := cfg.Upload.Par.KeepParityFiles && strings.TrimSpace(cfg.Upload.Par.Dir) != ""
-		parStagingDir := filepath.Join(cacheDir, "par-staging", j.ID)
+		parStagingDir := filepath.Join(stagingRoot, "par-staging", j.ID)
 		var parDir string // where par2 files are generated (staging)
 		if parEnabled {
 			emitPhase("Generando PAR (Generating PAR)")
@@ -320,7 +479,19 @@ func (r *Runner) runUpload(ctx context.Context, j *jobs.Job) {
 			}()
 
 			err := error(nil)
+			var releasePar func()
 			if parEnabled {
+				waitStart := time.Now()
+				releasePar, err = par2Create.acquire(ctx, cfg.Upload.Par.MaxConcurrent)
+				if waited := time.Since(waitStart); err == nil && waited > time.Second {
+					_ = r.jobs.AppendLog(ctx, j.ID, fmt.Sprintf("par2: waited %s for a free PAR2 slot (upload.par.max_concurrent=%d)", waited.Round(time.Second), max(cfg.Upload.Par.MaxConcurrent, 1)))
+				}
+			}
+			if parEnabled && err == nil {
+				name, argv, note := par2CreateCommand(cfg.Upload.Par, args)
+				if note != "" {
+					_ = r.jobs.AppendLog(ctx, j.ID, "WARN: "+note)
+				}
 				err = runCommand(ctx, func(line string) {
 					clean := strings.TrimSpace(line)
 					if m := rePercent.FindStringSubmatch(clean); len(m) == 2 {
@@ -334,7 +505,8 @@ func (r *Runner) runUpload(ctx context.Context, j *jobs.Job) {
 					if clean != "" {
 						_ = r.jobs.AppendLog(ctx, j.ID, clean)
 					}
-				}, "par2", args...)
+				}, name, argv...)
+				releasePar()
 			}
 			stopTick()
 			if !parEnabled {
@@ -362,10 +534,18 @@ func (r *Runner) runUpload(ctx context.Context, j *jobs.Job) {
 					args = append(args, "-g", ng.Groups)
 				}
 				// Obfuscation (safe for pipeline): randomize article metadata only.
-				// Keep filename/yenc-name stable so downstream import/mount keeps working.
+				// Keep filename/yenc-name stable so downstream import/mount keeps working
+				// (upload.nyuu_nzb_subject is validated to keep {filename}).
+				subj, nzbSubj := cfg.Upload.NyuuSubject, cfg.Upload.NyuuNZBSubject
+				if strings.TrimSpace(subj) == "" {
+					subj = config.DefaultNyuuSubject
+				}
+				if strings.TrimSpace(nzbSubj) == "" {
+					nzbSubj = config.DefaultNyuuNZBSubject
+				}
 				args = append(args,
-					"--subject", "${rand(40)} yEnc ({part}/{parts})",
-					"--nzb-subject", `"{filename}" yEnc ({part}/{parts})`,
+					"--subject", subj,
+					"--nzb-subject", nzbSubj,
 					"--message-id", "${rand(24)}-${rand(12)}@nyuu",
 					"--from", "poster <poster@example.com>",
 				)
@@ -375,8 +555,9 @@ func (r *Runner) runUpload(ctx context.Context, j *jobs.Job) {
 				args = append(args, "-u", ng.User, "-p", ng.Pass)
 				// Input file/dir (nyuu supports directories; keep subdirs)
 				args = append(args, "-r", "keep")
-				// NOTE: PAR2 is kept locally only (not uploaded as part of the release).
 				args = append(args, p.Path)
+				// PAR2 is kept locally only unless upload.par.upload_parity posts it with the release.
+				args = append(args, r.uploadParityFiles(ctx, j.ID, cfg, parDir)...)
 
 				emitPhase("Subiendo a Usenet (Uploading)")
 				emitProgress(1)
@@ -384,6 +565,7 @@ func (r *Runner) runUpload(ctx context.Context, j *jobs.Job) {
 				err := runCommand(ctx, func(line string) {
 					clean := sanitizeLine(line, ng.Pass)
 					_ = r.jobs.AppendLog(ctx, j.ID, clean)
+					tail.add(clean)
 					if m := rePercent.FindStringSubmatch(clean); len(m) == 2 {
 						if n, e := strconv.Atoi(m[1]); e == nil && n >= 0 && n <= 100 {
 							emitProgress(n)
@@ -396,8 +578,7 @@ func (r *Runner) runUpload(ctx context.Context, j *jobs.Job) {
 						_ = r.jobs.AppendLog(ctx, j.ID, "WARN: nyuu crashed with illegal instruction; retrying with ngpost")
 						provider = "ngpost"
 					} else {
-						_ = r.jobs.AppendLog(ctx, j.ID, "ERROR: "+msg)
-						_ = r.jobs.SetFailed(ctx, j.ID, msg)
+						r.failUpload(ctx, j, cfg, msg, tail.lines)
 						return
 					}
 				}
@@ -405,7 +586,7 @@ func (r *Runner) runUpload(ctx context.Context, j *jobs.Job) {
 					// Move staging NZB into the watched NZB inbox only after the uploader has finished.
 					emitPhase("Moviendo NZB a NZB inbox (Move to NZB inbox)")
 					emitProgress(99)
-					_, err = moveNZBStagingToFinal(stagingNZB, finalNZB)
+					finalNZB, err = moveNZBStagingToFinal(stagingNZB, finalNZB, replacing)
 					if err != nil {
 						msg := err.Error()
 						_ = r.jobs.AppendLog(ctx, j.ID, "ERROR: move nzb: "+msg)
@@ -416,9 +597,9 @@ func (r *Runner) runUpload(ctx context.Context, j *jobs.Job) {
 
 					// Persist PAR2 files (keep) if enabled.
 					if parKeep && parDir != "" {
-						relDir, err := filepath.Rel(outDir, filepath.Dir(finalNZB))
-						if err != nil {
-							relDir = ""
+						relDir := ""
+						if outDir, ok := cfg.NZBRootFor(finalNZB); ok {
+							relDir, _ = filepath.Rel(outDir, filepath.Dir(finalNZB))
 						}
 						keepDir := filepath.Join(strings.TrimSpace(cfg.Upload.Par.Dir), relDir)
 						_ = os.MkdirAll(keepDir, 0o755)
@@ -453,8 +634,11 @@ func (r *Runner) runUpload(ctx context.Context, j *jobs.Job) {
 						_ = r.jobs.AppendLog(ctx, j.ID, fmt.Sprintf("par: kept %d file(s) in %s", moved, keepDir))
 					}
 
+					r.cleanupUploadedMedia(ctx, j.ID, cfg, p.Path)
+
+					// Import is handled by the NZB watcher (watch.nzb) unless upload.import_immediately.
+					r.enqueueImportAfterUpload(ctx, j, cfg, finalNZB)
 					_ = r.jobs.SetDone(ctx, j.ID)
-					// Import is handled by the NZB watcher (watch.nzb). We just drop the NZB into the inbox.
 					return
 				}
 			}
@@ -484,6 +668,9 @@ func (r *Runner) runUpload(ctx context.Context, j *jobs.Job) {
 				if ng.TmpDir != "" {
 					args = append(args, "--tmp_dir", ng.TmpDir)
 				}
+				for _, pf := range r.uploadParityFiles(ctx, j.ID, cfg, parDir) {
+					args = append(args, "-i", pf)
+				}
 				args = append(args, "-u", ng.User, "-p", ng.Pass, "--disp_progress", "files")
 
 				emitPhase("Subiendo a Usenet (Uploading)")
@@ -496,6 +683,7 @@ func (r *Runner) runUpload(ctx context.Context, j *jobs.Job) {
 				err := runCommand(ctx, func(line string) {
 					clean := sanitizeLine(line, ng.Pass)
 					_ = r.jobs.AppendLog(ctx, j.ID, clean)
+					tail.add(clean)
 					if m := rePercent.FindStringSubmatch(clean); len(m) == 2 {
 						if n, e := strconv.Atoi(m[1]); e == nil && n >= 0 && n <= 100 {
 							emitProgress(n)
@@ -510,9 +698,7 @@ func (r *Runner) runUpload(ctx context.Context, j *jobs.Job) {
 					}
 				}, r.NgPostPath, args...)
 				if err != nil {
-					msg := err.Error()
-					_ = r.jobs.AppendLog(ctx, j.ID, "ERROR: "+msg)
-					_ = r.jobs.SetFailed(ctx, j.ID, msg)
+					r.failUpload(ctx, j, cfg, err.Error(), tail.lines)
 					return
 				}
 				// ngpost sometimes auto-renames the NZB. Prefer the actual produced staging path.
@@ -522,7 +708,7 @@ func (r *Runner) runUpload(ctx context.Context, j *jobs.Job) {
 				}
 				emitPhase("Moviendo NZB a NZB inbox (Move to NZB inbox)")
 				emitProgress(99)
-				_, err = moveNZBStagingToFinal(produced, finalNZB)
+				finalNZB, err = moveNZBStagingToFinal(produced, finalNZB, replacing)
 				if err != nil {
 					msg := err.Error()
 					_ = r.jobs.AppendLog(ctx, j.ID, "ERROR: move nzb: "+msg)
@@ -530,8 +716,11 @@ func (r *Runner) runUpload(ctx context.Context, j *jobs.Job) {
 					return
 				}
 				emitProgress(100)
+				r.cleanupUploadedMedia(ctx, j.ID, cfg, p.Path)
+
+				// Import is handled by the NZB watcher (watch.nzb) unless upload.import_immediately.
+				r.enqueueImportAfterUpload(ctx, j, cfg, finalNZB)
 				_ = r.jobs.SetDone(ctx, j.ID)
-				// Import is handled by the NZB watcher (watch.nzb). We just drop the NZB into the inbox.
 				return
 			}
 			if ng.Enabled {
@@ -557,9 +746,46 @@ func (r *Runner) runUpload(ctx context.Context, j *jobs.Job) {
 	_ = r.jobs.SetDone(ctx, j.ID)
 }
 
+// uploadParityFiles returns the .par2 files of parDir to post with the release when
+// upload.par.upload_parity is set; nil otherwise or when no parity was generated.
+func (r *Runner) uploadParityFiles(ctx context.Context, jobID string, cfg config.Config, parDir string) []string {
+	if !cfg.Upload.Par.UploadParity {
+		return nil
+	}
+	if parDir == "" {
+		_ = r.jobs.AppendLog(ctx, jobID, "WARN: upload.par.upload_parity set but no parity was generated; uploading without PAR2")
+		return nil
+	}
+	entries, _ := os.ReadDir(parDir)
+	files := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(strings.ToLower(e.Name()), ".par2") {
+			files = append(files, filepath.Join(parDir, e.Name()))
+		}
+	}
+	_ = r.jobs.AppendLog(ctx, jobID, fmt.Sprintf("par: uploading %d parity file(s) with the release", len(files)))
+	return files
+}
+
+// enqueueImportAfterUpload queues the import of a just-finalized NZB when
+// upload.import_immediately is set. If the watcher picks the same file up meanwhile,
+// Enqueue's path dedupe returns the already queued job.
+func (r *Runner) enqueueImportAfterUpload(ctx context.Context, j *jobs.Job, cfg config.Config, finalNZB string) {
+	if !cfg.Upload.ImportImmediately {
+		return
+	}
+	ij, err := r.jobs.Enqueue(ctx, jobs.TypeImport, map[string]string{"path": finalNZB})
+	if err != nil {
+		_ = r.jobs.AppendLog(ctx, j.ID, "WARN: import enqueue failed (watcher will pick it up): "+err.Error())
+		return
+	}
+	_ = r.jobs.AppendLog(ctx, j.ID, fmt.Sprintf("import queued: job=%s nzb=%s", ij.ID, finalNZB))
+}
+
 // moveNZBStagingToFinal moves a staging NZB into the RAW directory only after it is complete.
 // It tries to behave atomically at the destination by writing to a temp file then renaming.
-func moveNZBStagingToFinal(stagingPath, finalPath string) (string, error) {
+// With replace, an existing file at finalPath is overwritten instead of picking a "_N" name.
+func moveNZBStagingToFinal(stagingPath, finalPath string, replace bool) (string, error) {
 	if strings.TrimSpace(stagingPath) == "" || strings.TrimSpace(finalPath) == "" {
 		return "", fmt.Errorf("staging and final paths required")
 	}
@@ -569,7 +795,7 @@ func moveNZBStagingToFinal(stagingPath, finalPath string) (string, error) {
 
 	// Choose a unique final path if it already exists.
 	dest := finalPath
-	if _, err := os.Stat(dest); err == nil {
+	if _, err := os.Stat(dest); err == nil && !replace {
 		ext := filepath.Ext(finalPath)
 		base := strings.TrimSuffix(finalPath, ext)
 		for i := 2; i < 1000; i++ {
@@ -681,21 +907,28 @@ func detectSeasonFromDir(path string) int {
 	return 0
 }
 
-func buildRawNZBPath(cfg config.Config, inputPath, rawRoot, qualityHint string) string {
-	if strings.TrimSpace(rawRoot) == "" {
-		rawRoot = "/host/inbox/nzb"
+// rawBucketFolder is library.BucketFolder restricted to A-Z/# for the alpha scheme.
+func rawBucketFolder(scheme, title string, year int) string {
+	initial := library.BucketFolder(scheme, title, year)
+	if initial == "" || strings.HasSuffix(initial, "0s") {
+		return initial
+	}
+	if len([]rune(initial)) != 1 || (initial[0] < 'A' || initial[0] > 'Z') {
+		return "#"
 	}
+	return initial
+}
+
+func buildRawNZBPath(cfg config.Config, inputPath, rawRoot, qualityHint string) string {
 	base := filepath.Base(inputPath)
 	g := library.GuessFromFilename(base)
-	// normalize quality to 1080/2160
-	q := strings.ToLower(strings.TrimSpace(qualityHint))
-	if q == "" {
-		q = strings.ToLower(strings.TrimSpace(g.Quality))
-	}
-	quality := "1080"
-	if q == "4k" || strings.Contains(q, "2160") {
-		quality = "2160"
+	// normalize quality to the same tier the library view uses (4K is stored as 2160 here)
+	l := cfg.Library.Defaults()
+	q := qualityHint
+	if library.NormalizeQuality(q, l.QualityBuckets) == "" {
+		q = library.DetectQuality(base, l.QualityBuckets)
 	}
+	quality := library.RawQualityFolder(library.QualityOr(q, l.DefaultQuality, l.QualityBuckets), l.QualityBuckets)
 
 	// helpers
 	safe := func(s string) string {
@@ -706,8 +939,6 @@ func buildRawNZBPath(cfg config.Config, inputPath, rawRoot, qualityHint string)
 		return s
 	}
 
-	l := cfg.Library.Defaults()
-
 	// If inputPath is a directory, treat it as series content (season pack or series folder).
 	isDir := false
 	if st, err := os.Stat(inputPath); err == nil {
@@ -716,6 +947,10 @@ func buildRawNZBPath(cfg config.Config, inputPath, rawRoot, qualityHint string)
 	if isDir {
 		g.IsSeries = true
 	}
+	// Empty rawRoot = the category's output root (upload.movies_output_dir / series_output_dir).
+	if strings.TrimSpace(rawRoot) == "" {
+		rawRoot = cfg.NZBOutputDir(g.IsSeries)
+	}
 
 	if g.IsSeries {
 		seriesTitle := strings.TrimSpace(g.Title)
@@ -746,10 +981,7 @@ func buildRawNZBPath(cfg config.Config, inputPath, rawRoot, qualityHint string)
 				yearPart = fmt.Sprintf(" (%d)", year)
 			}
 		}
-		initial := library.InitialFolder(seriesName)
-		if strings.TrimSpace(initial) == "" || len([]rune(initial)) != 1 || (initial[0] < 'A' || initial[0] > 'Z') {
-			initial = "#"
-		}
+		initial := rawBucketFolder(cfg.Library.BucketScheme, seriesName, 0)
 		seriesFolder := safe(seriesName + yearPart)
 
 		fileName := ""
@@ -769,11 +1001,12 @@ func buildRawNZBPath(cfg config.Config, inputPath, rawRoot, qualityHint string)
 			fileName = fmt.Sprintf("%s%s.nzb", safe(seriesName), yearPart)
 		}
 
+		if cfg.Upload.Layout == "flat" {
+			return filepath.Join(rawRoot, fileName)
+		}
 		// NZB layout for series: SERIES/A/.../Serie (Año)/<file>.nzb
 		rel := filepath.Join(l.SeriesRoot, initial, seriesFolder, fileName)
-		if cfg.Library.UppercaseFolders {
-			rel = library.ApplyUppercaseFolders(rel)
-		}
+		rel = library.UppercaseFolders(l, rel)
 		return filepath.Join(rawRoot, rel)
 	}
 
@@ -788,16 +1021,14 @@ func buildRawNZBPath(cfg config.Config, inputPath, rawRoot, qualityHint string)
 	}
 	movieFolder := safe(movieTitle + yearPart)
 	fileName := movieFolder + ".nzb"
-
-	initial := library.InitialFolder(movieTitle)
-	if strings.TrimSpace(initial) == "" || len([]rune(initial)) != 1 || (initial[0] < 'A' || initial[0] > 'Z') {
-		initial = "#"
+	if cfg.Upload.Layout == "flat" {
+		return filepath.Join(rawRoot, fileName)
 	}
+
+	initial := rawBucketFolder(cfg.Library.BucketScheme, movieTitle, year)
 	// NZB files: keep them directly under .../<Initial>/ (no extra movie folder).
 	// The FUSE/library view can still expose movie folders for MKVs.
 	rel := filepath.Join(l.MoviesRoot, quality, initial, fileName)
-	if cfg.Library.UppercaseFolders {
-		rel = library.ApplyUppercaseFolders(rel)
-	}
+	rel = library.UppercaseFolders(l, rel)
 	return filepath.Join(rawRoot, rel)
 }
@@ -0,0 +1,128 @@
+package runner
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gaby/EDRmount/internal/config"
+	"github.com/gaby/EDRmount/internal/jobs"
+)
+
+// Staging NZBs are named "<base>-<jobID>.nzb" (jobID = 32 hex chars).
+var reStagingJobID = regexp.MustCompile(`-([0-9a-f]{32})\.nzb$`)
+
+const stagingSweepEvery = 1 * time.Hour
+
+// runStagingSweeper removes abandoned upload staging artifacts at startup and then periodically.
+func (r *Runner) runStagingSweeper(ctx context.Context) {
+	t := time.NewTicker(stagingSweepEvery)
+	defer t.Stop()
+	for {
+		cfg := config.Default()
+		if r.GetConfig != nil {
+			cfg = r.GetConfig()
+		}
+		n, reclaimed := r.sweepStaging(ctx, cfg)
+		if n > 0 {
+			log.Printf("staging sweep: removed %d item(s), reclaimed %d bytes", n, reclaimed)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// sweepStaging deletes nzb-staging files and par-staging/<jobID> dirs older than
+// Paths.StagingMaxAgeHours whose owning job is no longer queued/running.
+func (r *Runner) sweepStaging(ctx context.Context, cfg config.Config) (removed int, reclaimed int64) {
+	maxAge := time.Duration(cfg.Paths.StagingMaxAgeHours) * time.Hour
+	if maxAge <= 0 {
+		maxAge = 24 * time.Hour
+	}
+	cutoff := time.Now().Add(-maxAge)
+	root := cfg.Paths.StagingRoot()
+
+	nzbDir := filepath.Join(root, "nzb-staging")
+	if ents, err := os.ReadDir(nzbDir); err == nil {
+		for _, e := range ents {
+			if e.IsDir() {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil || info.ModTime().After(cutoff) {
+				continue
+			}
+			jobID := ""
+			if m := reStagingJobID.FindStringSubmatch(e.Name()); len(m) == 2 {
+				jobID = m[1]
+			}
+			if r.stagingJobActive(ctx, jobID) {
+				continue
+			}
+			if err := os.Remove(filepath.Join(nzbDir, e.Name())); err == nil {
+				removed++
+				reclaimed += info.Size()
+			}
+		}
+	}
+
+	parDir := filepath.Join(root, "par-staging")
+	if ents, err := os.ReadDir(parDir); err == nil {
+		for _, e := range ents {
+			if !e.IsDir() {
+				continue
+			}
+			p := filepath.Join(parDir, e.Name())
+			size, newest := dirSizeAndNewest(p)
+			if newest.After(cutoff) || r.stagingJobActive(ctx, e.Name()) {
+				continue
+			}
+			if err := os.RemoveAll(p); err == nil {
+				removed++
+				reclaimed += size
+			}
+		}
+	}
+	return removed, reclaimed
+}
+
+func (r *Runner) stagingJobActive(ctx context.Context, jobID string) bool {
+	jobID = strings.TrimSpace(jobID)
+	if jobID == "" {
+		return false
+	}
+	var state string
+	if err := r.jobs.DB().SQL.QueryRowContext(ctx, `SELECT state FROM jobs WHERE id=?`, jobID).Scan(&state); err != nil {
+		return false
+	}
+	return state == string(jobs.StateQueued) || state == string(jobs.StateRunning)
+}
+
+func dirSizeAndNewest(dir string) (int64, time.Time) {
+	var size int64
+	var newest time.Time
+	_ = filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d == nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+		if !d.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, newest
+}
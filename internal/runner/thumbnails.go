@@ -0,0 +1,109 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gaby/EDRmount/internal/cache"
+	"github.com/gaby/EDRmount/internal/config"
+	"github.com/gaby/EDRmount/internal/jobs"
+	"github.com/gaby/EDRmount/internal/streamer"
+	"github.com/gaby/EDRmount/internal/thumbs"
+)
+
+// runThumbnails extracts a preview frame for every video file of an import
+// (thumbnail_import, queued after the import when thumbnails.enabled). A file that
+// fails is logged and skipped; the job only fails when nothing could be read.
+func (r *Runner) runThumbnails(ctx context.Context, j *jobs.Job) {
+	var p struct {
+		ImportID string `json:"import_id"`
+	}
+	if err := json.Unmarshal(j.Payload, &p); err != nil || p.ImportID == "" {
+		_ = r.jobs.SetFailed(ctx, j.ID, "invalid payload")
+		return
+	}
+	cfg := config.Default()
+	if r.GetConfig != nil {
+		cfg = r.GetConfig()
+	}
+	if !cfg.Thumbnails.Enabled {
+		_ = r.jobs.AppendLog(ctx, j.ID, "thumbnails: disabled, nothing to do")
+		_ = r.jobs.SetDone(ctx, j.ID)
+		return
+	}
+
+	db := r.jobs.DB().SQL
+	var needsExtraction bool
+	if err := db.QueryRowContext(ctx, `SELECT needs_extraction FROM nzb_imports WHERE id=?`, p.ImportID).Scan(&needsExtraction); err != nil {
+		msg := "thumbnails: import " + p.ImportID + ": " + err.Error()
+		_ = r.jobs.AppendLog(ctx, j.ID, "ERROR: "+msg)
+		_ = r.jobs.SetFailed(ctx, j.ID, msg)
+		return
+	}
+	if needsExtraction {
+		// RAR/7z sets have no playable video to cut a frame from.
+		_ = r.jobs.AppendLog(ctx, j.ID, "thumbnails: import needs extraction, skipped")
+		_ = r.jobs.SetDone(ctx, j.ID)
+		return
+	}
+
+	type file struct {
+		idx      int
+		filename string
+		size     int64
+	}
+	rows, err := db.QueryContext(ctx, `SELECT idx,filename,total_bytes FROM nzb_files WHERE import_id=? ORDER BY idx ASC`, p.ImportID)
+	if err != nil {
+		msg := "thumbnails: " + err.Error()
+		_ = r.jobs.AppendLog(ctx, j.ID, "ERROR: "+msg)
+		_ = r.jobs.SetFailed(ctx, j.ID, msg)
+		return
+	}
+	files := make([]file, 0)
+	for rows.Next() {
+		var f file
+		if err := rows.Scan(&f.idx, &f.filename, &f.size); err != nil {
+			continue
+		}
+		if thumbs.IsVideo(f.filename) {
+			files = append(files, f)
+		}
+	}
+	rows.Close()
+
+	st := streamer.New(cfg.Download, r.jobs, cfg.Paths.CacheDir, cfg.Paths.CacheMaxBytes)
+	st.CompressCache = cfg.Paths.CompressCache
+	st.Eviction = cache.NewEviction(cfg.Paths.CacheEviction, cfg.Paths.CachePinnedImports)
+	done, failed := 0, 0
+	for _, f := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		if _, err := os.Stat(thumbs.Path(cfg.Paths.CacheDir, p.ImportID, f.idx)); err == nil {
+			done++
+			continue
+		}
+		start := time.Now()
+		fctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		err := thumbs.Generate(fctx, cfg, st, p.ImportID, f.idx, f.filename, f.size)
+		cancel()
+		if err != nil {
+			failed++
+			_ = r.jobs.AppendLog(ctx, j.ID, fmt.Sprintf("thumbnails: WARN: %s: %v", f.filename, err))
+			continue
+		}
+		done++
+		_ = r.jobs.AppendLog(ctx, j.ID, fmt.Sprintf("thumbnails: %s (%s)", f.filename, time.Since(start).Round(time.Millisecond)))
+	}
+	if failed > 0 && done == 0 {
+		msg := fmt.Sprintf("thumbnails: all %d file(s) failed", failed)
+		_ = r.jobs.AppendLog(ctx, j.ID, "ERROR: "+msg)
+		_ = r.jobs.SetFailed(ctx, j.ID, msg)
+		return
+	}
+	_ = r.jobs.AppendLog(ctx, j.ID, fmt.Sprintf("thumbnails: %d/%d video file(s)", done, len(files)))
+	_ = r.jobs.SetDone(ctx, j.ID)
+}
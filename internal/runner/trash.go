@@ -0,0 +1,37 @@
+package runner
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gaby/EDRmount/internal/config"
+	"github.com/gaby/EDRmount/internal/trash"
+)
+
+const trashPurgeEvery = 6 * time.Hour
+
+// runTrashPurge empties trash folders older than trash.retention_days.
+func (r *Runner) runTrashPurge(ctx context.Context) {
+	t := time.NewTicker(trashPurgeEvery)
+	defer t.Stop()
+	for {
+		cfg := config.Default()
+		if r.GetConfig != nil {
+			cfg = r.GetConfig()
+		}
+		if days := cfg.Trash.RetentionDays; days > 0 {
+			cutoff := time.Now().Add(-time.Duration(days) * 24 * time.Hour)
+			if n, size, err := trash.Purge(trash.Root, cutoff); err != nil {
+				log.Printf("trash purge: %v", err)
+			} else if n > 0 {
+				log.Printf("trash purge: removed %d file(s), %d bytes", n, size)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
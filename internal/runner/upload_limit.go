@@ -0,0 +1,35 @@
+package runner
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// oversizedUploadFile returns the first file under path (the path itself when it is a
+// file) larger than max bytes, with its size.
+func oversizedUploadFile(path string, max int64) (string, int64, bool) {
+	if max <= 0 {
+		return "", 0, false
+	}
+	st, err := os.Stat(path)
+	if err != nil {
+		return "", 0, false
+	}
+	if !st.IsDir() {
+		return path, st.Size(), st.Size() > max
+	}
+	var name string
+	var size int64
+	_ = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.Size() > max {
+			name, size = p, info.Size()
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return name, size, name != ""
+}
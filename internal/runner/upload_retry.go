@@ -0,0 +1,88 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gaby/EDRmount/internal/config"
+	"github.com/gaby/EDRmount/internal/jobs"
+)
+
+// outputTail keeps the last lines an uploader printed; the process error itself is
+// usually just "exit status N", the reason is in the output.
+type outputTail struct {
+	max   int
+	lines []string
+}
+
+func (t *outputTail) add(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	t.lines = append(t.lines, line)
+	if len(t.lines) > t.max {
+		t.lines = t.lines[len(t.lines)-t.max:]
+	}
+}
+
+// Permanent markers win over transient ones: a login rejected after a reconnect
+// should not be retried.
+var (
+	uploadPermanentMarkers = []string{
+		"481 ", "482 ", "502 ", "authentication", "auth failed", "invalid user", "invalid password",
+		"bad credentials", "no such file", "permission denied", "no space left",
+	}
+	uploadTransientMarkers = []string{
+		"timeout", "timed out", "connection reset", "connection refused", "connection closed",
+		"connection aborted", "broken pipe", "unexpected eof", "network is unreachable",
+		"no route to host", "temporary failure", "temporarily unavailable", "try again",
+		"too many connections", "400 ", "503 ", "econnreset", "etimedout", "econnrefused", "socket hang up",
+	}
+)
+
+// transientUploadError reports whether an uploader failure looks like a network or
+// provider hiccup worth retrying, judging by the error and the uploader's last lines.
+func transientUploadError(msg string, tail []string) bool {
+	text := strings.ToLower(msg + "\n" + strings.Join(tail, "\n"))
+	for _, m := range uploadPermanentMarkers {
+		if strings.Contains(text, m) {
+			return false
+		}
+	}
+	for _, m := range uploadTransientMarkers {
+		if strings.Contains(text, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadRetryDelay is the wait before retry number attempt (1-based): the base delay,
+// doubled per attempt.
+func uploadRetryDelay(cfg config.Config, attempt int) time.Duration {
+	base := time.Duration(cfg.Upload.RetryDelaySeconds) * time.Second
+	if attempt > 1 {
+		base <<= uint(min(attempt-1, 10))
+	}
+	return base
+}
+
+// failUpload re-queues the upload when the failure is transient and upload.max_retries
+// allows another attempt; otherwise it marks the job failed.
+func (r *Runner) failUpload(ctx context.Context, j *jobs.Job, cfg config.Config, msg string, tail []string) {
+	if cfg.Upload.MaxRetries > 0 && j.Attempts < cfg.Upload.MaxRetries && ctx.Err() == nil && transientUploadError(msg, tail) {
+		attempt := j.Attempts + 1
+		delay := uploadRetryDelay(cfg, attempt)
+		_ = r.jobs.AppendLog(ctx, j.ID, fmt.Sprintf("RETRY: transient upload error (%s); retry %d/%d in %s", msg, attempt, cfg.Upload.MaxRetries, delay))
+		if err := r.jobs.RetryLater(ctx, j.ID, delay, msg); err == nil {
+			return
+		}
+	}
+	if j.Attempts > 0 {
+		msg = fmt.Sprintf("%s (after %d retries)", msg, j.Attempts)
+	}
+	_ = r.jobs.AppendLog(ctx, j.ID, "ERROR: "+msg)
+	_ = r.jobs.SetFailed(ctx, j.ID, msg)
+}
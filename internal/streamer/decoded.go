@@ -0,0 +1,153 @@
+package streamer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// decodedFlushEvery is how many measured segments are written per transaction.
+const decodedFlushEvery = 256
+
+// decodedMaxWorkers caps the connections a measuring pass uses. It is background work
+// sharing the provider pool with playback, so it never takes more than two.
+const decodedMaxWorkers = 2
+
+// MeasureDecodedSizes records nzb_segments.decoded_bytes for every segment of importID
+// that lacks it (import.precompute_decoded_sizes). Cached segments are measured on
+// disk; the rest are downloaded and decoded once, without being cached, so a full pass
+// does not flush the cache. Segments gone from the server stay unknown (their file
+// keeps estimated offsets) and are counted in missing. onProgress, if set, gets the
+// number of segments handled so far and the total.
+func (s *Streamer) MeasureDecodedSizes(ctx context.Context, importID string, onProgress func(done, total int)) (measured, missing int, err error) {
+	db := s.jobs.DB().SQL
+	rows, err := db.QueryContext(ctx, `SELECT file_idx,number,message_id FROM nzb_segments WHERE import_id=? AND decoded_bytes=0 ORDER BY file_idx ASC, number ASC`, importID)
+	if err != nil {
+		return 0, 0, err
+	}
+	var todo []SegmentLocator
+	for rows.Next() {
+		seg := SegmentLocator{ImportID: importID}
+		if err := rows.Scan(&seg.FileIdx, &seg.Number, &seg.MessageID); err != nil {
+			_ = rows.Close()
+			return 0, 0, err
+		}
+		seg.MessageID = strings.TrimSpace(seg.MessageID)
+		todo = append(todo, seg)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+	if len(todo) == 0 {
+		return 0, 0, nil
+	}
+
+	type result struct {
+		seg  SegmentLocator
+		size int64
+		err  error
+	}
+	// Measured sizes are written even when the pass stops early: a rerun does the rest.
+	wctx := context.WithoutCancel(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	in := make(chan SegmentLocator)
+	out := make(chan result)
+	workers := min(max(s.conns, 1), decodedMaxWorkers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for seg := range in {
+				size, err := s.decodedSize(ctx, seg)
+				select {
+				case out <- result{seg: seg, size: size, err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(in)
+		for _, seg := range todo {
+			select {
+			case in <- seg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	pending := make([]result, 0, decodedFlushEvery)
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		tx, err := db.BeginTx(wctx, nil)
+		if err != nil {
+			return err
+		}
+		for _, r := range pending {
+			if _, err := tx.ExecContext(wctx, `UPDATE nzb_segments SET decoded_bytes=? WHERE import_id=? AND file_idx=? AND number=?`, r.size, importID, r.seg.FileIdx, r.seg.Number); err != nil {
+				_ = tx.Rollback()
+				return err
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		pending = pending[:0]
+		return nil
+	}
+
+	done := 0
+	for r := range out {
+		done++
+		switch {
+		case r.err == nil && r.size > 0:
+			measured++
+			pending = append(pending, r)
+		case r.err == nil || errors.Is(r.err, ErrSegmentMissing):
+			missing++
+		case err == nil:
+			err = r.err
+			cancel()
+		}
+		if len(pending) >= decodedFlushEvery {
+			if ferr := flush(); ferr != nil && err == nil {
+				err = ferr
+				cancel()
+			}
+		}
+		if onProgress != nil && err == nil {
+			onProgress(done, len(todo))
+		}
+	}
+	if ferr := flush(); ferr != nil && err == nil {
+		err = ferr
+	}
+	return measured, missing, err
+}
+
+// decodedSize is the decoded size of seg: from the cache when it is there, else
+// downloaded.
+func (s *Streamer) decodedSize(ctx context.Context, seg SegmentLocator) (int64, error) {
+	if p, ok := cachedSegment(s.segCachePath(seg.ImportID, seg.FileIdx, seg.Number, seg.MessageID)); ok {
+		if n, err := segmentSize(p); err == nil {
+			return n, nil
+		}
+	}
+	data, err := s.fetchSegmentRetry(ctx, seg)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(data)), nil
+}
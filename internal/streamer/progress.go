@@ -0,0 +1,17 @@
+package streamer
+
+import "context"
+
+type progressKey struct{}
+
+// WithProgress attaches fn to ctx; StreamRange/EnsureFile call it every time a
+// segment has been fetched or read from the cache.
+func WithProgress(ctx context.Context, fn func()) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+func progress(ctx context.Context) {
+	if fn, _ := ctx.Value(progressKey{}).(func()); fn != nil {
+		fn()
+	}
+}
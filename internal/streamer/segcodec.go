@@ -0,0 +1,118 @@
+package streamer
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Cached segments are stored either raw (<name>.bin) or zstd-compressed (<name>.bin.zst,
+// paths.compress_cache). Both variants are read, so toggling the option never
+// invalidates the cache; StreamRange always sees decoded sizes and bytes.
+const zstSuffix = ".zst"
+
+var (
+	zstdOnce sync.Once
+	zstdEnc  *zstd.Encoder
+	zstdDec  *zstd.Decoder
+)
+
+func zstdCodec() (*zstd.Encoder, *zstd.Decoder) {
+	zstdOnce.Do(func() {
+		zstdEnc, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1))
+		zstdDec, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+	})
+	return zstdEnc, zstdDec
+}
+
+// cachedSegment returns the existing cache file for base path p (raw or compressed).
+func cachedSegment(p string) (string, bool) {
+	for _, c := range []string{p, p + zstSuffix} {
+		if st, err := os.Stat(c); err == nil && st.Size() > 0 {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+// writeSegment stores decoded segment data under base path p and returns the file
+// written. The other variant is removed so a refetch never leaves a stale copy behind.
+func (s *Streamer) writeSegment(p string, data []byte) (string, error) {
+	out, stale := p, p+zstSuffix
+	if s.CompressCache {
+		enc, _ := zstdCodec()
+		data = enc.EncodeAll(data, make([]byte, 0, len(data)/2))
+		out, stale = stale, out
+	}
+	tmp := out + ".part"
+	_ = os.Remove(tmp)
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, out); err != nil {
+		return "", err
+	}
+	_ = os.Remove(stale)
+	return out, nil
+}
+
+func isCompressedSegment(p string) bool {
+	return len(p) > len(zstSuffix) && p[len(p)-len(zstSuffix):] == zstSuffix
+}
+
+// segmentSize returns the decoded size of a cached segment. Compressed segments carry
+// it in the zstd frame header, so only a few bytes are read.
+func segmentSize(p string) (int64, error) {
+	if !isCompressedSegment(p) {
+		st, err := os.Stat(p)
+		if err != nil {
+			return 0, err
+		}
+		return st.Size(), nil
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return 0, err
+	}
+	buf := make([]byte, zstd.HeaderMaxSize)
+	n, _ := io.ReadFull(f, buf)
+	_ = f.Close()
+	var h zstd.Header
+	if err := h.Decode(buf[:n]); err == nil && h.HasFCS {
+		return int64(h.FrameContentSize), nil
+	}
+	data, err := readSegment(p)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(data)), nil
+}
+
+// readSegment returns the decoded bytes of a compressed cached segment.
+func readSegment(p string) ([]byte, error) {
+	raw, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	_, dec := zstdCodec()
+	return dec.DecodeAll(raw, nil)
+}
+
+type nopSeekCloser struct{ *bytes.Reader }
+
+func (nopSeekCloser) Close() error { return nil }
+
+// openSegment opens a cached segment for reading decoded bytes.
+func openSegment(p string) (io.ReadSeekCloser, error) {
+	if !isCompressedSegment(p) {
+		return os.Open(p)
+	}
+	data, err := readSegment(p)
+	if err != nil {
+		return nil, err
+	}
+	return nopSeekCloser{bytes.NewReader(data)}, nil
+}
@@ -0,0 +1,55 @@
+package streamer
+
+import (
+	"io"
+	"math/rand/v2"
+	"path/filepath"
+	"testing"
+)
+
+// benchSegment is a yEnc-sized (750 KiB) payload: half random, half repeated text,
+// so zstd has something to do without the numbers depending on one extreme.
+func benchSegment() []byte {
+	data := make([]byte, 750*1024)
+	r := rand.New(rand.NewPCG(1, 2))
+	for i := range data[:len(data)/2] {
+		data[i] = byte(r.Uint32())
+	}
+	for i := len(data) / 2; i < len(data); i++ {
+		data[i] = "segment cache "[i%14]
+	}
+	return data
+}
+
+// BenchmarkReadCachedSegment measures reading one cached segment back the way
+// StreamRange does (size, open, read all), raw versus paths.compress_cache.
+func BenchmarkReadCachedSegment(b *testing.B) {
+	data := benchSegment()
+	for _, tc := range []struct {
+		name     string
+		compress bool
+	}{{"raw", false}, {"zstd", true}} {
+		b.Run(tc.name, func(b *testing.B) {
+			s := &Streamer{CompressCache: tc.compress}
+			p, err := s.writeSegment(filepath.Join(b.TempDir(), "seg.bin"), data)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.SetBytes(int64(len(data)))
+			for b.Loop() {
+				n, err := segmentSize(p)
+				if err != nil || n != int64(len(data)) {
+					b.Fatalf("segmentSize = %d, %v", n, err)
+				}
+				f, err := openSegment(p)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := io.Copy(io.Discard, f); err != nil {
+					b.Fatal(err)
+				}
+				_ = f.Close()
+			}
+		})
+	}
+}
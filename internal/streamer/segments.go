@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha1"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -15,6 +16,7 @@ import (
 	"time"
 
 	"github.com/gaby/EDRmount/internal/cache"
+	"github.com/gaby/EDRmount/internal/nntp"
 	"github.com/gaby/EDRmount/internal/yenc"
 )
 
@@ -32,34 +34,84 @@ type FileLayout struct {
 	Total    int64
 	Segs     []SegmentLocator // sorted by Number
 	Offsets  []int64          // starting byte offset for each seg (same index as Segs)
+	// Exact: every segment has a recorded decoded size (import.precompute_decoded_sizes),
+	// so Offsets are real file offsets rather than encoded-size estimates.
+	Exact bool
 }
 
 func buildLayout(segs []segRow, importID string, fileIdx int) (*FileLayout, error) {
 	sort.Slice(segs, func(i, j int) bool { return segs[i].Number < segs[j].Number })
-	layout := &FileLayout{ImportID: importID, FileIdx: fileIdx}
+	layout := &FileLayout{ImportID: importID, FileIdx: fileIdx, Exact: len(segs) > 0}
+	for _, s := range segs {
+		if s.Decoded <= 0 {
+			layout.Exact = false
+			break
+		}
+	}
 	layout.Segs = make([]SegmentLocator, 0, len(segs))
 	layout.Offsets = make([]int64, 0, len(segs))
 	var off int64 = 0
 	for _, s := range segs {
+		size := s.Bytes
+		if layout.Exact {
+			size = s.Decoded
+		}
 		layout.Offsets = append(layout.Offsets, off)
-		layout.Segs = append(layout.Segs, SegmentLocator{ImportID: importID, FileIdx: fileIdx, Number: s.Number, Bytes: s.Bytes, MessageID: s.MessageID})
-		off += s.Bytes
+		layout.Segs = append(layout.Segs, SegmentLocator{ImportID: importID, FileIdx: fileIdx, Number: s.Number, Bytes: size, MessageID: s.MessageID})
+		off += size
 	}
 	layout.Total = off
 	return layout, nil
 }
 
+// segShardSize is the number of segments per shard directory, so large files do not
+// end up with tens of thousands of entries in a single directory.
+const segShardSize = 256
+
+// segCachePath returns rawseg/<importID>/<fileIdx>/<shard>/<segNum>_<hash>.bin.
 func (s *Streamer) segCachePath(importID string, fileIdx int, segNum int, messageID string) string {
+	dir, name := s.segCacheDirName(importID, fileIdx, segNum, messageID)
+	return filepath.Join(dir, fmt.Sprintf("%03d", segNum/segShardSize), name)
+}
+
+// legacySegCachePath is the pre-sharding flat location of a segment.
+func (s *Streamer) legacySegCachePath(importID string, fileIdx int, segNum int, messageID string) string {
+	dir, name := s.segCacheDirName(importID, fileIdx, segNum, messageID)
+	return filepath.Join(dir, name)
+}
+
+func (s *Streamer) segCacheDirName(importID string, fileIdx int, segNum int, messageID string) (string, string) {
 	// include message-id hash to avoid collisions if same seg num changes across reimports
 	h := sha1.Sum([]byte(messageID))
 	name := fmt.Sprintf("%06d_%s.bin", segNum, hex.EncodeToString(h[:6]))
-	return filepath.Join(s.cacheDir, "rawseg", importID, fmt.Sprintf("%d", fileIdx), name)
+	return filepath.Join(s.cacheDir, "rawseg", importID, fmt.Sprintf("%d", fileIdx)), name
+}
+
+// adoptLegacySegment moves a segment cached in the old flat layout to its sharded
+// path, so existing caches are re-homed lazily instead of being downloaded again.
+func (s *Streamer) adoptLegacySegment(seg SegmentLocator, p string) bool {
+	old := s.legacySegCachePath(seg.ImportID, seg.FileIdx, seg.Number, seg.MessageID)
+	if st, err := os.Stat(old); err != nil || st.Size() == 0 {
+		return false
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return false
+	}
+	return os.Rename(old, p) == nil
 }
 
 func (s *Streamer) ensureSegment(ctx context.Context, seg SegmentLocator) (string, error) {
 	p := s.segCachePath(seg.ImportID, seg.FileIdx, seg.Number, seg.MessageID)
-	if st, err := os.Stat(p); err == nil && st.Size() > 0 {
-		return p, nil
+	tr := traceFrom(ctx)
+	if !tr.skipCache(p) {
+		if cp, ok := cachedSegment(p); ok {
+			tr.hit()
+			return cp, nil
+		}
+		if s.adoptLegacySegment(seg, p) {
+			tr.hit()
+			return p, nil
+		}
 	}
 	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
 		return "", err
@@ -72,50 +124,109 @@ func (s *Streamer) ensureSegment(ctx context.Context, seg SegmentLocator) (strin
 	defer l.Unlock()
 
 	// Re-check after lock (another goroutine may have completed it).
-	if st, err := os.Stat(p); err == nil && st.Size() > 0 {
-		return p, nil
+	if !tr.skipCache(p) {
+		if cp, ok := cachedSegment(p); ok {
+			tr.hit()
+			return cp, nil
+		}
 	}
 
 	// Download + decode (reuse NNTP connections)
 	if s.pool == nil {
 		return "", fmt.Errorf("nntp pool not initialized")
 	}
-	cl, err := s.pool.Acquire(ctx)
+	fetchStart := time.Now()
+	data, err := s.fetchSegmentRetry(ctx, seg)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("rawseg: import=%s fileIdx=%d seg=%d decoded=%d bytes", seg.ImportID, seg.FileIdx, seg.Number, len(data))
+
+	out, err := s.writeSegment(p, data)
 	if err != nil {
 		return "", err
 	}
+	tr.fetched(p, time.Since(fetchStart))
+	// Best-effort cache limit enforcement.
+	cache.EnforceSizeLimit(filepath.Join(s.cacheDir, "rawseg"), s.maxCache, s.Eviction)
+	return out, nil
+}
+
+// ErrSegmentMissing means the article is gone from the server (or arrived truncated);
+// retrying won't help.
+var ErrSegmentMissing = errors.New("segment missing on server")
+
+// ErrSegmentCorrupt is a segment that failed its yEnc CRC check (download.validate_crc),
+// usually a bad transfer: it is retried, and only reported as missing (both errors
+// match) once every attempt failed.
+var ErrSegmentCorrupt = errors.New("segment failed crc check")
+
+const segmentFetchAttempts = 3
+
+// fetchSegmentRetry is fetchSegment with up to segmentFetchAttempts tries; missing
+// articles and a provider that is down are not retried, corrupt ones are.
+func (s *Streamer) fetchSegmentRetry(ctx context.Context, seg SegmentLocator) ([]byte, error) {
+	var data []byte
+	var err error
+	for attempt := 1; attempt <= segmentFetchAttempts; attempt++ {
+		data, err = s.fetchSegment(ctx, seg)
+		if err == nil || errors.Is(err, ErrSegmentMissing) || errors.Is(err, nntp.ErrProviderDown) || ctx.Err() != nil {
+			break
+		}
+		log.Printf("rawseg: import=%s fileIdx=%d seg=%d attempt=%d err=%v", seg.ImportID, seg.FileIdx, seg.Number, attempt, err)
+		if attempt < segmentFetchAttempts {
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Duration(attempt) * 500 * time.Millisecond):
+			}
+		}
+	}
+	if errors.Is(err, ErrSegmentCorrupt) {
+		err = fmt.Errorf("%w: %w", ErrSegmentMissing, err)
+	}
+	return data, err
+}
+
+func (s *Streamer) fetchSegment(ctx context.Context, seg SegmentLocator) ([]byte, error) {
+	cl, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
 	defer s.pool.Release(cl)
 	log.Printf("rawseg: import=%s fileIdx=%d seg=%d fetching", seg.ImportID, seg.FileIdx, seg.Number)
 	lines, err := cl.BodyByMessageID(seg.MessageID)
 	if err != nil {
-		return "", err
+		if errors.Is(err, nntp.ErrArticleNotFound) {
+			return nil, fmt.Errorf("%w: %v", ErrSegmentMissing, err)
+		}
+		return nil, err
 	}
-	data, _, _, _, err := yenc.DecodePart(lines)
+	part, err := yenc.Decode(lines)
 	if err != nil {
-		return "", err
-	}
-	log.Printf("rawseg: import=%s fileIdx=%d seg=%d decoded=%d bytes", seg.ImportID, seg.FileIdx, seg.Number, len(data))
-
-	tmp := p + ".part"
-	_ = os.Remove(tmp)
-	if err := os.WriteFile(tmp, data, 0o644); err != nil {
-		return "", err
+		if errors.Is(err, yenc.ErrMissingYEnd) {
+			return nil, fmt.Errorf("%w: %v", ErrSegmentMissing, err)
+		}
+		return nil, err
 	}
-	if err := os.Rename(tmp, p); err != nil {
-		return "", err
+	if s.cfg.ValidateCRC {
+		if err := part.CheckCRC(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSegmentCorrupt, err)
+		}
 	}
-	// Best-effort cache limit enforcement.
-	cache.EnforceSizeLimit(filepath.Join(s.cacheDir, "rawseg"), s.maxCache)
-	return p, nil
+	return part.Data, nil
 }
 
 // StreamRange writes exactly [start,end] inclusive from the logical file.
+// With Download.TolerateMissingTail it returns io.EOF (after writing what it could)
+// when a needed segment is missing on the server.
 // El parámetro prefetch indica cuántos segmentos adicionales descargar anticipadamente.
 func (s *Streamer) StreamRange(ctx context.Context, importID string, fileIdx int, filename string, start, end int64, w io.Writer, prefetch int) error {
+	// Keeps the segments of importID from eviction (paths.cache_eviction=protect).
+	defer cache.Acquire(importID)()
 	// Load segments from DB
 	qctx, qcancel := context.WithTimeout(ctx, 5*time.Second)
 	defer qcancel()
-	rows, err := s.jobs.DB().SQL.QueryContext(qctx, `SELECT number,bytes,message_id FROM nzb_segments WHERE import_id=? AND file_idx=? ORDER BY number ASC`, importID, fileIdx)
+	rows, err := s.jobs.DB().SQL.QueryContext(qctx, `SELECT number,bytes,message_id,decoded_bytes FROM nzb_segments WHERE import_id=? AND file_idx=? ORDER BY number ASC`, importID, fileIdx)
 	if err != nil {
 		return err
 	}
@@ -123,7 +234,7 @@ func (s *Streamer) StreamRange(ctx context.Context, importID string, fileIdx int
 	segs := make([]segRow, 0)
 	for rows.Next() {
 		var r segRow
-		if err := rows.Scan(&r.Number, &r.Bytes, &r.MessageID); err != nil {
+		if err := rows.Scan(&r.Number, &r.Bytes, &r.MessageID, &r.Decoded); err != nil {
 			continue
 		}
 		r.MessageID = strings.TrimSpace(r.MessageID)
@@ -133,6 +244,7 @@ func (s *Streamer) StreamRange(ctx context.Context, importID string, fileIdx int
 		return fmt.Errorf("no segments")
 	}
 	layout, _ := buildLayout(segs, importID, fileIdx)
+	tr := traceFrom(ctx)
 	if start < 0 {
 		start = 0
 	}
@@ -142,7 +254,8 @@ func (s *Streamer) StreamRange(ctx context.Context, importID string, fileIdx int
 
 	// IMPORTANT: NZB segment bytes are often ENCODED sizes and may not match decoded payload sizes.
 	// We use encoded offsets only as a fast index hint (start near requested range),
-	// then stream using real decoded segment sizes from cache/files.
+	// then stream using real decoded segment sizes from cache/files. An Exact layout
+	// (decoded sizes recorded at import) starts at the right segment directly.
 	writtenAny := false
 
 	startIdx := sort.Search(len(layout.Segs), func(i int) bool {
@@ -152,10 +265,12 @@ func (s *Streamer) StreamRange(ctx context.Context, importID string, fileIdx int
 		startIdx = 0
 	}
 	// Small backtrack window to absorb encoded-vs-decoded drift.
-	if startIdx > 2 {
-		startIdx -= 2
-	} else {
-		startIdx = 0
+	if !layout.Exact {
+		if startIdx > 2 {
+			startIdx -= 2
+		} else {
+			startIdx = 0
+		}
 	}
 	off := int64(0)
 	if startIdx < len(layout.Offsets) {
@@ -166,7 +281,8 @@ func (s *Streamer) StreamRange(ctx context.Context, importID string, fileIdx int
 		seg := layout.Segs[i]
 
 		// Prefetch best-effort: do not block on errors/results.
-		if prefetch > 0 && i+1 < len(layout.Segs) {
+		// Skipped for NoCache traces: prefetched copies would be refetched anyway.
+		if prefetch > 0 && i+1 < len(layout.Segs) && (tr == nil || !tr.NoCache) {
 			for j := 1; j <= prefetch && i+j < len(layout.Segs); j++ {
 				nextSeg := layout.Segs[i+j]
 				go func(ns SegmentLocator) {
@@ -179,13 +295,20 @@ func (s *Streamer) StreamRange(ctx context.Context, importID string, fileIdx int
 
 		p, err := s.ensureSegment(ctx, seg)
 		if err != nil {
+			// A segment gone from the server ends the stream like EOF (after what was already
+			// written) instead of failing the whole read.
+			if s.cfg.TolerateMissingTail && errors.Is(err, ErrSegmentMissing) {
+				log.Printf("rawseg: import=%s fileIdx=%d seg=%d missing; treating as EOF", importID, fileIdx, seg.Number)
+				return io.EOF
+			}
 			return err
 		}
-		st, err := os.Stat(p)
+		progress(ctx)
+		// Decoded size, also for compressed segments: offsets are in file bytes.
+		segSize, err := segmentSize(p)
 		if err != nil {
 			return err
 		}
-		segSize := st.Size()
 		if segSize <= 0 {
 			continue
 		}
@@ -200,7 +323,7 @@ func (s *Streamer) StreamRange(ctx context.Context, importID string, fileIdx int
 			break
 		}
 
-		f, err := os.Open(p)
+		f, err := openSegment(p)
 		if err != nil {
 			return err
 		}
@@ -2,6 +2,7 @@ package streamer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -11,10 +12,13 @@ import (
 	"sync"
 	"time"
 
+	"github.com/gaby/EDRmount/internal/cache"
 	"github.com/gaby/EDRmount/internal/config"
 	"github.com/gaby/EDRmount/internal/jobs"
 	"github.com/gaby/EDRmount/internal/nntp"
 	"github.com/gaby/EDRmount/internal/yenc"
+
+	"golang.org/x/sync/singleflight"
 )
 
 type Streamer struct {
@@ -23,7 +27,13 @@ type Streamer struct {
 	cacheDir string
 	pool     *nntp.Pool
 	maxCache int64
+	conns    int      // pool size
 	segLocks sync.Map // cachePath -> *sync.Mutex
+
+	// CompressCache stores newly fetched segments zstd-compressed (paths.compress_cache).
+	CompressCache bool
+	// Eviction is paths.cache_eviction / paths.cache_pinned_imports for the size limit.
+	Eviction cache.Eviction
 }
 
 func New(cfg config.DownloadProvider, j *jobs.Store, cacheDir string, maxCacheBytes int64) *Streamer {
@@ -35,26 +45,106 @@ func New(cfg config.DownloadProvider, j *jobs.Store, cacheDir string, maxCacheBy
 	if poolSize > 64 {
 		poolSize = 64
 	}
-	p := nntp.NewPool(nntp.Config{Host: cfg.Host, Port: cfg.Port, SSL: cfg.SSL, User: cfg.User, Pass: cfg.Pass, Timeout: 15 * time.Second}, poolSize)
-	return &Streamer{cfg: cfg, jobs: j, cacheDir: cacheDir, pool: p, maxCache: maxCacheBytes}
+	return &Streamer{cfg: cfg, jobs: j, cacheDir: cacheDir, pool: sharedPool(cfg, poolSize), maxCache: maxCacheBytes, conns: poolSize}
+}
+
+// Streamers are created per request/mount; they share one pool per provider settings
+// so idle connections are reused instead of piling up against the global limiter.
+// Pools of old settings stay registered, their idle connections are reclaimed on demand.
+var (
+	poolsMu sync.Mutex
+	pools   = map[string]*nntp.Pool{}
+)
+
+func sharedPool(cfg config.DownloadProvider, size int) *nntp.Pool {
+	key := fmt.Sprintf("%s|%d|%v|%s|%s|%d|%s|%s|%d|%s", cfg.Host, cfg.Port, cfg.SSL, cfg.User, cfg.Pass, size, cfg.DialTimeout(), cfg.IOTimeout(), cfg.BreakerThreshold(), cfg.BreakerCooldown())
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
+	if p, ok := pools[key]; ok {
+		return p
+	}
+	p := nntp.NewPool(cfg.NNTPConfig(), size)
+	pools[key] = p
+	return p
 }
 
 type segRow struct {
 	Number    int
 	Bytes     int64
 	MessageID string
+	Decoded   int64 // nzb_segments.decoded_bytes, 0 = unknown
+}
+
+// rawCachePath returns /cache/raw/<importID>/<filename>. Both parts come from request
+// parameters, so only their last element is used and "." / ".." are refused: the
+// result can never point outside the import's cache folder.
+func (s *Streamer) rawCachePath(importID, filename string) (string, error) {
+	id, name := filepath.Base(importID), filepath.Base(filename)
+	for _, part := range []string{id, name} {
+		if part == "." || part == ".." || part == string(filepath.Separator) {
+			return "", fmt.Errorf("invalid cache name %q", filepath.Join(importID, filename))
+		}
+	}
+	return filepath.Join(s.cacheDir, "raw", id, name), nil
+}
+
+// CachedFile returns the /cache/raw copy written by EnsureFile when it is complete
+// (its size matches the expected file size).
+func (s *Streamer) CachedFile(importID, filename string, size int64) (string, bool) {
+	p, err := s.rawCachePath(importID, filename)
+	if err != nil {
+		return "", false
+	}
+	st, err := os.Stat(p)
+	if err != nil || !st.Mode().IsRegular() || st.Size() != size {
+		return "", false
+	}
+	return p, true
 }
 
+// ensureFileGroup coalesces concurrent full-file downloads of the same cache path
+// (Streamers are created per request, so this is package level).
+var ensureFileGroup singleflight.Group
+
+// EnsureFile downloads the whole file into /cache/raw/<importID>/<filename> (or returns
+// the cached copy). Concurrent calls for the same file share a single download.
 func (s *Streamer) EnsureFile(ctx context.Context, importID string, fileIdx int, filename string) (string, error) {
-	log.Printf("raw: ensure start import=%s fileIdx=%d filename=%s", importID, fileIdx, filename)
-	// cache path
-	base := filepath.Join(s.cacheDir, "raw", importID)
-	if err := os.MkdirAll(base, 0o755); err != nil {
+	key, err := s.rawCachePath(importID, filename)
+	if err != nil {
 		return "", err
 	}
-	outPath := filepath.Join(base, filename)
-	if st, err := os.Stat(outPath); err == nil && st.Size() > 0 {
-		return outPath, nil
+	defer cache.Acquire(importID)()
+	for attempt := 0; ; attempt++ {
+		ch := ensureFileGroup.DoChan(key, func() (any, error) {
+			return s.ensureFile(ctx, importID, fileIdx, key)
+		})
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case res := <-ch:
+			if res.Err != nil {
+				// The shared download belonged to a caller that went away; try on our own.
+				if res.Shared && attempt == 0 && ctx.Err() == nil && errors.Is(res.Err, context.Canceled) {
+					continue
+				}
+				return "", res.Err
+			}
+			return res.Val.(string), nil
+		}
+	}
+}
+
+func (s *Streamer) ensureFile(ctx context.Context, importID string, fileIdx int, outPath string) (string, error) {
+	log.Printf("raw: ensure start import=%s fileIdx=%d path=%s", importID, fileIdx, outPath)
+	if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+		return "", err
+	}
+	tr := traceFrom(ctx)
+	if tr == nil || !tr.NoCache {
+		if st, err := os.Stat(outPath); err == nil && st.Size() > 0 {
+			tr.hit()
+			return outPath, nil
+		}
 	}
 
 	if !s.cfg.Enabled {
@@ -87,7 +177,7 @@ func (s *Streamer) EnsureFile(ctx context.Context, importID string, fileIdx int,
 	sort.Slice(segs, func(i, j int) bool { return segs[i].Number < segs[j].Number })
 
 	log.Printf("raw: dialing nntp host=%s port=%d ssl=%v", s.cfg.Host, s.cfg.Port, s.cfg.SSL)
-	cl, err := nntp.Dial(ctx, nntp.Config{Host: s.cfg.Host, Port: s.cfg.Port, SSL: s.cfg.SSL, User: s.cfg.User, Pass: s.cfg.Pass, Timeout: 15 * time.Second})
+	cl, err := nntp.Dial(ctx, s.cfg.NNTPConfig())
 	if err != nil {
 		log.Printf("raw: dial error: %v", err)
 		return "", err
@@ -111,18 +201,27 @@ func (s *Streamer) EnsureFile(ctx context.Context, importID string, fileIdx int,
 
 	for _, seg := range segs {
 		log.Printf("raw: import=%s fileIdx=%d seg=%d fetching", importID, fileIdx, seg.Number)
+		fetchStart := time.Now()
 		lines, err := cl.BodyByMessageID(seg.MessageID)
 		if err != nil {
 			return "", err
 		}
-		data, _, _, _, err := yenc.DecodePart(lines)
+		tr.fetched("", time.Since(fetchStart))
+		part, err := yenc.Decode(lines)
+		data := part.Data
 		log.Printf("raw: import=%s fileIdx=%d seg=%d decoded=%d bytes", importID, fileIdx, seg.Number, len(data))
 		if err != nil {
 			return "", err
 		}
+		if s.cfg.ValidateCRC {
+			if err := part.CheckCRC(); err != nil {
+				return "", fmt.Errorf("seg %d: %w", seg.Number, err)
+			}
+		}
 		if _, err := f.Write(data); err != nil {
 			return "", err
 		}
+		progress(ctx)
 	}
 	if err := f.Close(); err != nil {
 		return "", err
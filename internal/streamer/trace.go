@@ -0,0 +1,65 @@
+package streamer
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Trace collects per-request fetch diagnostics. With NoCache set, cached segments
+// are ignored and re-downloaded (the fresh result is still written to the cache);
+// each segment is refetched at most once per Trace so a preflight probe followed
+// by the real stream does not download it twice.
+type Trace struct {
+	NoCache bool
+
+	Fetched    atomic.Int64
+	CacheHits  atomic.Int64
+	FetchNanos atomic.Int64
+
+	refreshed sync.Map // cachePath -> struct{}
+}
+
+type traceKey struct{}
+
+// WithTrace attaches t to ctx for StreamRange/EnsureFile.
+func WithTrace(ctx context.Context, t *Trace) context.Context {
+	return context.WithValue(ctx, traceKey{}, t)
+}
+
+func traceFrom(ctx context.Context) *Trace {
+	t, _ := ctx.Value(traceKey{}).(*Trace)
+	return t
+}
+
+// FetchTime is the summed time spent downloading segments.
+func (t *Trace) FetchTime() time.Duration {
+	return time.Duration(t.FetchNanos.Load())
+}
+
+// skipCache reports whether a cached copy at p must be ignored.
+func (t *Trace) skipCache(p string) bool {
+	if t == nil || !t.NoCache {
+		return false
+	}
+	_, done := t.refreshed.Load(p)
+	return !done
+}
+
+func (t *Trace) hit() {
+	if t != nil {
+		t.CacheHits.Add(1)
+	}
+}
+
+func (t *Trace) fetched(p string, d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.Fetched.Add(1)
+	t.FetchNanos.Add(int64(d))
+	if p != "" {
+		t.refreshed.Store(p, struct{}{})
+	}
+}
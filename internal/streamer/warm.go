@@ -0,0 +1,59 @@
+package streamer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/gaby/EDRmount/internal/cache"
+	"github.com/gaby/EDRmount/internal/config"
+	"github.com/gaby/EDRmount/internal/jobs"
+)
+
+// WarmRecent pre-fetches the head of the most recently played files into the segment
+// cache (download.warm_on_start), one file at a time so a restart doesn't stampede
+// the provider.
+func WarmRecent(ctx context.Context, cfg config.Config, j *jobs.Store) {
+	dl := cfg.Download
+	if !dl.WarmOnStart || !dl.Enabled || j == nil {
+		return
+	}
+	files, err := j.RecentPlayedFiles(ctx, dl.WarmCountOrDefault())
+	if err != nil {
+		log.Printf("cache warm: %v", err)
+		return
+	}
+	if len(files) == 0 {
+		return
+	}
+	st := New(dl, j, cfg.Paths.CacheDir, cfg.Paths.CacheMaxBytes)
+	st.CompressCache = cfg.Paths.CompressCache
+	st.Eviction = cache.NewEviction(cfg.Paths.CacheEviction, cfg.Paths.CachePinnedImports)
+	head := dl.WarmBytes()
+	start := time.Now()
+	warmed := 0
+	for _, f := range files {
+		if ctx.Err() != nil {
+			return
+		}
+		end := head - 1
+		if f.Size > 0 && end >= f.Size {
+			end = f.Size - 1
+		}
+		name := f.Filename
+		if name == "" {
+			name = fmt.Sprintf("file_%04d.bin", f.FileIdx)
+		}
+		fctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+		err := st.StreamRange(fctx, f.ImportID, f.FileIdx, name, 0, end, io.Discard, 0)
+		cancel()
+		if err != nil {
+			log.Printf("cache warm: import=%s fileIdx=%d: %v", f.ImportID, f.FileIdx, err)
+			continue
+		}
+		warmed++
+	}
+	log.Printf("cache warm: %d/%d file(s) in %s", warmed, len(files), time.Since(start).Round(time.Millisecond))
+}
@@ -1,6 +1,7 @@
 package subject
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 )
@@ -19,3 +20,63 @@ func FilenameFromSubject(subj string) (string, bool) {
 	}
 	return "", false
 }
+
+// Extractor tries user-configured regexes in order before falling back to
+// FilenameFromSubject. A nil *Extractor only uses the fallback.
+type Extractor struct {
+	res []pattern
+}
+
+// pattern is a compiled subject pattern with its index in the configured list (blank
+// entries are skipped, so the two can differ).
+type pattern struct {
+	idx int
+	re  *regexp.Regexp
+}
+
+// NewExtractor compiles patterns in order. Each pattern should capture the filename
+// in a group named "name" or, failing that, in its first capture group; without groups
+// the whole match is used.
+func NewExtractor(patterns []string) (*Extractor, error) {
+	e := &Extractor{}
+	for i, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %d: %w", i, err)
+		}
+		e.res = append(e.res, pattern{idx: i, re: re})
+	}
+	return e, nil
+}
+
+// Filename returns the extracted name and the index of the matching pattern in the
+// list given to NewExtractor (-1 when the built-in heuristic was used).
+func (e *Extractor) Filename(subj string) (string, int, bool) {
+	if e != nil {
+		for _, p := range e.res {
+			if name := matchName(p.re, subj); name != "" {
+				return name, p.idx, true
+			}
+		}
+	}
+	name, ok := FilenameFromSubject(subj)
+	return name, -1, ok
+}
+
+func matchName(re *regexp.Regexp, subj string) string {
+	m := re.FindStringSubmatch(subj)
+	if m == nil {
+		return ""
+	}
+	if i := re.SubexpIndex("name"); i > 0 {
+		return strings.TrimSpace(m[i])
+	}
+	if len(m) > 1 {
+		return strings.TrimSpace(m[1])
+	}
+	return strings.TrimSpace(m[0])
+}
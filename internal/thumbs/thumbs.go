@@ -0,0 +1,121 @@
+// Package thumbs extracts preview frames of imported videos (thumbnails.enabled).
+package thumbs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gaby/EDRmount/internal/config"
+	"github.com/gaby/EDRmount/internal/streamer"
+)
+
+// videoExts are the files a frame is extracted from.
+var videoExts = map[string]bool{".mkv": true, ".mp4": true, ".m4v": true, ".avi": true}
+
+// IsVideo reports whether filename gets a thumbnail.
+func IsVideo(filename string) bool {
+	return videoExts[strings.ToLower(filepath.Ext(filename))]
+}
+
+// Dir is where the thumbnails of an import are cached.
+func Dir(cacheDir, importID string) string {
+	return filepath.Join(cacheDir, "thumbs", importID)
+}
+
+// Path is the cached JPEG of file idx of an import.
+func Path(cacheDir, importID string, idx int) string {
+	return filepath.Join(Dir(cacheDir, importID), strconv.Itoa(idx)+".jpg")
+}
+
+// ValidImportID reports whether importID is safe as a directory name (ids come from
+// request paths).
+func ValidImportID(importID string) bool {
+	return importID != "" && importID != "." && importID != ".." && !strings.ContainsAny(importID, `/\`)
+}
+
+// Remove drops the cached thumbnails of an import.
+func Remove(cacheDir, importID string) error {
+	if cacheDir == "" || !ValidImportID(importID) {
+		return nil
+	}
+	return os.RemoveAll(Dir(cacheDir, importID))
+}
+
+// Generate streams the head of a file (at most thumbnails.max_head_mb) into ffmpeg and
+// writes one frame at thumbnails.seek_seconds to Path. When the head ends before the
+// seek offset (short file, low bitrate cut) the first frame is used instead.
+func Generate(ctx context.Context, cfg config.Config, st *streamer.Streamer, importID string, idx int, filename string, size int64) error {
+	t := cfg.Thumbnails.Defaults()
+	head := int64(t.MaxHeadMB) << 20
+	if size > 0 && size < head {
+		head = size
+	}
+	out := Path(cfg.Paths.CacheDir, importID, idx)
+	if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+		return err
+	}
+	tmp := out + ".tmp.jpg"
+	defer os.Remove(tmp)
+
+	err := extract(ctx, cfg, t, st, importID, idx, filename, head, t.SeekSeconds, tmp)
+	if err != nil && ctx.Err() == nil && t.SeekSeconds > 0 {
+		err = extract(ctx, cfg, t, st, importID, idx, filename, head, 0, tmp)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, out)
+}
+
+func extract(ctx context.Context, cfg config.Config, t config.Thumbnails, st *streamer.Streamer, importID string, idx int, filename string, head int64, seek int, out string) error {
+	_ = os.Remove(out)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	args := []string{"-hide_banner", "-loglevel", "error", "-y"}
+	if seek > 0 {
+		args = append(args, "-ss", strconv.Itoa(seek))
+	}
+	args = append(args, "-i", "pipe:0", "-frames:v", "1", "-vf", fmt.Sprintf("scale=%d:-2", t.Width), "-f", "image2", out)
+	cmd := exec.CommandContext(ctx, cfg.ThumbnailFFmpeg(), args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// ffmpeg closes stdin once it has its frame; the broken pipe ends the stream early.
+	streamErr := make(chan error, 1)
+	go func() {
+		err := st.StreamRange(ctx, importID, idx, filename, 0, head-1, stdin, 2)
+		_ = stdin.Close()
+		streamErr <- err
+	}()
+	waitErr := cmd.Wait()
+	cancel()
+	serr := <-streamErr
+
+	if fi, err := os.Stat(out); err == nil && fi.Size() > 0 {
+		return nil
+	}
+	if waitErr != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("ffmpeg: %s", msg)
+		}
+		return fmt.Errorf("ffmpeg: %w", waitErr)
+	}
+	if serr != nil && !errors.Is(serr, context.Canceled) {
+		return serr
+	}
+	return fmt.Errorf("ffmpeg produced no frame at %ds", seek)
+}
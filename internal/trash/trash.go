@@ -0,0 +1,170 @@
+package trash
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Root is where delete_full moves NZBs and PAR2 files. Layout:
+//
+//	<Root>/<kind>/<stamp>/<rel>
+//
+// kind is "nzb" or "par2", stamp the time of the delete (StampLayout) and rel the
+// file's path relative to its original root (the NZB watch dir or upload.par.dir).
+const Root = "/host/inbox/.trash"
+
+// StampLayout formats the per-delete folder name.
+const StampLayout = "20060102-150405"
+
+// Kinds are the top-level trash folders.
+var Kinds = []string{"nzb", "par2"}
+
+// Entry is one trashed file.
+type Entry struct {
+	Kind      string    `json:"kind"`
+	Stamp     string    `json:"stamp"`
+	Rel       string    `json:"rel"`
+	Path      string    `json:"path"` // relative to Root: <kind>/<stamp>/<rel>
+	Size      int64     `json:"size"`
+	TrashedAt time.Time `json:"trashed_at"`
+}
+
+// stampTime parses a stamp folder name, falling back to the folder mtime for
+// folders not created by delete_full.
+func stampTime(dir, stamp string) time.Time {
+	if t, err := time.ParseInLocation(StampLayout, stamp, time.Local); err == nil {
+		return t
+	}
+	if st, err := os.Stat(dir); err == nil {
+		return st.ModTime()
+	}
+	return time.Time{}
+}
+
+// List returns every trashed file under root, newest first.
+func List(root string) ([]Entry, error) {
+	out := make([]Entry, 0)
+	for _, kind := range Kinds {
+		stamps, err := os.ReadDir(filepath.Join(root, kind))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, sd := range stamps {
+			if !sd.IsDir() {
+				continue
+			}
+			dir := filepath.Join(root, kind, sd.Name())
+			at := stampTime(dir, sd.Name())
+			_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+				if err != nil || d.IsDir() {
+					return nil
+				}
+				rel, err := filepath.Rel(dir, p)
+				if err != nil {
+					return nil
+				}
+				var size int64
+				if info, err := d.Info(); err == nil {
+					size = info.Size()
+				}
+				out = append(out, Entry{
+					Kind:      kind,
+					Stamp:     sd.Name(),
+					Rel:       rel,
+					Path:      filepath.Join(kind, sd.Name(), rel),
+					Size:      size,
+					TrashedAt: at,
+				})
+				return nil
+			})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].TrashedAt.Equal(out[j].TrashedAt) {
+			return out[i].TrashedAt.After(out[j].TrashedAt)
+		}
+		return out[i].Path < out[j].Path
+	})
+	return out, nil
+}
+
+// Purge removes the stamp folders trashed before cutoff (a zero cutoff removes
+// everything) and returns the number of files and bytes freed.
+func Purge(root string, cutoff time.Time) (files int, bytes int64, err error) {
+	for _, kind := range Kinds {
+		stamps, e := os.ReadDir(filepath.Join(root, kind))
+		if errors.Is(e, os.ErrNotExist) {
+			continue
+		}
+		if e != nil {
+			return files, bytes, e
+		}
+		for _, sd := range stamps {
+			if !sd.IsDir() {
+				continue
+			}
+			dir := filepath.Join(root, kind, sd.Name())
+			if !cutoff.IsZero() && !stampTime(dir, sd.Name()).Before(cutoff) {
+				continue
+			}
+			_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+				if err == nil && !d.IsDir() {
+					files++
+					if info, err := d.Info(); err == nil {
+						bytes += info.Size()
+					}
+				}
+				return nil
+			})
+			if e := os.RemoveAll(dir); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	return files, bytes, err
+}
+
+// Resolve validates a <kind>/<stamp>/<rel> path (as returned in Entry.Path) and
+// returns its parts plus the absolute trash file path.
+func Resolve(root, p string) (kind, stamp, rel, abs string, err error) {
+	p = filepath.Clean(strings.TrimPrefix(strings.TrimSpace(p), string(filepath.Separator)))
+	parts := strings.SplitN(p, string(filepath.Separator), 3)
+	if len(parts) != 3 || parts[2] == "" || parts[2] == "." {
+		return "", "", "", "", errors.New("path must be <kind>/<stamp>/<file>")
+	}
+	kind, stamp, rel = parts[0], parts[1], parts[2]
+	if kind != "nzb" && kind != "par2" {
+		return "", "", "", "", errors.New("kind must be nzb|par2")
+	}
+	if stamp == ".." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", "", "", "", errors.New("invalid path")
+	}
+	abs = filepath.Join(root, kind, stamp, rel)
+	st, err := os.Stat(abs)
+	if err != nil {
+		return "", "", "", "", err
+	}
+	if st.IsDir() {
+		return "", "", "", "", errors.New("not a file")
+	}
+	return kind, stamp, rel, abs, nil
+}
+
+// RemoveEmpty drops empty folders left under dir up to (not including) stop.
+func RemoveEmpty(dir, stop string) {
+	dir, stop = filepath.Clean(dir), filepath.Clean(stop)
+	for dir != stop && strings.HasPrefix(dir, stop+string(filepath.Separator)) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
@@ -1,10 +1,13 @@
 package watch
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"fmt"
+	"io"
 	"io/fs"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -19,6 +22,10 @@ type Watcher struct {
 	NZB   config.WatchKind
 	Media config.WatchKind
 
+	// NZBExtraDirs are scanned with the NZB settings too (upload.movies_output_dir /
+	// series_output_dir when they live outside NZB.Dir).
+	NZBExtraDirs []string
+
 	Interval time.Duration
 }
 
@@ -47,6 +54,7 @@ func (w *Watcher) scanOnce(ctx context.Context) error {
 	if w.jobs == nil {
 		return nil
 	}
+	_ = w.jobs.PruneSuppressed(ctx)
 	if w.NZB.Enabled {
 		if err := w.scanNZB(ctx); err != nil {
 			_ = w.jobs.AppendLog(ctx, "watch", fmt.Sprintf("watch scanNZB error: %v", err))
@@ -60,12 +68,44 @@ func (w *Watcher) scanOnce(ctx context.Context) error {
 	return nil
 }
 
+// nzbRoots returns NZB.Dir plus the extra dirs, dropping any nested inside another.
+func (w *Watcher) nzbRoots() []string {
+	var roots []string
+	for _, d := range append([]string{w.NZB.Dir}, w.NZBExtraDirs...) {
+		if strings.TrimSpace(d) == "" {
+			continue
+		}
+		roots = append(roots, filepath.Clean(d))
+	}
+	out := make([]string, 0, len(roots))
+	for i, r := range roots {
+		nested := false
+		for j, o := range roots {
+			if i == j {
+				continue
+			}
+			if strings.HasPrefix(r, o+string(filepath.Separator)) || (r == o && j < i) {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
 func (w *Watcher) scanNZB(ctx context.Context) error {
-	root := w.NZB.Dir
-	if root == "" {
-		return nil
+	for _, root := range w.nzbRoots() {
+		if err := w.scanNZBRoot(ctx, root); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
+func (w *Watcher) scanNZBRoot(ctx context.Context, root string) error {
 	walkFn := func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return nil
@@ -80,13 +120,25 @@ func (w *Watcher) scanNZB(ctx context.Context) error {
 			return nil
 		}
 		name := d.Name()
-		if !strings.HasSuffix(strings.ToLower(name), ".nzb") {
+		if !strings.HasSuffix(strings.ToLower(name), ".nzb") || IgnoredName(name, w.NZB.IgnorePatterns) {
 			return nil
 		}
 		info, err := d.Info()
 		if err != nil {
 			return nil
 		}
+		// Optional stability window: skip NZBs still being written; a later scan picks them up.
+		if w.NZB.StableSeconds > 0 && time.Since(info.ModTime()) < time.Duration(w.NZB.StableSeconds)*time.Second {
+			return nil
+		}
+		// Written in place and not finished yet: leave it unseen so a later scan retries.
+		if w.NZB.RequireComplete && !nzbComplete(path, info.Size()) {
+			return nil
+		}
+		// Recently deleted (delete_full): don't resurrect it from a leftover copy.
+		if sup, _ := w.jobs.Suppressed(ctx, path, "nzb"); sup {
+			return nil
+		}
 		if ok, _ := w.markSeen(ctx, path, "nzb", info); ok {
 			_, _ = w.jobs.Enqueue(ctx, jobs.TypeImport, map[string]string{"path": path})
 		}
@@ -102,8 +154,15 @@ func (w *Watcher) scanMedia(ctx context.Context) error {
 		return nil
 	}
 	// Avoid processing incomplete files while they are being copied into the inbox.
-	// Require the file to be unchanged for this duration before enqueueing.
+	// Require the file (or season folder) to be unchanged for this duration before enqueueing.
 	stableFor := 60 * time.Second
+	if w.Media.StableSeconds > 0 {
+		stableFor = time.Duration(w.Media.StableSeconds) * time.Second
+	}
+	folderStableFor := 60 * time.Second
+	if w.Media.FolderStableSeconds > 0 {
+		folderStableFor = time.Duration(w.Media.FolderStableSeconds) * time.Second
+	}
 
 	isVideo := func(name string) bool {
 		low := strings.ToLower(name)
@@ -155,7 +214,10 @@ func (w *Watcher) scanMedia(ctx context.Context) error {
 					if e != nil {
 						return nil
 					}
-					if ok, _ := w.markStable(ctx, path, "media_pack_pending", "media_pack", info, stableFor); ok {
+					if sup, _ := w.jobs.Suppressed(ctx, path, "media"); sup {
+						return fs.SkipDir
+					}
+					if ok, _ := w.markStable(ctx, path, "media_pack_pending", "media_pack", info, folderStableFor); ok {
 						_, _ = w.jobs.Enqueue(ctx, jobs.TypeUpload, map[string]string{"path": path})
 					}
 					return fs.SkipDir
@@ -172,6 +234,9 @@ func (w *Watcher) scanMedia(ctx context.Context) error {
 		if err != nil {
 			return nil
 		}
+		if sup, _ := w.jobs.Suppressed(ctx, path, "media"); sup {
+			return nil
+		}
 		if ok, _ := w.markStable(ctx, path, "media_pending", "media", info, stableFor); ok {
 			_, _ = w.jobs.Enqueue(ctx, jobs.TypeUpload, map[string]string{"path": path})
 		}
@@ -254,3 +319,36 @@ func (w *Watcher) markStable(ctx context.Context, path, pendingKind, readyKind s
 	_, err = d.ExecContext(ctx, `UPDATE ingest_seen SET kind=?, size=?, mtime=?, seen_at=? WHERE path=?`, pendingKind, size, mtime, now, path)
 	return false, err
 }
+
+// IgnoredName reports whether name matches one of the glob patterns, ignoring case.
+// The NZB watcher (watch.nzb.ignore_patterns) and the host browser
+// (hostfs.hidden_patterns) share it.
+func IgnoredName(name string, patterns []string) bool {
+	low := strings.ToLower(name)
+	for _, pat := range patterns {
+		if ok, _ := filepath.Match(strings.ToLower(pat), low); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// nzbComplete reports whether the NZB ends with its closing </nzb> tag, i.e. it is not
+// a partial write.
+func nzbComplete(path string, size int64) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	const tail = 512
+	off := size - tail
+	if off < 0 {
+		off = 0
+	}
+	buf := make([]byte, size-off)
+	if _, err := f.ReadAt(buf, off); err != nil && err != io.EOF {
+		return false
+	}
+	return bytes.Contains(bytes.ToLower(buf), []byte("</nzb>"))
+}
@@ -2,24 +2,64 @@ package yenc
 
 import (
 	"errors"
+	"fmt"
+	"hash/crc32"
 	"strconv"
 	"strings"
 )
 
+// ErrMissingYEnd is returned when a part has no =yend trailer (truncated article).
+var ErrMissingYEnd = errors.New("invalid yenc: missing yend")
+
+// ErrCRCMismatch is returned by Part.CheckCRC when the decoded bytes don't match the
+// trailer checksum (corrupted article).
+var ErrCRCMismatch = errors.New("yenc crc32 mismatch")
+
+// Part is one decoded yEnc article.
+type Part struct {
+	Data  []byte
+	Begin int // 1-based inclusive, 0 when the post is single-part (no =ypart)
+	End   int
+	Name  string
+
+	// CRC32 is the trailer checksum of Data: pcrc32 for multipart posts, crc32 for
+	// single-part ones. HasCRC is false when the poster didn't include one.
+	CRC32  uint32
+	HasCRC bool
+}
+
+// CheckCRC validates Data against the trailer checksum. Parts without one pass.
+func (p Part) CheckCRC() error {
+	if !p.HasCRC {
+		return nil
+	}
+	if got := crc32.ChecksumIEEE(p.Data); got != p.CRC32 {
+		return fmt.Errorf("%w: got %08x want %08x", ErrCRCMismatch, got, p.CRC32)
+	}
+	return nil
+}
+
 // DecodePart decodes yEnc payload lines into bytes.
 // It expects to see =ybegin and =yend, optionally =ypart.
 // Returns decoded bytes and the declared (begin,end) if present; begin/end are 1-based inclusive.
 func DecodePart(lines []string) (data []byte, begin int, end int, name string, err error) {
-	begin = 0
-	end = 0
+	p, err := Decode(lines)
+	return p.Data, p.Begin, p.End, p.Name, err
+}
+
+// Decode is DecodePart returning the trailer checksum as well.
+func Decode(lines []string) (Part, error) {
+	var p Part
 	in := false
+	multipart := false
 	for _, l := range lines {
 		if strings.HasPrefix(l, "=ybegin") {
 			in = true
 			// parse name=...
 			if i := strings.Index(l, " name="); i >= 0 {
-				name = strings.TrimSpace(l[i+6:])
+				p.Name = strings.TrimSpace(l[i+6:])
 			}
+			multipart = strings.Contains(l, " part=")
 			continue
 		}
 		if !in {
@@ -28,26 +68,40 @@ func DecodePart(lines []string) (data []byte, begin int, end int, name string, e
 		if strings.HasPrefix(l, "=ypart") {
 			// parse begin/end
 			// =ypart begin=1 end=716800
+			multipart = true
 			fields := strings.Fields(l)
 			for _, f := range fields {
 				if strings.HasPrefix(f, "begin=") {
-					begin, _ = strconv.Atoi(strings.TrimPrefix(f, "begin="))
+					p.Begin, _ = strconv.Atoi(strings.TrimPrefix(f, "begin="))
 				}
 				if strings.HasPrefix(f, "end=") {
-					end, _ = strconv.Atoi(strings.TrimPrefix(f, "end="))
+					p.End, _ = strconv.Atoi(strings.TrimPrefix(f, "end="))
 				}
 			}
 			continue
 		}
 		if strings.HasPrefix(l, "=yend") {
-			return data, begin, end, name, nil
+			// =yend size=716800 part=1 pcrc32=ab12cd34 [crc32=...]
+			// crc32 covers the whole file, so it only checks Data on single-part posts.
+			key := "crc32="
+			if multipart {
+				key = "pcrc32="
+			}
+			for _, f := range strings.Fields(l) {
+				if strings.HasPrefix(f, key) {
+					if v, err := strconv.ParseUint(strings.TrimPrefix(f, key), 16, 32); err == nil {
+						p.CRC32, p.HasCRC = uint32(v), true
+					}
+				}
+			}
+			return p, nil
 		}
 
 		// payload line
 		decoded := decodeLine(l)
-		data = append(data, decoded...)
+		p.Data = append(p.Data, decoded...)
 	}
-	return nil, 0, 0, name, errors.New("invalid yenc: missing yend")
+	return Part{Name: p.Name}, ErrMissingYEnd
 }
 
 func decodeLine(l string) []byte {